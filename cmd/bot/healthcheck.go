@@ -0,0 +1,32 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+)
+
+// probeHealth calls /healthz of a bot running on this host with the same config.
+func probeHealth(cfg *config.Config) error {
+	scheme := "http"
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Web.TLS.Enabled() {
+		scheme = "https"
+		// the certificate is issued for the public name, not for localhost
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+
+	resp, err := client.Get(fmt.Sprintf("%s://127.0.0.1:%d/healthz", scheme, cfg.Web.Port))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
@@ -11,19 +11,26 @@ import (
 	"github.com/camuig/rus-trader/internal/ai"
 	"github.com/camuig/rus-trader/internal/broker"
 	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/corpevents"
+	"github.com/camuig/rus-trader/internal/events"
 	"github.com/camuig/rus-trader/internal/executor"
 	"github.com/camuig/rus-trader/internal/guard"
 	"github.com/camuig/rus-trader/internal/logger"
 	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/newsstore"
+	"github.com/camuig/rus-trader/internal/notify"
+	"github.com/camuig/rus-trader/internal/regime"
 	"github.com/camuig/rus-trader/internal/scheduler"
 	"github.com/camuig/rus-trader/internal/storage"
-	"github.com/camuig/rus-trader/internal/telegram"
+	"github.com/camuig/rus-trader/internal/universe"
 	"github.com/camuig/rus-trader/internal/web"
 )
 
 func main() {
 	configPath := flag.String("config", "config.yaml", "path to config file")
 	dbPath := flag.String("db", "data/rus-trader.db", "path to SQLite database")
+	dev := flag.Bool("dev", false, "reload templates and static files from internal/web on every request")
+	healthcheck := flag.Bool("healthcheck", false, "probe /healthz of the running bot and exit (Docker HEALTHCHECK)")
 	flag.Parse()
 
 	// Load config
@@ -33,6 +40,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *healthcheck {
+		if err := probeHealth(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "unhealthy: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *dev {
+		cfg.Web.DevAssetsDir = "internal/web"
+	}
+
 	// Init logger
 	log := logger.New(cfg.Logging.Level)
 
@@ -63,13 +82,32 @@ func main() {
 	log.Info("broker connected", "account_id", bc.AccountID())
 
 	// Init services
-	aiClient := ai.NewDeepSeekClient(cfg, log)
-	notifier := telegram.NewNotifier(cfg, log)
-	exec := executor.NewExecutor(bc, repo, notifier, cfg, log)
-	moexClient := moex.NewClient(log)
-	tradeGuard := guard.NewTradeGuard(repo, cfg, log)
-	sched := scheduler.NewScheduler(bc, moexClient, aiClient, exec, repo, notifier, tradeGuard, cfg, log)
-	webServer := web.NewServer(bc, repo, cfg, log)
+	aiClient, err := ai.NewDeepSeekClient(cfg, log)
+	if err != nil {
+		log.Error("AI client init failed", "error", err)
+		os.Exit(1)
+	}
+	notifier, err := notify.NewDispatcher(cfg, log)
+	if err != nil {
+		log.Error("notifications init failed", "error", err)
+		os.Exit(1)
+	}
+	bus := events.NewBus()
+	exec := executor.NewExecutor(bc, repo, notifier, bus, cfg, log)
+	moexClient := moex.NewClient(cfg.MOEX, log)
+	tickerNames := moex.NewNameDirectory(cfg.News, log)
+	newsFeeds := moex.NewNewsFeeds(moexClient, cfg.News, log)
+	seenNews := newsstore.NewStore(repo, cfg.News, log)
+	calendar := corpevents.NewCalendar(bc, moexClient, cfg, log)
+	market := regime.NewMonitor(moexClient, cfg.Market, log)
+	tickerUniverse := universe.NewUniverse(moexClient, repo, cfg, log)
+	tradeGuard := guard.NewTradeGuard(repo, bus, cfg, log)
+	sched := scheduler.NewScheduler(bc, moexClient, tickerNames, newsFeeds, seenNews, calendar, market, tickerUniverse, aiClient, exec, repo, notifier, bus, tradeGuard, cfg, log)
+	webServer, err := web.NewServer(bc, repo, bus, sched, aiClient, notifier, cfg, log)
+	if err != nil {
+		log.Error("web server init failed", "error", err)
+		os.Exit(1)
+	}
 
 	// Start scheduler in goroutine
 	go sched.Run(ctx)
@@ -104,5 +142,8 @@ func main() {
 	}
 
 	notifier.NotifyStatus("🛑 Rus-Trader остановлен")
+	if err := notifier.Close(shutdownCtx); err != nil {
+		log.Error("pending notifications not delivered", "error", err)
+	}
 	log.Info("rus-trader stopped")
 }
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/history"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// fetchhistory downloads MOEX ISS bars of shares into the history_bars table,
+// CSV or Parquet files. Rerunning it continues each ticker after its last bar.
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to config file")
+	dbPath := flag.String("db", "data/rus-trader.db", "path to SQLite database")
+	csvDir := flag.String("csv", "", "write CSV files to this directory instead of the database")
+	parquetDir := flag.String("parquet", "", "write Parquet datasets to this directory instead of the database")
+	tickers := flag.String("tickers", "", "comma-separated tickers, e.g. SBER,GAZP")
+	fromFlag := flag.String("from", "", "first day, YYYY-MM-DD")
+	tillFlag := flag.String("till", "", "last day, YYYY-MM-DD (default: yesterday)")
+	source := flag.String("source", "candles", "candles (any interval) or history (daily end-of-day results)")
+	intervalFlag := flag.String("interval", "1d", "candle interval: 1m, 10m, 1h, 1d, 1w or 1mo")
+	rps := flag.Float64("rps", 4, "ISS requests per second")
+	flag.Parse()
+
+	list := splitTickers(*tickers)
+	if len(list) == 0 || *fromFlag == "" {
+		fmt.Fprintln(os.Stderr, "usage: fetchhistory -tickers SBER,GAZP -from 2020-01-01 [-till 2024-12-31] [-interval 1d] [-csv dir | -parquet dir]")
+		os.Exit(2)
+	}
+	if *csvDir != "" && *parquetDir != "" {
+		fmt.Fprintln(os.Stderr, "-csv and -parquet are mutually exclusive")
+		os.Exit(2)
+	}
+	from, err := time.Parse("2006-01-02", *fromFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -from: %v\n", err)
+		os.Exit(2)
+	}
+	// today's bars are still forming and would be stored incomplete
+	y, m, d := time.Now().AddDate(0, 0, -1).Date()
+	till := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if *tillFlag != "" {
+		if till, err = time.Parse("2006-01-02", *tillFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -till: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	interval, ok := moex.ParseInterval(*intervalFlag)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown -interval %q\n", *intervalFlag)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	log := logger.New(cfg.Logging.Level)
+	h := moex.NewHistory(moex.NewClient(cfg.MOEX, log), *rps)
+
+	var fetch history.Fetch
+	switch *source {
+	case "candles":
+		fetch = func(ctx context.Context, ticker string, from, till time.Time, page func([]moex.Candle) error) error {
+			return h.Candles(ctx, ticker, interval, from, till, page)
+		}
+	case "history":
+		if interval.Name != "1d" {
+			fmt.Fprintln(os.Stderr, "-source history has daily bars only")
+			os.Exit(2)
+		}
+		fetch = h.Daily
+	default:
+		fmt.Fprintf(os.Stderr, "unknown -source %q\n", *source)
+		os.Exit(2)
+	}
+
+	var sink history.Sink
+	switch {
+	case *csvDir != "":
+		if sink, err = history.NewCSVSink(*csvDir, interval.Name); err != nil {
+			fmt.Fprintf(os.Stderr, "csv error: %v\n", err)
+			os.Exit(1)
+		}
+	case *parquetDir != "":
+		if sink, err = history.NewParquetSink(*parquetDir, interval.Name); err != nil {
+			fmt.Fprintf(os.Stderr, "parquet error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		db, err := storage.NewDatabase(*dbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "database error: %v\n", err)
+			os.Exit(1)
+		}
+		sink = history.NewStoreSink(storage.NewRepository(db), interval.Name)
+	}
+
+	// Ctrl+C keeps the pages already written; the next run continues from them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := 0
+	for _, r := range history.NewDownloader(fetch, sink, log).Run(ctx, list, from, till) {
+		status := "ok"
+		if r.Err != nil {
+			status = "error: " + r.Err.Error()
+			failed++
+		}
+		var notes []string
+		if r.Backfilled {
+			notes = append(notes, "backfilled")
+		}
+		if r.Resumed {
+			notes = append(notes, "resumed")
+		}
+		note := ""
+		if len(notes) > 0 {
+			note = " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Printf("  %-6s from %s%s: %d bars, %s\n", r.Ticker, r.From.Format("2006-01-02"), note, r.Bars, status)
+		if !r.Gap.IsZero() {
+			// files are only appended to; the database takes the missing days
+			fmt.Printf("         warning: stored bars start on %s, the days from %s are not downloaded; move the files of %s away to download them\n",
+				r.Gap.Format("2006-01-02"), from.Format("2006-01-02"), r.Ticker)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func splitTickers(s string) []string {
+	var out []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashpass prints values for the web.auth section of the config:
+// a bcrypt hash of a password read from stdin, or a new API token with its SHA-256.
+func main() {
+	token := flag.Bool("token", false, "generate a random API token instead of hashing a password")
+	flag.Parse()
+
+	if *token {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			fmt.Fprintf(os.Stderr, "generate token error: %v\n", err)
+			os.Exit(1)
+		}
+		t := base64.RawURLEncoding.EncodeToString(buf)
+		sum := sha256.Sum256([]byte(t))
+		fmt.Printf("token:        %s\n", t)
+		fmt.Printf("token_sha256: %s\n", hex.EncodeToString(sum[:]))
+		return
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintf(os.Stderr, "read password error: %v\n", err)
+		os.Exit(1)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		fmt.Fprintln(os.Stderr, "empty password")
+		os.Exit(1)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hash error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(hash))
+}
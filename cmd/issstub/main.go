@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/moex/moextest"
+)
+
+// issstub serves the recorded MOEX ISS responses and the recorded RSS feed,
+// for running the bot with moex.iss_url pointed at it.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8099", "listen address")
+	recorded := flag.Bool("recorded", false, "serve news times as recorded instead of moving them to now")
+	flag.Parse()
+
+	h := moextest.NewHandler()
+	if !*recorded {
+		h.Now = time.Now
+	}
+	fmt.Printf("moex.iss_url: http://%s/iss\n", *addr)
+	fmt.Printf("rss feed:     http://%s/rss/interfax.xml\n", *addr)
+	if err := http.ListenAndServe(*addr, h); err != nil {
+		fmt.Fprintf(os.Stderr, "listen error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	openai "github.com/sashabaranov/go-openai"
 
@@ -14,25 +15,39 @@ import (
 )
 
 type DeepSeekClient struct {
-	client *openai.Client
-	model  string
-	cfg    *config.Config
-	logger *logger.Logger
+	client  *openai.Client
+	model   string
+	prompts *PromptSet
+	cfg     *config.Config
+	logger  *logger.Logger
 }
 
-func NewDeepSeekClient(cfg *config.Config, log *logger.Logger) *DeepSeekClient {
+func NewDeepSeekClient(cfg *config.Config, log *logger.Logger) (*DeepSeekClient, error) {
 	ocfg := openai.DefaultConfig(cfg.DeepSeek.APIKey)
 	ocfg.BaseURL = "https://api.deepseek.com/v1"
 
-	return &DeepSeekClient{
-		client: openai.NewClientWithConfig(ocfg),
-		model:  cfg.DeepSeek.Model,
-		cfg:    cfg,
-		logger: log,
+	prompts, err := NewPromptSet(cfg.DeepSeek.Prompts)
+	if err != nil {
+		return nil, fmt.Errorf("load prompts: %w", err)
 	}
+	log.Info("prompt variants loaded", "versions", prompts.Versions())
+
+	return &DeepSeekClient{
+		client:  openai.NewClientWithConfig(ocfg),
+		model:   cfg.DeepSeek.Model,
+		prompts: prompts,
+		cfg:     cfg,
+		logger:  log,
+	}, nil
 }
 
-func (d *DeepSeekClient) Analyze(ctx context.Context, req *AnalysisRequest, todayTraded []string) ([]AIDecision, string, error) {
+// Ping checks that the API is reachable and the key is accepted by listing models.
+func (d *DeepSeekClient) Ping(ctx context.Context) error {
+	_, err := d.client.ListModels(ctx)
+	return err
+}
+
+func (d *DeepSeekClient) Analyze(ctx context.Context, req *AnalysisRequest, todayTraded []string) (*AnalysisResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, d.cfg.DeepSeekTimeout())
 	defer cancel()
 
@@ -43,47 +58,272 @@ func (d *DeepSeekClient) Analyze(ctx context.Context, req *AnalysisRequest, toda
 		MaxWorldNewsItems:   d.cfg.DeepSeek.MaxWorldNewsItems,
 		MaxNewsTitleChars:   d.cfg.DeepSeek.MaxNewsTitleChars,
 	}
-	userPrompt := BuildUserPrompt(req, todayTraded, limits)
+	prompt := d.prompts.Pick()
+	result := &AnalysisResult{Model: d.model, PromptVersion: prompt.Version}
+
+	params := NewPromptParams(d.cfg)
+	var tools []openai.Tool
+	if req.Tools != nil {
+		params.ToolsEnabled = true
+		tools = agentTools()
+	}
+	system, err := prompt.System(params)
+	if err != nil {
+		return result, err
+	}
+	userPrompt, err := prompt.User(req, todayTraded, limits, params)
+	if err != nil {
+		return result, err
+	}
+	result.SystemPrompt, result.UserPrompt = system, userPrompt
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: system},
+		{Role: openai.ChatMessageRoleUser, Content: userPrompt},
+	}
 
 	d.logger.Info("sending analysis request to DeepSeek",
+		"prompt_version", prompt.Version,
 		"tickers", len(req.Tickers),
 		"positions", len(req.Positions),
-		"prompt_length", len([]rune(userPrompt)))
-
-	stream, err := d.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model: d.model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
-			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
-		},
-	})
+		"prompt_length", len([]rune(userPrompt)),
+		"tools", len(tools))
+
+	budget := d.toolBudget()
+	start := time.Now()
+	var reasoning []string
+	var content string
+	var answerStart time.Time
+	executed := 0
+	forceAnswer := false
+
+	for {
+		round, err := d.streamRound(ctx, messages, tools, forceAnswer)
+		d.addRoundUsage(result, round)
+		if round.reasoning != "" {
+			reasoning = append(reasoning, round.reasoning)
+		}
+		content, answerStart = round.content, round.answerStart
+		if err != nil {
+			d.finishResult(result, start, answerStart, strings.Join(reasoning, "\n\n"), content)
+			d.priceUsage(result)
+			return result, err
+		}
+		if len(round.toolCalls) == 0 || forceAnswer {
+			break
+		}
+
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:      openai.ChatMessageRoleAssistant,
+			Content:   round.content,
+			ToolCalls: round.toolCalls,
+		})
+		for _, call := range round.toolCalls {
+			var record ToolCallRecord
+			if budget.exhausted(executed, result.ToolDuration) {
+				record = ToolCallRecord{
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+					Error:     "tool budget exhausted, answer with the data you have",
+				}
+			} else {
+				record = executeToolCall(ctx, req.Tools, call, budget)
+				executed++
+				result.ToolDuration += time.Duration(record.DurationMs) * time.Millisecond
+				d.logger.Info("AI tool call",
+					"tool", record.Name, "arguments", record.Arguments,
+					"duration_ms", record.DurationMs, "error", record.Error)
+			}
+			result.ToolCalls = append(result.ToolCalls, record)
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    record.toolMessageContent(),
+				ToolCallID: call.ID,
+			})
+		}
+		if budget.exhausted(executed, result.ToolDuration) {
+			forceAnswer = true
+		}
+	}
+
+	d.finishResult(result, start, answerStart, strings.Join(reasoning, "\n\n"), content)
+	d.priceUsage(result)
+	d.logger.Info("received AI response",
+		"length", len(result.RawResponse),
+		"reasoning_length", len(result.Reasoning),
+		"reasoning_phase", result.ReasoningDuration.Round(time.Millisecond),
+		"answer_phase", result.AnswerDuration.Round(time.Millisecond),
+		"tool_calls", len(result.ToolCalls),
+		"prompt_tokens", result.Usage.PromptTokens,
+		"completion_tokens", result.Usage.CompletionTokens,
+		"reasoning_tokens", result.Usage.ReasoningTokens,
+		"estimated", result.Usage.Estimated,
+		"latency", result.Usage.Latency.Round(time.Millisecond),
+		"cost_rub", fmt.Sprintf("%.2f", result.CostRub))
+	d.logger.Debug("AI raw response", "content", result.RawResponse)
+	d.logger.Debug("AI reasoning", "content", result.Reasoning)
+
+	decisions, err := ParseDecisions(result.RawResponse)
+	if err != nil {
+		return result, fmt.Errorf("parse AI response: %w", err)
+	}
+	for i := range decisions {
+		decisions[i].PromptVersion = prompt.Version
+	}
+	result.Decisions = decisions
+
+	return result, nil
+}
+
+// completionRound is one streamed chat completion within an analysis cycle.
+// A cycle has several rounds when the model calls tools.
+type completionRound struct {
+	content     string
+	reasoning   string
+	toolCalls   []openai.ToolCall
+	usage       Usage
+	answerStart time.Time // first answer token, zero if none
+}
+
+// streamRound sends the conversation and collects the streamed answer, reasoning and tool calls.
+// The returned round is never nil, so partial output and usage survive stream errors.
+func (d *DeepSeekClient) streamRound(ctx context.Context, messages []openai.ChatCompletionMessage, tools []openai.Tool, forceAnswer bool) (*completionRound, error) {
+	round := &completionRound{}
+	start := time.Now()
+
+	chatReq := openai.ChatCompletionRequest{
+		Model:         d.model,
+		Messages:      messages,
+		Tools:         tools,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	}
+	if len(tools) > 0 && forceAnswer {
+		chatReq.ToolChoice = "none"
+	}
+
+	stream, err := d.client.CreateChatCompletionStream(ctx, chatReq)
 	if err != nil {
-		return nil, "", fmt.Errorf("deepseek API call: %w", err)
+		round.usage.Latency = time.Since(start)
+		return round, fmt.Errorf("deepseek API call: %w", err)
 	}
 	defer stream.Close()
 
-	var content strings.Builder
+	var content, reasoning strings.Builder
+	var apiUsage *openai.Usage
+	toolCalls := make(map[int]*openai.ToolCall)
+	var streamErr error
 	for {
 		chunk, err := stream.Recv()
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			return nil, content.String(), fmt.Errorf("deepseek stream: %w", err)
+			streamErr = fmt.Errorf("deepseek stream: %w", err)
+			break
+		}
+		if chunk.Usage != nil {
+			apiUsage = chunk.Usage
 		}
 		if len(chunk.Choices) > 0 {
-			content.WriteString(chunk.Choices[0].Delta.Content)
+			delta := chunk.Choices[0].Delta
+			if delta.Content != "" && round.answerStart.IsZero() {
+				round.answerStart = time.Now()
+			}
+			content.WriteString(delta.Content)
+			reasoning.WriteString(delta.ReasoningContent)
+			mergeToolCallDeltas(toolCalls, delta.ToolCalls)
 		}
 	}
 
-	rawResponse := content.String()
-	d.logger.Info("received AI response", "length", len(rawResponse))
-	d.logger.Debug("AI raw response", "content", rawResponse)
+	round.content = content.String()
+	round.reasoning = strings.TrimSpace(reasoning.String())
+	round.toolCalls = orderedToolCalls(toolCalls)
+	round.usage = roundUsage(apiUsage, messages, round)
+	round.usage.Latency = time.Since(start)
+	return round, streamErr
+}
 
-	decisions, err := ParseDecisions(rawResponse)
-	if err != nil {
-		return nil, rawResponse, fmt.Errorf("parse AI response: %w", err)
+// roundUsage converts token usage reported by the API, falling back to a local
+// estimate when the stream ended without a usage chunk.
+func roundUsage(apiUsage *openai.Usage, messages []openai.ChatCompletionMessage, round *completionRound) Usage {
+	var u Usage
+	if apiUsage != nil && apiUsage.TotalTokens > 0 {
+		u.PromptTokens = apiUsage.PromptTokens
+		u.CompletionTokens = apiUsage.CompletionTokens
+		if apiUsage.PromptTokensDetails != nil {
+			u.CachedTokens = apiUsage.PromptTokensDetails.CachedTokens
+		}
+		if apiUsage.CompletionTokensDetails != nil {
+			u.ReasoningTokens = apiUsage.CompletionTokensDetails.ReasoningTokens
+		}
+		return u
 	}
 
-	return decisions, rawResponse, nil
+	var prompt strings.Builder
+	for _, m := range messages {
+		prompt.WriteString(m.Content)
+	}
+	u.Estimated = true
+	u.PromptTokens = EstimateTokens(prompt.String())
+	reasoning := round.reasoning
+	if reasoning == "" {
+		reasoning, _ = ExtractThinkTags(round.content)
+	}
+	u.ReasoningTokens = EstimateTokens(reasoning)
+	u.CompletionTokens = u.ReasoningTokens + EstimateTokens(StripThinkTags(round.content))
+	for _, call := range round.toolCalls {
+		u.CompletionTokens += EstimateTokens(call.Function.Name + call.Function.Arguments)
+	}
+	return u
+}
+
+// addRoundUsage accumulates a round's token usage and latency into the cycle result.
+func (d *DeepSeekClient) addRoundUsage(result *AnalysisResult, round *completionRound) {
+	result.Usage.PromptTokens += round.usage.PromptTokens
+	result.Usage.CachedTokens += round.usage.CachedTokens
+	result.Usage.CompletionTokens += round.usage.CompletionTokens
+	result.Usage.ReasoningTokens += round.usage.ReasoningTokens
+	result.Usage.Latency += round.usage.Latency
+	if round.usage.Estimated {
+		result.Usage.Estimated = true
+	}
+}
+
+// finishResult stores the answer and reasoning streams and splits the call into phases:
+// reasoning runs from the request until the first answer token, answer from there to the end.
+// Models that inline <think> tags instead of streaming reasoning_content are handled too.
+func (d *DeepSeekClient) finishResult(result *AnalysisResult, start, answerStart time.Time, reasoning, content string) {
+	result.RawResponse = content
+	result.Reasoning = strings.TrimSpace(reasoning)
+	if result.Reasoning == "" {
+		result.Reasoning, _ = ExtractThinkTags(content)
+	}
+
+	end := time.Now()
+	if answerStart.IsZero() {
+		result.ReasoningDuration = end.Sub(start)
+		return
+	}
+	result.ReasoningDuration = answerStart.Sub(start)
+	result.AnswerDuration = end.Sub(answerStart)
+}
+
+// priceUsage converts the accumulated token usage to USD and RUB.
+func (d *DeepSeekClient) priceUsage(result *AnalysisResult) {
+	price, ok := d.cfg.ModelPrice(d.model)
+	if !ok {
+		d.logger.Debug("no price configured for model, cost not tracked", "model", d.model)
+		return
+	}
+	result.CostUSD = result.Usage.CostUSD(price)
+	result.CostRub = result.CostUSD * d.cfg.DeepSeek.UsdRubRate
+}
+
+func (d *DeepSeekClient) toolBudget() ToolBudget {
+	tc := d.cfg.DeepSeek.Tools
+	return ToolBudget{
+		MaxCalls:       tc.MaxCalls,
+		CallTimeout:    time.Duration(tc.CallTimeoutSeconds) * time.Second,
+		TotalTimeout:   time.Duration(tc.TotalTimeoutSeconds) * time.Second,
+		MaxResultChars: tc.MaxResultChars,
+	}
 }
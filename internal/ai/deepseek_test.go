@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+)
+
+// newStreamServer serves successive chat completion streams, one per request.
+func newStreamServer(t *testing.T, rounds [][]string, requests *[]openai.ChatCompletionRequest) *httptest.Server {
+	t.Helper()
+	var n int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		*requests = append(*requests, req)
+
+		i := int(atomic.AddInt32(&n, 1)) - 1
+		if i >= len(rounds) {
+			t.Errorf("unexpected request #%d", i+1)
+			i = len(rounds) - 1
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range rounds[i] {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+}
+
+func newTestDeepSeekClient(t *testing.T, url string, tools config.AgentToolsConfig) *DeepSeekClient {
+	t.Helper()
+	cfg := &config.Config{
+		DeepSeek: config.DeepSeekConfig{
+			Model:          "deepseek-reasoner",
+			TimeoutSeconds: 10,
+			UsdRubRate:     100,
+			Pricing: map[string]config.ModelPrice{
+				"deepseek-reasoner": {InputPerMTok: 1, OutputPerMTok: 2},
+			},
+			Tools: tools,
+		},
+	}
+	ocfg := openai.DefaultConfig("test")
+	ocfg.BaseURL = url
+	prompts, err := NewPromptSet(cfg.DeepSeek.Prompts)
+	if err != nil {
+		t.Fatalf("load prompts: %v", err)
+	}
+	return &DeepSeekClient{
+		client:  openai.NewClientWithConfig(ocfg),
+		model:   cfg.DeepSeek.Model,
+		prompts: prompts,
+		cfg:     cfg,
+		logger:  logger.New("error"),
+	}
+}
+
+func TestAnalyze_CapturesReasoningAndUsage(t *testing.T) {
+	var requests []openai.ChatCompletionRequest
+	srv := newStreamServer(t, [][]string{{
+		`{"choices":[{"index":0,"delta":{"reasoning_content":"SBER перепродан."}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"[{\"action\":\"BUY\",\"ticker\":\"SBER\",\"confidence\":80}]"}}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":1000,"completion_tokens":500,"total_tokens":1500,"completion_tokens_details":{"reasoning_tokens":400}}}`,
+	}}, &requests)
+	defer srv.Close()
+
+	d := newTestDeepSeekClient(t, srv.URL, config.AgentToolsConfig{})
+	result, err := d.Analyze(context.Background(), &AnalysisRequest{}, nil)
+	if err != nil {
+		t.Fatalf("Analyze error: %v", err)
+	}
+
+	if len(result.Decisions) != 1 || result.Decisions[0].Ticker != "SBER" {
+		t.Fatalf("unexpected decisions %+v", result.Decisions)
+	}
+	if result.Reasoning != "SBER перепродан." {
+		t.Fatalf("unexpected reasoning %q", result.Reasoning)
+	}
+	if result.Usage.Estimated || result.Usage.PromptTokens != 1000 || result.Usage.ReasoningTokens != 400 {
+		t.Fatalf("unexpected usage %+v", result.Usage)
+	}
+	// (1000*1 + 500*2) / 1M USD * 100 RUB/USD
+	if result.CostRub < 0.19999 || result.CostRub > 0.20001 {
+		t.Fatalf("unexpected cost %f", result.CostRub)
+	}
+	if len(requests) != 1 || len(requests[0].Tools) != 0 {
+		t.Fatalf("expected single request without tools")
+	}
+}
+
+func TestAnalyze_RunsToolLoopWithinBudget(t *testing.T) {
+	var requests []openai.ChatCompletionRequest
+	srv := newStreamServer(t, [][]string{
+		{
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_candles","arguments":"{\"ticker\":\"SBER\","}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"interval\":\"1h\"}"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_order_book","arguments":"{\"ticker\":\"SBER\"}"}}]}}]}`,
+		},
+		{`{"choices":[{"index":0,"delta":{"content":"[]"}}]}`},
+	}, &requests)
+	defer srv.Close()
+
+	d := newTestDeepSeekClient(t, srv.URL, config.AgentToolsConfig{Enabled: true, MaxCalls: 1, MaxResultChars: 1000})
+	backend := &fakeToolBackend{}
+	result, err := d.Analyze(context.Background(), &AnalysisRequest{Tools: backend}, nil)
+	if err != nil {
+		t.Fatalf("Analyze error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 rounds, got %d", len(requests))
+	}
+	if len(result.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool call records, got %d", len(result.ToolCalls))
+	}
+	if result.ToolCalls[0].Error != "" || backend.lastTicker != "SBER" {
+		t.Fatalf("expected first call executed, got %+v", result.ToolCalls[0])
+	}
+	if !strings.Contains(result.ToolCalls[1].Error, "budget exhausted") {
+		t.Fatalf("expected second call rejected by budget, got %+v", result.ToolCalls[1])
+	}
+	if requests[1].ToolChoice != "none" {
+		t.Fatalf("expected final round to force an answer, got tool_choice %v", requests[1].ToolChoice)
+	}
+	msgs := requests[1].Messages
+	last := msgs[len(msgs)-2]
+	if last.Role != openai.ChatMessageRoleTool || last.ToolCallID != "call_1" || last.Content != "[1,2,3]" {
+		t.Fatalf("unexpected tool message %+v", last)
+	}
+	if !result.Usage.Estimated {
+		t.Fatalf("expected estimated usage when API reports none")
+	}
+}
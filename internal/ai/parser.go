@@ -17,6 +17,23 @@ func StripThinkTags(text string) string {
 	return strings.TrimSpace(thinkTagRegex.ReplaceAllString(text, ""))
 }
 
+// ExtractThinkTags splits inline <think> blocks from the answer.
+// Returns the concatenated reasoning and the remaining answer text.
+func ExtractThinkTags(text string) (reasoning, answer string) {
+	blocks := thinkTagRegex.FindAllString(text, -1)
+	if len(blocks) == 0 {
+		return "", strings.TrimSpace(text)
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		b = strings.TrimSuffix(strings.TrimPrefix(b, "<think>"), "</think>")
+		if b = strings.TrimSpace(b); b != "" {
+			parts = append(parts, b)
+		}
+	}
+	return strings.Join(parts, "\n\n"), StripThinkTags(text)
+}
+
 // stripCodeFences extracts content from markdown code fences if present.
 func stripCodeFences(text string) string {
 	if match := codeFenceRegex.FindStringSubmatch(text); len(match) > 1 {
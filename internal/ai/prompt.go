@@ -6,205 +6,8 @@ import (
 	"time"
 )
 
-const systemPrompt = `Роль: Трейдер MOEX (горизонт 1-2 дня).
-Задача: Анализ технических индикаторов, OHLCV, объемов и новостей для принятия решений (BUY, SELL, HOLD).
-
-Алгоритм анализа:
-1. Управление позициями: HOLD, если цена между SL и TP. SELL только при пробое SL, фундаментальном негативе или отсутствии прогресса > 2 дней. Шум ±0.5% игнорировать.
-2. Открытие (BUY): Только при Confidence ≥65. Цель — прибыль >1.5% (учитывай комиссию 0.08%). Тикеры, которые уже в портфеле или торговались сегодня, покупать только если это сильно обосновано.
-3. Технический анализ:
-   - RSI: <30 перепроданность (потенциал BUY), >70 перекупленность (избегать BUY, рассмотреть SELL)
-   - EMA: EMA9 > EMA21 = восходящий тренд, EMA9 < EMA21 = нисходящий
-   - ATR: использовать для расчёта SL (1.5-2 × ATR от входа)
-   - Объём: RelVol > 1.5 подтверждает движение, < 0.5 — слабый сигнал
-   - Уровни поддержки/сопротивления: учитывать при выставлении SL/TP
-4. Риск-менеджмент: Лимит на позицию — 10% депо. Обязательны расчетные SL/TP.
-5. Время суток: Избегать BUY в последний час торгов (после 17:50 MSK) — риск гэпа на открытии.
-6. Статистика: Учитывай win rate и серию убытков. При серии убытков — повышай порог confidence.
-
-Требования к ответу:
-- Строго JSON массив объектов.
-- reasoning: 1 краткое предложение.
-- Если сделок нет — вернуть [].
-
-Ответ строго в JSON (массив объектов):
-[
-  {
-    "action": "BUY",
-    "ticker": "SBER",
-    "stop_loss": 250.0,
-    "take_profit": 290.0,
-    "confidence": 80,
-    "reasoning": "Краткая причина (1 предложение)"
-  }
-]
-`
-
-func BuildUserPrompt(req *AnalysisRequest, todayTraded []string, limits PromptLimits) string {
-	limits = normalizePromptLimits(limits)
-
-	tail := "\nПроанализируй и выдай решения в JSON."
-	bodyLimit := limits.MaxChars - runeLen(tail)
-	if bodyLimit < 0 {
-		bodyLimit = limits.MaxChars
-	}
-
-	builder := &cappedBuilder{maxRunes: bodyLimit}
-
-	// Current time context
-	if !req.CurrentTime.IsZero() {
-		builder.WriteString(fmt.Sprintf("## Текущее время: %s MSK\n\n", req.CurrentTime.Format("02.01.2006 15:04")))
-	}
-
-	// Performance stats
-	if req.Stats.TradeCount7d > 0 {
-		builder.WriteString("## Статистика за 7 дней\n")
-		builder.WriteString(fmt.Sprintf("Сделок: %d, Win rate: %.0f%%, P&L: %+.2f ₽\n",
-			req.Stats.TradeCount7d, req.Stats.WinRate7d, req.Stats.TotalPnL7d))
-		builder.WriteString(fmt.Sprintf("Ср. прибыль: +%.2f ₽, Ср. убыток: %.2f ₽\n",
-			req.Stats.AvgProfit, req.Stats.AvgLoss))
-		if len(req.Stats.WorstTickers) > 0 {
-			builder.WriteString(fmt.Sprintf("Худшие тикеры: %s\n", strings.Join(req.Stats.WorstTickers, ", ")))
-		}
-		builder.WriteString("\n")
-	}
-
-	builder.WriteString("## Текущий портфель\n")
-	builder.WriteString(fmt.Sprintf("Общий баланс: %.2f ₽ / Доступно: %.2f ₽\n\n", req.TotalRub, req.AvailableRub))
-
-	if len(req.Positions) > 0 {
-		builder.WriteString("### Открытые позиции\n")
-		for _, p := range req.Positions {
-			changeSinceEntry := 0.0
-			if p.AvgPrice > 0 {
-				changeSinceEntry = (p.CurrentPrice - p.AvgPrice) / p.AvgPrice * 100
-			}
-			builder.WriteString(fmt.Sprintf("- %s: %.0f шт, вход %.2f, текущая %.2f (%+.2f%%), P&L %.2f",
-				p.Ticker, p.Quantity, p.AvgPrice, p.CurrentPrice, changeSinceEntry, p.PnL))
-			if tc, ok := req.OpenContext[p.Ticker]; ok {
-				builder.WriteString(fmt.Sprintf("\n  Открыта: %s (удержание %s)",
-					tc.OpenedAt.Format("02.01 15:04"), formatDuration(tc.OpenedAt)))
-				if tc.StopLossPrice > 0 || tc.TakeProfitPrice > 0 {
-					builder.WriteString(fmt.Sprintf("\n  План: SL=%.2f, TP=%.2f", tc.StopLossPrice, tc.TakeProfitPrice))
-					if tc.TakeProfitPrice > 0 && p.AvgPrice > 0 {
-						progress := (p.CurrentPrice - p.AvgPrice) / (tc.TakeProfitPrice - p.AvgPrice) * 100
-						builder.WriteString(fmt.Sprintf(" (прогресс к TP: %.0f%%)", progress))
-					}
-				}
-				if tc.Reasoning != "" {
-					builder.WriteString(fmt.Sprintf("\n  Гипотеза: %s", truncate(tc.Reasoning, 120)))
-				}
-			}
-			builder.WriteString("\n")
-		}
-		builder.WriteString("\n")
-	} else {
-		builder.WriteString("Открытых позиций нет.\n\n")
-	}
-
-	if len(req.RecentTrades) > 0 {
-		builder.WriteString("### Закрытые сделки за 24ч\n")
-		for i, t := range req.RecentTrades {
-			if i >= 8 {
-				builder.WriteString("- ...\n")
-				break
-			}
-			builder.WriteString(fmt.Sprintf("- %s: вход %.2f → выход %.2f, %d шт, P&L %+.2f, закрыта %s",
-				t.Ticker, t.EntryPrice, t.ExitPrice, t.Quantity, t.PnL,
-				t.ClosedAt.Format("02.01 15:04")))
-			if t.Reasoning != "" {
-				builder.WriteString(fmt.Sprintf("\n  Причина закрытия: %s", truncate(t.Reasoning, 120)))
-			}
-			builder.WriteString("\n")
-		}
-		builder.WriteString("\n")
-	}
-
-	if len(todayTraded) > 0 {
-		builder.WriteString("### Тикеры, проторгованные сегодня (НЕ покупать повторно!)\n")
-		builder.WriteString(strings.Join(todayTraded, ", "))
-		builder.WriteString("\n\n")
-	}
-
-	builder.WriteString("## OHLCV (TQBR)\n")
-	builder.WriteString("Ticker|Price|Per|Open|High|Low|Vol|Chg%\n")
-	for _, t := range req.Tickers {
-		periods := []struct {
-			name string
-			data PeriodData
-		}{
-			{"3h", t.Period3h},
-			{"1d", t.Period1d},
-			{"3d", t.Period3d},
-			{"1w", t.Period1w},
-		}
-		for i, p := range periods {
-			if i == 0 {
-				builder.WriteString(fmt.Sprintf("%s|%.2f|%s|%.2f|%.2f|%.2f|%s|%+.1f\n",
-					t.Ticker, t.LastPrice, p.name, p.data.Open, p.data.High, p.data.Low, formatVolume(p.data.Volume), p.data.ChangePct))
-			} else {
-				builder.WriteString(fmt.Sprintf("||%s|%.2f|%.2f|%.2f|%s|%+.1f\n",
-					p.name, p.data.Open, p.data.High, p.data.Low, formatVolume(p.data.Volume), p.data.ChangePct))
-			}
-		}
-	}
-	builder.WriteString("\n")
-
-	// Technical indicators section
-	builder.WriteString("## Индикаторы\n")
-	builder.WriteString("Ticker|RSI14|EMA9|EMA21|ATR14|RelVol|Support|Resist\n")
-	for _, t := range req.Tickers {
-		ind := t.Indicators
-		builder.WriteString(fmt.Sprintf("%s|%.1f|%.2f|%.2f|%.2f|%.1fx|%.2f|%.2f\n",
-			t.Ticker, ind.RSI14, ind.EMA9, ind.EMA21, ind.ATR14,
-			ind.RelVolume, ind.Support, ind.Resistance))
-	}
-	builder.WriteString("\n")
-
-	builder.WriteString(buildTickerBriefSection(req.Tickers, limits.MaxTickerBriefChars, limits.MaxNewsTitleChars))
-	builder.WriteString(buildTickerNewsSection(req.Tickers, limits.MaxTickerNewsItems, limits.MaxNewsTitleChars))
-	builder.WriteString(buildWorldNewsSection(req.GlobalNews, limits.MaxWorldNewsItems, limits.MaxNewsTitleChars))
-
-	prompt := builder.String() + tail
-	if runeLen(prompt) > limits.MaxChars {
-		return truncate(prompt, limits.MaxChars)
-	}
-	return prompt
-}
-
-type cappedBuilder struct {
-	sb       strings.Builder
-	maxRunes int
-	used     int
-}
-
-func (b *cappedBuilder) WriteString(s string) {
-	if s == "" {
-		return
-	}
-	if b.maxRunes <= 0 {
-		b.sb.WriteString(s)
-		return
-	}
-	if b.used >= b.maxRunes {
-		return
-	}
-
-	r := []rune(s)
-	remaining := b.maxRunes - b.used
-	if len(r) > remaining {
-		b.sb.WriteString(string(r[:remaining]))
-		b.used = b.maxRunes
-		return
-	}
-
-	b.sb.WriteString(s)
-	b.used += len(r)
-}
-
-func (b *cappedBuilder) String() string {
-	return b.sb.String()
-}
+// FreshNewsTag prefixes headlines first seen in the current cycle.
+const FreshNewsTag = "[новое]"
 
 func normalizePromptLimits(l PromptLimits) PromptLimits {
 	if l.MaxChars <= 0 {
@@ -264,6 +67,22 @@ func buildTickerBriefSection(tickers []TickerAnalysis, maxSectionChars, maxTitle
 	return sb.String()
 }
 
+func buildCorporateEventsSection(tickers []TickerAnalysis) string {
+	var sb strings.Builder
+	for _, t := range tickers {
+		for _, e := range t.Events {
+			if sb.Len() == 0 {
+				sb.WriteString("## Корпоративные события\n")
+			}
+			fmt.Fprintf(&sb, "- %s: %s\n", t.Ticker, sanitizePromptLine(e))
+		}
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
 func buildTickerNewsSection(tickers []TickerAnalysis, maxItems, maxTitleChars int) string {
 	if maxItems <= 0 {
 		return ""
@@ -303,6 +122,18 @@ func buildTickerNewsSection(tickers []TickerAnalysis, maxItems, maxTitleChars in
 		sb.WriteString(item)
 		sb.WriteString("\n")
 	}
+	writeFreshNewsLegend(&sb, items)
+	var tones []string
+	for _, t := range tickers {
+		if len(t.News) > 0 && t.NewsSentiment != 0 {
+			tones = append(tones, fmt.Sprintf("%s %+.2f", t.Ticker, t.NewsSentiment))
+		}
+	}
+	if len(tones) > 0 {
+		sb.WriteString("Тональность новостей (-1..+1): ")
+		sb.WriteString(strings.Join(tones, ", "))
+		sb.WriteString("\n")
+	}
 	sb.WriteString("\n")
 	return sb.String()
 }
@@ -341,10 +172,21 @@ func buildWorldNewsSection(news []string, maxItems, maxTitleChars int) string {
 		sb.WriteString(item)
 		sb.WriteString("\n")
 	}
+	writeFreshNewsLegend(&sb, items)
 	sb.WriteString("\n")
 	return sb.String()
 }
 
+// writeFreshNewsLegend explains FreshNewsTag when any of the lines carries it.
+func writeFreshNewsLegend(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		if strings.Contains(line, FreshNewsTag) {
+			sb.WriteString(FreshNewsTag + " — появилось после прошлого цикла, остальное уже учитывалось ранее\n")
+			return
+		}
+	}
+}
+
 func sanitizePromptLine(s string) string {
 	s = strings.ReplaceAll(s, "\n", " ")
 	s = strings.ReplaceAll(s, "\r", " ")
@@ -0,0 +1,296 @@
+package ai
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/broker"
+	"github.com/camuig/rus-trader/internal/config"
+)
+
+//go:embed prompts
+var builtinPrompts embed.FS
+
+// DefaultPromptVersion is the built-in prompt used when no variants are configured.
+const DefaultPromptVersion = "v1"
+
+const (
+	systemPromptFile = "system.tmpl"
+	userPromptFile   = "user.tmpl"
+)
+
+// PromptParams are business rules bound into the prompt from config,
+// so the model is told the same thresholds the guard and executor enforce.
+type PromptParams struct {
+	MinConfidence          int
+	CommissionPct          float64 // per side
+	RoundTripCommissionPct float64 // entry + exit
+	MaxPositionRub         float64
+	MaxOpenPositions       int
+	DefaultStopLossPct     float64
+	DefaultTakeProfitPct   float64
+	NoLastHourBuy          bool
+	MarketContext          bool // the market regime is shown in the user prompt
+	CorporateEvents        bool // event dates are listed in the user prompt
+	ToolsEnabled           bool
+}
+
+// NewPromptParams binds prompt parameters from trading config.
+func NewPromptParams(cfg *config.Config) PromptParams {
+	t := cfg.Trading
+	return PromptParams{
+		MinConfidence:          t.MinConfidence,
+		CommissionPct:          t.CommissionPct,
+		RoundTripCommissionPct: t.CommissionPct * 2,
+		MaxPositionRub:         t.MaxPositionRub,
+		MaxOpenPositions:       t.MaxOpenPositions,
+		DefaultStopLossPct:     t.DefaultStopLossPct,
+		DefaultTakeProfitPct:   t.DefaultTakeProfitPct,
+		NoLastHourBuy:          t.NoLastHourBuy,
+		MarketContext:          len(cfg.Market.Instruments) > 0,
+		CorporateEvents:        len(cfg.CorporateEvents.Sources) > 0,
+	}
+}
+
+// PromptTemplate is one versioned pair of system and user prompt templates.
+type PromptTemplate struct {
+	Version string
+	system  *template.Template
+	user    *template.Template
+}
+
+// positionView is an open position with the derived values the user prompt shows.
+type positionView struct {
+	broker.PositionInfo
+	ChangePct   float64
+	HasContext  bool
+	OpenedAt    time.Time
+	StopLoss    float64
+	TakeProfit  float64
+	HasProgress bool
+	ProgressPct float64
+	Hypothesis  string
+}
+
+// userPromptData is the data passed to user.tmpl.
+type userPromptData struct {
+	PromptParams
+	CurrentTime      time.Time
+	Market           *MarketContext
+	Stats            PerformanceStats
+	TotalRub         float64
+	AvailableRub     float64
+	Positions        []positionView
+	RecentTrades     []RecentClosedTrade
+	MoreRecentTrades bool
+	TodayTraded      []string
+	Tickers          []TickerAnalysis
+
+	// Sections with their own size limits, rendered in Go.
+	Events       string
+	TickerBriefs string
+	TickerNews   string
+	WorldNews    string
+}
+
+const maxPromptRecentTrades = 8
+
+var promptFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"truncate": func(s string, n int) string { return truncate(s, n) },
+	"since":    formatDuration,
+	"volume":   formatVolume,
+	"period": func(p PeriodData) string {
+		return fmt.Sprintf("%.2f|%.2f|%.2f|%s|%+.1f", p.Open, p.High, p.Low, formatVolume(p.Volume), p.ChangePct)
+	},
+}
+
+// LoadPromptTemplate loads a prompt version from dir/<version>/ if present,
+// falling back to the built-in templates.
+func LoadPromptTemplate(dir, version string) (*PromptTemplate, error) {
+	var fsys fs.FS
+	if dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, version)); err == nil {
+			fsys = os.DirFS(filepath.Join(dir, version))
+		}
+	}
+	if fsys == nil {
+		sub, err := fs.Sub(builtinPrompts, "prompts/"+version)
+		if err != nil {
+			return nil, fmt.Errorf("prompt version %q: %w", version, err)
+		}
+		if _, err := fs.Stat(sub, systemPromptFile); err != nil {
+			return nil, fmt.Errorf("prompt version %q not found", version)
+		}
+		fsys = sub
+	}
+
+	system, err := template.New(systemPromptFile).Funcs(promptFuncs).ParseFS(fsys, systemPromptFile)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s/%s: %w", version, systemPromptFile, err)
+	}
+	user, err := template.New(userPromptFile).Funcs(promptFuncs).ParseFS(fsys, userPromptFile)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s/%s: %w", version, userPromptFile, err)
+	}
+	if user.Lookup("tail") == nil {
+		user, err = user.New("tail").Parse("")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &PromptTemplate{Version: version, system: system, user: user.Lookup(userPromptFile)}, nil
+}
+
+// System renders the system prompt.
+func (p *PromptTemplate) System(params PromptParams) (string, error) {
+	var sb strings.Builder
+	if err := p.system.Execute(&sb, params); err != nil {
+		return "", fmt.Errorf("render %s system prompt: %w", p.Version, err)
+	}
+	return sb.String(), nil
+}
+
+// User renders the user prompt, capped to limits.MaxChars.
+// The "tail" template is always kept after the cap so the final instruction is not cut off.
+func (p *PromptTemplate) User(req *AnalysisRequest, todayTraded []string, limits PromptLimits, params PromptParams) (string, error) {
+	limits = normalizePromptLimits(limits)
+	data := newUserPromptData(req, todayTraded, limits, params)
+
+	var body, tail strings.Builder
+	if err := p.user.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("render %s user prompt: %w", p.Version, err)
+	}
+	if err := p.user.ExecuteTemplate(&tail, "tail", data); err != nil {
+		return "", fmt.Errorf("render %s prompt tail: %w", p.Version, err)
+	}
+
+	bodyLimit := limits.MaxChars - runeLen(tail.String())
+	if bodyLimit < 0 {
+		bodyLimit = limits.MaxChars
+	}
+	prompt := truncateRunes(body.String(), bodyLimit) + tail.String()
+	if runeLen(prompt) > limits.MaxChars {
+		return truncate(prompt, limits.MaxChars), nil
+	}
+	return prompt, nil
+}
+
+func newUserPromptData(req *AnalysisRequest, todayTraded []string, limits PromptLimits, params PromptParams) userPromptData {
+	data := userPromptData{
+		PromptParams: params,
+		CurrentTime:  req.CurrentTime,
+		Market:       req.Market,
+		Stats:        req.Stats,
+		TotalRub:     req.TotalRub,
+		AvailableRub: req.AvailableRub,
+		RecentTrades: req.RecentTrades,
+		TodayTraded:  todayTraded,
+		Tickers:      req.Tickers,
+		Events:       buildCorporateEventsSection(req.Tickers),
+		TickerBriefs: buildTickerBriefSection(req.Tickers, limits.MaxTickerBriefChars, limits.MaxNewsTitleChars),
+		TickerNews:   buildTickerNewsSection(req.Tickers, limits.MaxTickerNewsItems, limits.MaxNewsTitleChars),
+		WorldNews:    buildWorldNewsSection(req.GlobalNews, limits.MaxWorldNewsItems, limits.MaxNewsTitleChars),
+	}
+	if len(data.RecentTrades) > maxPromptRecentTrades {
+		data.RecentTrades = data.RecentTrades[:maxPromptRecentTrades]
+		data.MoreRecentTrades = true
+	}
+
+	for _, pos := range req.Positions {
+		v := positionView{PositionInfo: pos}
+		if pos.AvgPrice > 0 {
+			v.ChangePct = (pos.CurrentPrice - pos.AvgPrice) / pos.AvgPrice * 100
+		}
+		if tc, ok := req.OpenContext[pos.Ticker]; ok {
+			v.HasContext = true
+			v.OpenedAt = tc.OpenedAt
+			v.StopLoss = tc.StopLossPrice
+			v.TakeProfit = tc.TakeProfitPrice
+			v.Hypothesis = tc.Reasoning
+			if tc.TakeProfitPrice > 0 && pos.AvgPrice > 0 {
+				v.HasProgress = true
+				v.ProgressPct = (pos.CurrentPrice - pos.AvgPrice) / (tc.TakeProfitPrice - pos.AvgPrice) * 100
+			}
+		}
+		data.Positions = append(data.Positions, v)
+	}
+	return data
+}
+
+// truncateRunes cuts s to at most n runes without an ellipsis. n <= 0 means no limit.
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// PromptSet holds the configured prompt variants and splits cycles between them by weight.
+type PromptSet struct {
+	variants []*PromptTemplate
+	weights  []int
+	total    int
+}
+
+// NewPromptSet loads all configured variants. With no variants the built-in default is used.
+func NewPromptSet(cfg config.PromptsConfig) (*PromptSet, error) {
+	variants := cfg.Variants
+	if len(variants) == 0 {
+		variants = []config.PromptVariant{{Version: DefaultPromptVersion, Weight: 100}}
+	}
+
+	set := &PromptSet{}
+	for _, v := range variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		tmpl, err := LoadPromptTemplate(cfg.Dir, v.Version)
+		if err != nil {
+			return nil, err
+		}
+		set.variants = append(set.variants, tmpl)
+		set.weights = append(set.weights, v.Weight)
+		set.total += v.Weight
+	}
+	if len(set.variants) == 0 {
+		return nil, fmt.Errorf("no prompt variants with positive weight")
+	}
+	return set, nil
+}
+
+// Pick chooses the prompt variant for one analysis cycle.
+func (s *PromptSet) Pick() *PromptTemplate {
+	return s.pick(rand.Intn(s.total))
+}
+
+func (s *PromptSet) pick(n int) *PromptTemplate {
+	for i, w := range s.weights {
+		if n < w {
+			return s.variants[i]
+		}
+		n -= w
+	}
+	return s.variants[len(s.variants)-1]
+}
+
+// Versions returns the loaded variant versions in config order.
+func (s *PromptSet) Versions() []string {
+	out := make([]string, len(s.variants))
+	for i, v := range s.variants {
+		out[i] = v.Version
+	}
+	return out
+}
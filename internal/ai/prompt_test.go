@@ -1,8 +1,12 @@
 package ai
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/camuig/rus-trader/internal/config"
 )
 
 func TestBuildUserPrompt_IncludesBriefsAndWorldNews(t *testing.T) {
@@ -27,7 +31,7 @@ func TestBuildUserPrompt_IncludesBriefsAndWorldNews(t *testing.T) {
 		TotalRub:     150000,
 	}
 
-	prompt := BuildUserPrompt(req, nil, PromptLimits{
+	prompt := buildUserPrompt(t, req, nil, PromptLimits{
 		MaxChars:            12000,
 		MaxTickerBriefChars: 900,
 		MaxTickerNewsItems:  8,
@@ -67,7 +71,7 @@ func TestBuildUserPrompt_RespectsMaxChars(t *testing.T) {
 	}
 
 	limit := 420
-	prompt := BuildUserPrompt(req, nil, PromptLimits{
+	prompt := buildUserPrompt(t, req, nil, PromptLimits{
 		MaxChars:            limit,
 		MaxTickerBriefChars: 120,
 		MaxTickerNewsItems:  2,
@@ -83,6 +87,67 @@ func TestBuildUserPrompt_RespectsMaxChars(t *testing.T) {
 	}
 }
 
+func TestBuildUserPrompt_IncludesMarketContext(t *testing.T) {
+	prompt := buildUserPrompt(t, &AnalysisRequest{Market: &MarketContext{
+		Regime:     "risk-off",
+		Reasons:    []string{"IMOEX в нисходящем тренде", "рубль слабеет"},
+		Lines:      []string{"IMOEX 2750.12: 1д -0.8%", "USD/RUB 92.10: 1д +1.2%"},
+		KeyRatePct: 16,
+	}}, nil, PromptLimits{})
+
+	want := "## Рыночный фон\nРежим: risk-off (IMOEX в нисходящем тренде; рубль слабеет)\n" +
+		"IMOEX 2750.12: 1д -0.8%\nUSD/RUB 92.10: 1д +1.2%\nКлючевая ставка ЦБ: 16.00%\n\n"
+	if !strings.Contains(prompt, want) {
+		t.Fatalf("expected market section, got:\n%s", prompt)
+	}
+	if strings.Contains(buildUserPrompt(t, &AnalysisRequest{}, nil, PromptLimits{}), "Рыночный фон") {
+		t.Fatal("section must be omitted without market context")
+	}
+}
+
+func TestBuildUserPrompt_ListsCorporateEvents(t *testing.T) {
+	prompt := buildUserPrompt(t, &AnalysisRequest{Tickers: []TickerAnalysis{
+		{Ticker: "SBER", Events: []string{"экс-дивидендная дата 11.07 (завтра): 33.30 RUB"}},
+		{Ticker: "GAZP"},
+	}}, nil, PromptLimits{})
+
+	if !strings.Contains(prompt, "## Корпоративные события\n- SBER: экс-дивидендная дата 11.07 (завтра): 33.30 RUB\n") {
+		t.Fatalf("expected corporate events section, got:\n%s", prompt)
+	}
+	if strings.Contains(buildUserPrompt(t, &AnalysisRequest{}, nil, PromptLimits{}), "Корпоративные события") {
+		t.Fatal("section must be omitted without events")
+	}
+}
+
+func TestBuildTickerNewsSection_IncludesSentiment(t *testing.T) {
+	section := buildTickerNewsSection([]TickerAnalysis{
+		{Ticker: "SBER", News: []string{"Сбербанк выплатит дивиденды"}, NewsSentiment: 0.71},
+		{Ticker: "GAZP", News: []string{"Газпром провел собрание"}},
+		{Ticker: "LKOH", NewsSentiment: -0.5},
+	}, 8, 120)
+
+	if !strings.Contains(section, "Тональность новостей (-1..+1): SBER +0.71\n") {
+		t.Fatalf("expected sentiment line only for SBER, got:\n%s", section)
+	}
+}
+
+func TestBuildNewsSections_ExplainFreshTagOnlyWhenUsed(t *testing.T) {
+	fresh := buildTickerNewsSection([]TickerAnalysis{
+		{Ticker: "SBER", News: []string{FreshNewsTag + " Сбербанк выплатит дивиденды", "Сбербанк провел собрание"}},
+	}, 8, 120)
+	if !strings.Contains(fresh, "- SBER: [новое] Сбербанк выплатит дивиденды\n") {
+		t.Fatalf("expected tagged line, got:\n%s", fresh)
+	}
+	if strings.Count(fresh, FreshNewsTag) != 2 {
+		t.Fatalf("expected tagged line and legend, got:\n%s", fresh)
+	}
+
+	world := buildWorldNewsSection([]string{"BBC: Oil prices rise"}, 5, 120)
+	if strings.Contains(world, FreshNewsTag) {
+		t.Fatalf("legend without fresh items:\n%s", world)
+	}
+}
+
 func TestBuildUserPrompt_RespectsNewsItemLimits(t *testing.T) {
 	req := &AnalysisRequest{
 		Tickers: []TickerAnalysis{
@@ -101,7 +166,7 @@ func TestBuildUserPrompt_RespectsNewsItemLimits(t *testing.T) {
 		TotalRub:     150000,
 	}
 
-	prompt := BuildUserPrompt(req, nil, PromptLimits{
+	prompt := buildUserPrompt(t, req, nil, PromptLimits{
 		MaxChars:            12000,
 		MaxTickerBriefChars: 900,
 		MaxTickerNewsItems:  2,
@@ -116,3 +181,111 @@ func TestBuildUserPrompt_RespectsNewsItemLimits(t *testing.T) {
 		t.Fatalf("world news section exceeds configured item limit")
 	}
 }
+
+func TestPromptTemplate_SystemBindsConfigParams(t *testing.T) {
+	tmpl, err := LoadPromptTemplate("", DefaultPromptVersion)
+	if err != nil {
+		t.Fatalf("load default prompt: %v", err)
+	}
+
+	system, err := tmpl.System(PromptParams{
+		MinConfidence:          75,
+		RoundTripCommissionPct: 0.05,
+		MaxPositionRub:         10000,
+		MaxOpenPositions:       5,
+	})
+	if err != nil {
+		t.Fatalf("render system prompt: %v", err)
+	}
+	if !strings.Contains(system, "Confidence ≥75") {
+		t.Fatalf("expected min confidence from config in system prompt")
+	}
+	if !strings.Contains(system, "комиссию 0.05%") {
+		t.Fatalf("expected commission from config in system prompt")
+	}
+	if strings.Contains(system, "Инструменты:") {
+		t.Fatalf("tools section must be omitted when tools are disabled")
+	}
+	if strings.Contains(system, "Корпоративные события") {
+		t.Fatalf("corporate events rule must be omitted when events are disabled")
+	}
+	if strings.Contains(system, "Рыночный фон") {
+		t.Fatalf("market regime rule must be omitted when the market context is disabled")
+	}
+	if system, _ = tmpl.System(PromptParams{CorporateEvents: true}); !strings.Contains(system, "\n7. Корпоративные события") {
+		t.Fatalf("expected the corporate events rule as rule 7 without the market context")
+	}
+	if system, _ = tmpl.System(PromptParams{MarketContext: true, CorporateEvents: true}); !strings.Contains(system, "\n7. Рыночный фон") || !strings.Contains(system, "\n8. Корпоративные события") {
+		t.Fatalf("expected the market regime and corporate events rules")
+	}
+}
+
+func TestLoadPromptTemplate_CustomDirOverridesBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	versionDir := filepath.Join(dir, "v2")
+	if err := os.MkdirAll(versionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"system.tmpl": "min={{.MinConfidence}}",
+		"user.tmpl":   `{{define "tail"}} END{{end}}cash={{printf "%.0f" .AvailableRub}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(versionDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tmpl, err := LoadPromptTemplate(dir, "v2")
+	if err != nil {
+		t.Fatalf("load custom prompt: %v", err)
+	}
+	system, _ := tmpl.System(PromptParams{MinConfidence: 80})
+	user, _ := tmpl.User(&AnalysisRequest{AvailableRub: 500}, nil, PromptLimits{}, PromptParams{})
+	if system != "min=80" || user != "cash=500 END" {
+		t.Fatalf("unexpected render: system=%q user=%q", system, user)
+	}
+
+	if _, err := LoadPromptTemplate(dir, "v9"); err == nil {
+		t.Fatalf("expected error for unknown version")
+	}
+}
+
+func TestPromptSet_PickFollowsWeights(t *testing.T) {
+	set, err := NewPromptSet(config.PromptsConfig{Variants: []config.PromptVariant{
+		{Version: "v1", Weight: 30},
+		{Version: "v1b", Weight: 0},
+	}})
+	if err != nil {
+		t.Fatalf("zero-weight variants must be skipped without loading: %v", err)
+	}
+	if got := set.Versions(); len(got) != 1 || got[0] != "v1" {
+		t.Fatalf("unexpected versions %v", got)
+	}
+
+	set = &PromptSet{
+		variants: []*PromptTemplate{{Version: "a"}, {Version: "b"}},
+		weights:  []int{70, 30},
+		total:    100,
+	}
+	cases := map[int]string{0: "a", 69: "a", 70: "b", 99: "b"}
+	for n, want := range cases {
+		if got := set.pick(n).Version; got != want {
+			t.Fatalf("pick(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+// buildUserPrompt renders the user prompt with the built-in default template.
+func buildUserPrompt(t *testing.T, req *AnalysisRequest, todayTraded []string, limits PromptLimits) string {
+	t.Helper()
+	tmpl, err := LoadPromptTemplate("", DefaultPromptVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, err := tmpl.User(req, todayTraded, limits, PromptParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prompt
+}
@@ -0,0 +1,29 @@
+package ai
+
+import (
+	"regexp"
+	"strings"
+)
+
+var paragraphSplitRegex = regexp.MustCompile(`\n\s*\n`)
+
+// ReasoningForTicker returns the paragraphs of the model's reasoning that mention the ticker.
+// Paragraphs are matched on the ticker as a whole word, case-insensitively.
+func ReasoningForTicker(reasoning, ticker string) []string {
+	if reasoning == "" || ticker == "" {
+		return nil
+	}
+	tickerRegex, err := regexp.Compile(`(?i)(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(ticker) + `($|[^\p{L}\p{N}])`)
+	if err != nil {
+		return nil
+	}
+
+	var result []string
+	for _, p := range paragraphSplitRegex.Split(reasoning, -1) {
+		p = strings.TrimSpace(p)
+		if p != "" && tickerRegex.MatchString(p) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package ai
+
+import "testing"
+
+func TestExtractThinkTags(t *testing.T) {
+	reasoning, answer := ExtractThinkTags("<think>SBER looks weak</think>\n[]")
+	if reasoning != "SBER looks weak" {
+		t.Fatalf("unexpected reasoning %q", reasoning)
+	}
+	if answer != "[]" {
+		t.Fatalf("unexpected answer %q", answer)
+	}
+
+	reasoning, answer = ExtractThinkTags(`[{"action":"HOLD"}]`)
+	if reasoning != "" || answer != `[{"action":"HOLD"}]` {
+		t.Fatalf("expected text without think tags to pass through, got %q / %q", reasoning, answer)
+	}
+}
+
+func TestReasoningForTicker(t *testing.T) {
+	reasoning := "SBER: RSI 28, перепродан.\n\nGAZP в боковике.\n\nСравнивая SBER и GAZP, выбираю sber.\n\nSBERP не рассматриваю."
+
+	got := ReasoningForTicker(reasoning, "SBER")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 paragraphs for SBER, got %d: %q", len(got), got)
+	}
+	if got[0] != "SBER: RSI 28, перепродан." {
+		t.Fatalf("unexpected first paragraph %q", got[0])
+	}
+
+	if got := ReasoningForTicker(reasoning, "LKOH"); len(got) != 0 {
+		t.Fatalf("expected no paragraphs for LKOH, got %q", got)
+	}
+}
@@ -0,0 +1,266 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ToolBackend supplies the data behind the model's tool calls.
+// Results are JSON-encoded and sent back to the model as-is.
+type ToolBackend interface {
+	Candles(ctx context.Context, ticker, interval string, count int) (any, error)
+	NewsText(ctx context.Context, newsID int64) (any, error)
+	OrderBook(ctx context.Context, ticker string, depth int) (any, error)
+	PositionHistory(ctx context.Context, ticker string) (any, error)
+}
+
+// ToolBudget bounds the agent loop for one analysis cycle.
+type ToolBudget struct {
+	MaxCalls       int           // total tool calls per cycle
+	CallTimeout    time.Duration // per-call timeout
+	TotalTimeout   time.Duration // wall time spent in tool calls per cycle
+	MaxResultChars int           // tool result is truncated to this many characters
+}
+
+// ToolCallRecord is a single executed tool call, kept for the analysis log.
+type ToolCallRecord struct {
+	Name       string `json:"name"`
+	Arguments  string `json:"arguments"`
+	Result     string `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// exhausted reports whether no further tool calls are allowed. Zero limits mean unbounded.
+func (b ToolBudget) exhausted(calls int, spent time.Duration) bool {
+	if b.MaxCalls > 0 && calls >= b.MaxCalls {
+		return true
+	}
+	return b.TotalTimeout > 0 && spent >= b.TotalTimeout
+}
+
+const (
+	toolGetCandles         = "get_candles"
+	toolGetNewsText        = "get_news_text"
+	toolGetOrderBook       = "get_order_book"
+	toolGetPositionHistory = "get_position_history"
+
+	maxToolCandles   = 100
+	maxToolBookDepth = 20
+	defaultToolCount = 24
+	defaultToolDepth = 10
+)
+
+var toolIntervals = []string{"5m", "15m", "1h", "1d"}
+
+// agentTools returns the tool definitions exposed to the model.
+func agentTools() []openai.Tool {
+	return []openai.Tool{
+		functionTool(toolGetCandles,
+			"OHLCV свечи по тикеру TQBR за выбранный интервал (последние count свечей).",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"ticker":   map[string]any{"type": "string", "description": "Тикер, например SBER"},
+					"interval": map[string]any{"type": "string", "enum": toolIntervals},
+					"count":    map[string]any{"type": "integer", "minimum": 1, "maximum": maxToolCandles},
+				},
+				"required": []string{"ticker", "interval"},
+			}),
+		functionTool(toolGetNewsText,
+			"Полный текст новости MOEX по её id.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"news_id": map[string]any{"type": "integer"},
+				},
+				"required": []string{"news_id"},
+			}),
+		functionTool(toolGetOrderBook,
+			"Текущий биржевой стакан по тикеру (лучшие заявки на покупку и продажу).",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"ticker": map[string]any{"type": "string"},
+					"depth":  map[string]any{"type": "integer", "minimum": 1, "maximum": maxToolBookDepth},
+				},
+				"required": []string{"ticker"},
+			}),
+		functionTool(toolGetPositionHistory,
+			"История сделок бота по тикеру: входы, выходы, SL/TP, P&L и причины.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"ticker": map[string]any{"type": "string"},
+				},
+				"required": []string{"ticker"},
+			}),
+	}
+}
+
+func functionTool(name, description string, params map[string]any) openai.Tool {
+	return openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  params,
+		},
+	}
+}
+
+type toolArgs struct {
+	Ticker   string `json:"ticker"`
+	Interval string `json:"interval"`
+	Count    int    `json:"count"`
+	NewsID   int64  `json:"news_id"`
+	Depth    int    `json:"depth"`
+}
+
+// executeToolCall dispatches a single tool call to the backend.
+// Errors are reported back to the model as the tool result rather than aborting the cycle.
+func executeToolCall(ctx context.Context, backend ToolBackend, call openai.ToolCall, budget ToolBudget) ToolCallRecord {
+	record := ToolCallRecord{
+		Name:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+	}
+	start := time.Now()
+	defer func() {
+		record.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	var args toolArgs
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			record.Error = fmt.Sprintf("invalid arguments: %v", err)
+			return record
+		}
+	}
+	args.Ticker = strings.ToUpper(strings.TrimSpace(args.Ticker))
+
+	if budget.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget.CallTimeout)
+		defer cancel()
+	}
+
+	result, err := dispatchTool(ctx, backend, call.Function.Name, args)
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		record.Error = fmt.Sprintf("encode result: %v", err)
+		return record
+	}
+	record.Result = truncate(string(data), budget.MaxResultChars)
+	return record
+}
+
+func dispatchTool(ctx context.Context, backend ToolBackend, name string, args toolArgs) (any, error) {
+	switch name {
+	case toolGetCandles:
+		if args.Ticker == "" {
+			return nil, fmt.Errorf("ticker is required")
+		}
+		if !containsString(toolIntervals, args.Interval) {
+			return nil, fmt.Errorf("interval must be one of %s", strings.Join(toolIntervals, ", "))
+		}
+		count := args.Count
+		if count <= 0 {
+			count = defaultToolCount
+		}
+		if count > maxToolCandles {
+			count = maxToolCandles
+		}
+		return backend.Candles(ctx, args.Ticker, args.Interval, count)
+	case toolGetNewsText:
+		if args.NewsID == 0 {
+			return nil, fmt.Errorf("news_id is required")
+		}
+		return backend.NewsText(ctx, args.NewsID)
+	case toolGetOrderBook:
+		if args.Ticker == "" {
+			return nil, fmt.Errorf("ticker is required")
+		}
+		depth := args.Depth
+		if depth <= 0 {
+			depth = defaultToolDepth
+		}
+		if depth > maxToolBookDepth {
+			depth = maxToolBookDepth
+		}
+		return backend.OrderBook(ctx, args.Ticker, depth)
+	case toolGetPositionHistory:
+		if args.Ticker == "" {
+			return nil, fmt.Errorf("ticker is required")
+		}
+		return backend.PositionHistory(ctx, args.Ticker)
+	default:
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+}
+
+// toolMessageContent is what the model sees as the tool's answer.
+func (r ToolCallRecord) toolMessageContent() string {
+	if r.Error != "" {
+		data, _ := json.Marshal(map[string]string{"error": r.Error})
+		return string(data)
+	}
+	return r.Result
+}
+
+// mergeToolCallDeltas accumulates streamed tool call fragments, keyed by their index.
+func mergeToolCallDeltas(acc map[int]*openai.ToolCall, deltas []openai.ToolCall) {
+	for _, d := range deltas {
+		idx := len(acc)
+		if d.Index != nil {
+			idx = *d.Index
+		}
+		call, ok := acc[idx]
+		if !ok {
+			call = &openai.ToolCall{Type: openai.ToolTypeFunction}
+			acc[idx] = call
+		}
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Type != "" {
+			call.Type = d.Type
+		}
+		call.Function.Name += d.Function.Name
+		call.Function.Arguments += d.Function.Arguments
+	}
+}
+
+// orderedToolCalls returns accumulated tool calls in stream order.
+func orderedToolCalls(acc map[int]*openai.ToolCall) []openai.ToolCall {
+	indices := make([]int, 0, len(acc))
+	for idx := range acc {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+
+	calls := make([]openai.ToolCall, 0, len(indices))
+	for _, idx := range indices {
+		calls = append(calls, *acc[idx])
+	}
+	return calls
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type fakeToolBackend struct {
+	lastTicker   string
+	lastInterval string
+	lastCount    int
+	lastDepth    int
+}
+
+func (f *fakeToolBackend) Candles(_ context.Context, ticker, interval string, count int) (any, error) {
+	f.lastTicker, f.lastInterval, f.lastCount = ticker, interval, count
+	return []float64{1, 2, 3}, nil
+}
+
+func (f *fakeToolBackend) NewsText(_ context.Context, newsID int64) (any, error) {
+	return map[string]any{"id": newsID, "text": strings.Repeat("текст ", 100)}, nil
+}
+
+func (f *fakeToolBackend) OrderBook(_ context.Context, ticker string, depth int) (any, error) {
+	f.lastTicker, f.lastDepth = ticker, depth
+	return map[string]any{"bids": []float64{1}}, nil
+}
+
+func (f *fakeToolBackend) PositionHistory(_ context.Context, ticker string) (any, error) {
+	f.lastTicker = ticker
+	return []string{}, nil
+}
+
+func toolCall(name, args string) openai.ToolCall {
+	return openai.ToolCall{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: name, Arguments: args}}
+}
+
+func TestExecuteToolCall_ClampsArgumentsAndNormalizesTicker(t *testing.T) {
+	backend := &fakeToolBackend{}
+	budget := ToolBudget{MaxResultChars: 1000}
+
+	rec := executeToolCall(context.Background(), backend, toolCall(toolGetCandles, `{"ticker":" sber ","interval":"1h","count":500}`), budget)
+	if rec.Error != "" {
+		t.Fatalf("unexpected error: %s", rec.Error)
+	}
+	if backend.lastTicker != "SBER" || backend.lastInterval != "1h" || backend.lastCount != maxToolCandles {
+		t.Fatalf("unexpected backend args: %+v", backend)
+	}
+	if rec.Result != "[1,2,3]" {
+		t.Fatalf("unexpected result %q", rec.Result)
+	}
+
+	rec = executeToolCall(context.Background(), backend, toolCall(toolGetOrderBook, `{"ticker":"GAZP"}`), budget)
+	if rec.Error != "" || backend.lastDepth != defaultToolDepth {
+		t.Fatalf("expected default depth, got %d (error %q)", backend.lastDepth, rec.Error)
+	}
+}
+
+func TestExecuteToolCall_ReportsErrorsToModel(t *testing.T) {
+	backend := &fakeToolBackend{}
+	budget := ToolBudget{MaxResultChars: 1000}
+
+	cases := []openai.ToolCall{
+		toolCall(toolGetCandles, `{"ticker":"SBER","interval":"2h"}`),
+		toolCall(toolGetNewsText, `{}`),
+		toolCall("drop_tables", `{}`),
+		toolCall(toolGetOrderBook, `not json`),
+	}
+	for _, call := range cases {
+		rec := executeToolCall(context.Background(), backend, call, budget)
+		if rec.Error == "" {
+			t.Fatalf("expected error for %s %s", call.Function.Name, call.Function.Arguments)
+		}
+		if !strings.HasPrefix(rec.toolMessageContent(), `{"error":`) {
+			t.Fatalf("expected JSON error message, got %q", rec.toolMessageContent())
+		}
+	}
+}
+
+func TestExecuteToolCall_TruncatesResult(t *testing.T) {
+	rec := executeToolCall(context.Background(), &fakeToolBackend{}, toolCall(toolGetNewsText, `{"news_id":42}`), ToolBudget{MaxResultChars: 50})
+	if runeLen(rec.Result) != 50 {
+		t.Fatalf("expected result truncated to 50 runes, got %d", runeLen(rec.Result))
+	}
+}
+
+func TestMergeToolCallDeltas(t *testing.T) {
+	idx0, idx1 := 0, 1
+	acc := make(map[int]*openai.ToolCall)
+	mergeToolCallDeltas(acc, []openai.ToolCall{{Index: &idx0, ID: "a", Function: openai.FunctionCall{Name: "get_", Arguments: `{"tic`}}})
+	mergeToolCallDeltas(acc, []openai.ToolCall{{Index: &idx0, Function: openai.FunctionCall{Name: "candles", Arguments: `ker":"SBER"}`}}})
+	mergeToolCallDeltas(acc, []openai.ToolCall{{Index: &idx1, ID: "b", Function: openai.FunctionCall{Name: "get_order_book", Arguments: `{}`}}})
+
+	calls := orderedToolCalls(acc)
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[0].ID != "a" || calls[0].Function.Name != "get_candles" || calls[0].Function.Arguments != `{"ticker":"SBER"}` {
+		t.Fatalf("unexpected first call %+v", calls[0])
+	}
+	if calls[1].ID != "b" || calls[1].Function.Name != "get_order_book" {
+		t.Fatalf("unexpected second call %+v", calls[1])
+	}
+}
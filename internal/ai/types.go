@@ -17,15 +17,17 @@ type PeriodData struct {
 }
 
 type TickerAnalysis struct {
-	Ticker     string
-	Brief      string // краткая карточка тикера (имя/тип/лот/валюта/страна)
-	LastPrice  float64
-	Period3h   PeriodData
-	Period1d   PeriodData
-	Period3d   PeriodData
-	Period1w   PeriodData
-	News       []string // заголовки новостей
-	Indicators indicators.Indicators
+	Ticker        string
+	Brief         string // краткая карточка тикера (имя/тип/лот/валюта/страна)
+	LastPrice     float64
+	Period3h      PeriodData
+	Period1d      PeriodData
+	Period3d      PeriodData
+	Period1w      PeriodData
+	News          []string // заголовки новостей
+	NewsSentiment float64  // тональность новостей [-1, 1], 0 — нейтрально или нет новостей
+	Events        []string // предстоящие корпоративные события (дивиденды, отчётность)
+	Indicators    indicators.Indicators
 }
 
 type RecentClosedTrade struct {
@@ -64,7 +66,17 @@ type AnalysisRequest struct {
 	AvailableRub float64
 	TotalRub     float64
 	Stats        PerformanceStats
-	CurrentTime  time.Time // current time in MSK
+	CurrentTime  time.Time      // current time in MSK
+	Market       *MarketContext // nil when the market context is unavailable
+	Tools        ToolBackend    // nil disables tool calling
+}
+
+// MarketContext is the broad market picture shown before the tickers.
+type MarketContext struct {
+	Regime     string   // risk-on, neutral или risk-off
+	Reasons    []string // что определило режим
+	Lines      []string // по строке на инструмент (индекс, ОФЗ, рубль, нефть)
+	KeyRatePct float64  // ключевая ставка ЦБ, 0 — неизвестна
 }
 
 type PromptLimits struct {
@@ -82,4 +94,29 @@ type AIDecision struct {
 	TakeProfit float64 `json:"take_profit"`
 	Confidence int     `json:"confidence"` // 0-100
 	Reasoning  string  `json:"reasoning"`
+
+	PromptVersion string  `json:"-"` // prompt variant that produced the decision
+	AnalysisLogID uint    `json:"-"` // analysis cycle that produced the decision, set by the scheduler
+	Manual        bool    `json:"-"` // placed by an operator: no confidence gate, full position size
+	SizeScale     float64 `json:"-"` // position size multiplier set by the trade guard, 0 = unscaled
+}
+
+// AnalysisResult is the outcome of a single Analyze call.
+type AnalysisResult struct {
+	Decisions     []AIDecision
+	RawResponse   string
+	Reasoning     string // reasoning_content stream or inline <think> blocks
+	Model         string
+	PromptVersion string
+	SystemPrompt  string
+	UserPrompt    string
+	Usage         Usage
+	CostUSD       float64
+	CostRub       float64
+
+	ReasoningDuration time.Duration // request start → first answer token
+	AnswerDuration    time.Duration // first answer token → end of stream
+
+	ToolCalls    []ToolCallRecord
+	ToolDuration time.Duration // wall time spent executing tool calls
 }
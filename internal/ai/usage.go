@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+)
+
+// Usage holds token accounting for a single model call.
+type Usage struct {
+	PromptTokens     int
+	CachedTokens     int // prompt tokens served from the provider's context cache
+	CompletionTokens int // includes reasoning tokens
+	ReasoningTokens  int
+	Estimated        bool // true when the API did not report usage and counts were estimated locally
+	Latency          time.Duration
+}
+
+// TotalTokens returns prompt + completion tokens.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// CostUSD calculates the cost of the call under the given price table entry.
+func (u Usage) CostUSD(price config.ModelPrice) float64 {
+	cached := u.CachedTokens
+	if cached > u.PromptTokens {
+		cached = u.PromptTokens
+	}
+	cachedPrice := price.CachedInputPerMTok
+	if cachedPrice <= 0 {
+		cachedPrice = price.InputPerMTok
+	}
+	cost := float64(u.PromptTokens-cached)*price.InputPerMTok +
+		float64(cached)*cachedPrice +
+		float64(u.CompletionTokens)*price.OutputPerMTok
+	return cost / 1_000_000
+}
+
+// EstimateTokens gives a rough token count for text when the API reports no usage.
+// DeepSeek's tokenizer averages ~3 characters per token on mixed Russian/English text.
+func EstimateTokens(text string) int {
+	n := runeLen(text)
+	if n == 0 {
+		return 0
+	}
+	return (n + 2) / 3
+}
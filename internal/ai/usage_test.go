@@ -0,0 +1,37 @@
+package ai
+
+import (
+	"math"
+	"testing"
+
+	"github.com/camuig/rus-trader/internal/config"
+)
+
+func TestUsageCostUSD(t *testing.T) {
+	price := config.ModelPrice{InputPerMTok: 0.5, CachedInputPerMTok: 0.1, OutputPerMTok: 2}
+	u := Usage{PromptTokens: 10_000, CachedTokens: 4_000, CompletionTokens: 3_000}
+
+	// 6000*0.5 + 4000*0.1 + 3000*2 = 3000 + 400 + 6000 = 9400 per 1M
+	want := 0.0094
+	if got := u.CostUSD(price); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("CostUSD = %f, want %f", got, want)
+	}
+}
+
+func TestUsageCostUSD_NoCachedPriceFallsBackToInput(t *testing.T) {
+	price := config.ModelPrice{InputPerMTok: 1, OutputPerMTok: 1}
+	u := Usage{PromptTokens: 1_000_000, CachedTokens: 500_000}
+
+	if got := u.CostUSD(price); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("CostUSD = %f, want 1", got)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Fatalf("EstimateTokens(empty) = %d, want 0", got)
+	}
+	if got := EstimateTokens("абвгдеж"); got != 3 {
+		t.Fatalf("EstimateTokens(7 runes) = %d, want 3", got)
+	}
+}
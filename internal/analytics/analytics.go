@@ -0,0 +1,304 @@
+// Package analytics computes portfolio performance from equity snapshots and
+// closed trades: equity curve and drawdowns, period returns, risk-adjusted
+// ratios and trade statistics.
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// tradingDaysPerYear annualises daily Sharpe and Sortino ratios.
+const tradingDaysPerYear = 252
+
+// EquityPoint is one portfolio valuation with its distance from the running peak.
+type EquityPoint struct {
+	Time        time.Time `json:"time"`
+	Equity      float64   `json:"equity"`
+	Peak        float64   `json:"peak"`
+	DrawdownPct float64   `json:"drawdown_pct"` // <= 0
+}
+
+// PeriodReturn is the equity change over a calendar day, ISO week or month (MSK).
+type PeriodReturn struct {
+	Period    string    `json:"period"` // 2006-01-02, 2006-W01 or 2006-01
+	Start     time.Time `json:"start"`
+	Equity    float64   `json:"equity"` // at period end
+	PnL       float64   `json:"pnl"`
+	ReturnPct float64   `json:"return_pct"`
+}
+
+// TickerPnL aggregates closed round trips per ticker.
+type TickerPnL struct {
+	Ticker       string  `json:"ticker"`
+	Trades       int     `json:"trades"`
+	Wins         int     `json:"wins"`
+	PnL          float64 `json:"pnl"`
+	AvgReturnPct float64 `json:"avg_return_pct"`
+}
+
+// Drawdown is the deepest peak-to-trough decline of the equity curve.
+type Drawdown struct {
+	DepthPct  float64   `json:"depth_pct"` // <= 0
+	PeakAt    time.Time `json:"peak_at"`
+	TroughAt  time.Time `json:"trough_at"`
+	Recovered bool      `json:"recovered"`
+	// Hours from the peak until equity regained it, or until the last point if it has not.
+	DurationHours float64 `json:"duration_hours"`
+}
+
+// Report is the full set of performance metrics for a period.
+type Report struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	StartEquity float64       `json:"start_equity"`
+	EndEquity   float64       `json:"end_equity"`
+	ReturnPct   float64       `json:"return_pct"`
+	Equity      []EquityPoint `json:"equity"`
+
+	Daily   []PeriodReturn `json:"daily"`
+	Weekly  []PeriodReturn `json:"weekly"`
+	Monthly []PeriodReturn `json:"monthly"`
+
+	Sharpe      float64  `json:"sharpe"`  // annualised from daily returns, risk-free rate 0
+	Sortino     float64  `json:"sortino"` // annualised, downside deviation
+	MaxDrawdown Drawdown `json:"max_drawdown"`
+
+	Trades          int         `json:"trades"`
+	Wins            int         `json:"wins"`
+	WinRate         float64     `json:"win_rate"` // %
+	GrossProfit     float64     `json:"gross_profit"`
+	GrossLoss       float64     `json:"gross_loss"`    // <= 0
+	ProfitFactor    float64     `json:"profit_factor"` // 0 when there are no losing trades
+	Expectancy      float64     `json:"expectancy"`    // average P&L per trade, RUB
+	AvgHoldingHours float64     `json:"avg_holding_hours"`
+	Tickers         []TickerPnL `json:"tickers"` // by P&L, best first
+}
+
+// Build computes a report. Snapshots are portfolio valuations and trades are
+// closed BUY round trips (UpdatedAt is the exit time); both are assumed to
+// already be limited to the period. Deposits and withdrawals are not separated
+// from returns.
+func Build(snapshots []storage.PortfolioSnapshot, roundTrips []storage.Trade, from, to time.Time, loc *time.Location) Report {
+	r := Report{From: from, To: to}
+
+	sorted := make([]storage.PortfolioSnapshot, 0, len(snapshots))
+	for _, s := range snapshots {
+		if s.TotalRub > 0 {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	r.Equity = EquityCurve(sorted)
+	if n := len(r.Equity); n > 0 {
+		r.StartEquity = r.Equity[0].Equity
+		r.EndEquity = r.Equity[n-1].Equity
+		r.ReturnPct = pctChange(r.StartEquity, r.EndEquity)
+	}
+	r.MaxDrawdown = MaxDrawdown(r.Equity)
+
+	r.Daily = PeriodReturns(r.Equity, loc, dayKey)
+	r.Weekly = PeriodReturns(r.Equity, loc, weekKey)
+	r.Monthly = PeriodReturns(r.Equity, loc, monthKey)
+
+	daily := make([]float64, len(r.Daily))
+	for i, d := range r.Daily {
+		daily[i] = d.ReturnPct / 100
+	}
+	r.Sharpe, r.Sortino = RiskRatios(daily)
+
+	r.applyTrades(roundTrips)
+	return r
+}
+
+// EquityCurve turns chronologically sorted snapshots into equity points with drawdowns.
+func EquityCurve(snapshots []storage.PortfolioSnapshot) []EquityPoint {
+	points := make([]EquityPoint, 0, len(snapshots))
+	var peak float64
+	for _, s := range snapshots {
+		if s.TotalRub > peak {
+			peak = s.TotalRub
+		}
+		points = append(points, EquityPoint{
+			Time:        s.CreatedAt,
+			Equity:      s.TotalRub,
+			Peak:        peak,
+			DrawdownPct: pctChange(peak, s.TotalRub),
+		})
+	}
+	return points
+}
+
+// MaxDrawdown finds the deepest drawdown and how long it lasted.
+func MaxDrawdown(points []EquityPoint) Drawdown {
+	var dd Drawdown
+	peakIdx := 0
+	for i, p := range points {
+		if p.Equity >= points[peakIdx].Equity {
+			peakIdx = i
+			continue
+		}
+		if p.DrawdownPct < dd.DepthPct {
+			dd.DepthPct = p.DrawdownPct
+			dd.PeakAt = points[peakIdx].Time
+			dd.TroughAt = p.Time
+		}
+	}
+	if dd.DepthPct == 0 {
+		return dd
+	}
+
+	end := points[len(points)-1].Time
+	for _, p := range points {
+		if p.Time.After(dd.TroughAt) && p.DrawdownPct == 0 {
+			dd.Recovered = true
+			end = p.Time
+			break
+		}
+	}
+	dd.DurationHours = end.Sub(dd.PeakAt).Hours()
+	return dd
+}
+
+// periodKey names the calendar period a time belongs to.
+type periodKey func(t time.Time) (key string, start time.Time)
+
+func dayKey(t time.Time) (string, time.Time) {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start.Format("2006-01-02"), start
+}
+
+func weekKey(t time.Time) (string, time.Time) {
+	year, week := t.ISOWeek()
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+	day := t.AddDate(0, 0, -offset)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+	return fmt.Sprintf("%d-W%02d", year, week), start
+}
+
+func monthKey(t time.Time) (string, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start.Format("2006-01"), start
+}
+
+// PeriodReturns compares the closing equity of each period with the previous
+// period's close. The first period is measured from its first point.
+func PeriodReturns(points []EquityPoint, loc *time.Location, key periodKey) []PeriodReturn {
+	var out []PeriodReturn
+	var base float64
+	for _, p := range points {
+		k, start := key(p.Time.In(loc))
+		if len(out) == 0 || out[len(out)-1].Period != k {
+			if len(out) == 0 {
+				base = p.Equity
+			} else {
+				base = out[len(out)-1].Equity
+			}
+			out = append(out, PeriodReturn{Period: k, Start: start})
+		}
+		cur := &out[len(out)-1]
+		cur.Equity = p.Equity
+		cur.PnL = p.Equity - base
+		cur.ReturnPct = pctChange(base, p.Equity)
+	}
+	return out
+}
+
+// RiskRatios returns annualised Sharpe and Sortino ratios of daily returns
+// (fractions, not percent). Both are 0 with fewer than two returns or no variation.
+func RiskRatios(returns []float64) (sharpe, sortino float64) {
+	n := len(returns)
+	if n < 2 {
+		return 0, 0
+	}
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(n)
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	std := math.Sqrt(variance / float64(n-1))
+	downDev := math.Sqrt(downside / float64(n))
+
+	annual := math.Sqrt(tradingDaysPerYear)
+	if std > 0 {
+		sharpe = mean / std * annual
+	}
+	if downDev > 0 {
+		sortino = mean / downDev * annual
+	}
+	return sharpe, sortino
+}
+
+func (r *Report) applyTrades(roundTrips []storage.Trade) {
+	byTicker := make(map[string]*TickerPnL)
+	var holding time.Duration
+	for _, t := range roundTrips {
+		r.Trades++
+		if t.PnL > 0 {
+			r.Wins++
+			r.GrossProfit += t.PnL
+		} else {
+			r.GrossLoss += t.PnL
+		}
+		if t.UpdatedAt.After(t.CreatedAt) {
+			holding += t.UpdatedAt.Sub(t.CreatedAt)
+		}
+
+		tp, ok := byTicker[t.Ticker]
+		if !ok {
+			tp = &TickerPnL{Ticker: t.Ticker}
+			byTicker[t.Ticker] = tp
+		}
+		tp.Trades++
+		if t.PnL > 0 {
+			tp.Wins++
+		}
+		tp.PnL += t.PnL
+		if notional := t.Price * float64(t.Quantity); notional > 0 {
+			tp.AvgReturnPct += t.PnL / notional * 100
+		}
+	}
+	if r.Trades == 0 {
+		return
+	}
+
+	r.WinRate = float64(r.Wins) / float64(r.Trades) * 100
+	r.Expectancy = (r.GrossProfit + r.GrossLoss) / float64(r.Trades)
+	if r.GrossLoss < 0 {
+		r.ProfitFactor = r.GrossProfit / -r.GrossLoss
+	}
+	r.AvgHoldingHours = holding.Hours() / float64(r.Trades)
+
+	r.Tickers = make([]TickerPnL, 0, len(byTicker))
+	for _, tp := range byTicker {
+		tp.AvgReturnPct /= float64(tp.Trades)
+		r.Tickers = append(r.Tickers, *tp)
+	}
+	sort.Slice(r.Tickers, func(i, j int) bool {
+		if r.Tickers[i].PnL != r.Tickers[j].PnL {
+			return r.Tickers[i].PnL > r.Tickers[j].PnL
+		}
+		return r.Tickers[i].Ticker < r.Tickers[j].Ticker
+	})
+}
+
+func pctChange(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return (to - from) / from * 100
+}
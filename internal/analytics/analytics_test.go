@@ -0,0 +1,142 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+var msk = time.FixedZone("MSK", 3*60*60)
+
+func snap(t time.Time, equity float64) storage.PortfolioSnapshot {
+	return storage.PortfolioSnapshot{CreatedAt: t, TotalRub: equity}
+}
+
+func at(day, hour int) time.Time {
+	return time.Date(2026, 3, day, hour, 0, 0, 0, msk)
+}
+
+func TestMaxDrawdown_DepthAndRecovery(t *testing.T) {
+	points := EquityCurve([]storage.PortfolioSnapshot{
+		snap(at(2, 10), 100),
+		snap(at(2, 12), 110), // peak
+		snap(at(2, 14), 99),  // trough: -10%
+		snap(at(3, 10), 105),
+		snap(at(3, 16), 111), // recovered
+		snap(at(4, 10), 108),
+	})
+
+	dd := MaxDrawdown(points)
+	if math.Abs(dd.DepthPct+10) > 1e-9 {
+		t.Fatalf("expected -10%% drawdown, got %.4f", dd.DepthPct)
+	}
+	if !dd.PeakAt.Equal(at(2, 12)) || !dd.TroughAt.Equal(at(2, 14)) {
+		t.Fatalf("unexpected peak/trough %v %v", dd.PeakAt, dd.TroughAt)
+	}
+	if !dd.Recovered || dd.DurationHours != 28 {
+		t.Fatalf("expected recovery after 28h, got %+v", dd)
+	}
+}
+
+func TestMaxDrawdown_NotRecoveredRunsToLastPoint(t *testing.T) {
+	points := EquityCurve([]storage.PortfolioSnapshot{
+		snap(at(2, 10), 100),
+		snap(at(2, 11), 90),
+		snap(at(2, 15), 95),
+	})
+	dd := MaxDrawdown(points)
+	if dd.Recovered || dd.DurationHours != 5 {
+		t.Fatalf("expected open drawdown of 5h, got %+v", dd)
+	}
+	if MaxDrawdown(nil).DepthPct != 0 {
+		t.Fatalf("expected no drawdown without data")
+	}
+}
+
+func TestPeriodReturns_ChainsPeriodCloses(t *testing.T) {
+	points := EquityCurve([]storage.PortfolioSnapshot{
+		snap(at(2, 10), 100),
+		snap(at(2, 18), 102), // Monday close
+		snap(at(3, 18), 99.96),
+		snap(at(9, 18), 105), // next week
+	})
+
+	daily := PeriodReturns(points, msk, dayKey)
+	if len(daily) != 3 {
+		t.Fatalf("expected 3 days, got %d", len(daily))
+	}
+	if daily[0].Period != "2026-03-02" || math.Abs(daily[0].ReturnPct-2) > 1e-9 {
+		t.Fatalf("unexpected first day %+v", daily[0])
+	}
+	if math.Abs(daily[1].ReturnPct+2) > 1e-9 || math.Abs(daily[1].PnL+2.04) > 1e-9 {
+		t.Fatalf("second day must be measured from the previous close: %+v", daily[1])
+	}
+
+	weekly := PeriodReturns(points, msk, weekKey)
+	if len(weekly) != 2 || weekly[0].Period != "2026-W10" || !weekly[0].Start.Equal(at(2, 0)) {
+		t.Fatalf("unexpected weeks %+v", weekly)
+	}
+
+	monthly := PeriodReturns(points, msk, monthKey)
+	if len(monthly) != 1 || math.Abs(monthly[0].ReturnPct-5) > 1e-9 {
+		t.Fatalf("unexpected months %+v", monthly)
+	}
+}
+
+func TestRiskRatios(t *testing.T) {
+	if s, so := RiskRatios([]float64{0.01}); s != 0 || so != 0 {
+		t.Fatalf("expected zero ratios for a single return")
+	}
+
+	returns := []float64{0.01, -0.01, 0.02, 0}
+	sharpe, sortino := RiskRatios(returns)
+	// mean 0.005, sample std 0.012910, downside deviation sqrt(0.0001/4) = 0.005
+	if math.Abs(sharpe-0.005/0.0129099445*math.Sqrt(252)) > 1e-6 {
+		t.Fatalf("unexpected sharpe %.6f", sharpe)
+	}
+	if math.Abs(sortino-math.Sqrt(252)) > 1e-9 {
+		t.Fatalf("unexpected sortino %.6f", sortino)
+	}
+
+	_, sortino = RiskRatios([]float64{0.01, 0.02})
+	if sortino != 0 {
+		t.Fatalf("expected zero sortino without losing days")
+	}
+}
+
+func TestBuild_TradeStats(t *testing.T) {
+	trades := []storage.Trade{
+		{Ticker: "SBER", Price: 100, Quantity: 10, PnL: 30, CreatedAt: at(2, 10), UpdatedAt: at(2, 14)},
+		{Ticker: "SBER", Price: 100, Quantity: 10, PnL: -10, CreatedAt: at(3, 10), UpdatedAt: at(3, 12)},
+		{Ticker: "GAZP", Price: 200, Quantity: 5, PnL: -10, CreatedAt: at(3, 10), UpdatedAt: at(3, 16)},
+	}
+	r := Build([]storage.PortfolioSnapshot{snap(at(2, 10), 1000), snap(at(3, 18), 1010)}, trades, at(1, 0), at(5, 0), msk)
+
+	if r.Trades != 3 || r.Wins != 1 || math.Abs(r.WinRate-100.0/3) > 1e-9 {
+		t.Fatalf("unexpected counts %+v", r)
+	}
+	if r.ProfitFactor != 1.5 || math.Abs(r.Expectancy-10.0/3) > 1e-9 {
+		t.Fatalf("unexpected profit factor %.2f / expectancy %.2f", r.ProfitFactor, r.Expectancy)
+	}
+	if r.AvgHoldingHours != 4 {
+		t.Fatalf("expected 4h average holding, got %.2f", r.AvgHoldingHours)
+	}
+	if len(r.Tickers) != 2 || r.Tickers[0].Ticker != "SBER" || r.Tickers[0].PnL != 20 || r.Tickers[0].AvgReturnPct != 1 {
+		t.Fatalf("unexpected ticker breakdown %+v", r.Tickers)
+	}
+	if r.ReturnPct != 1 {
+		t.Fatalf("expected 1%% return, got %.2f", r.ReturnPct)
+	}
+}
+
+func TestBuild_ProfitFactorWithoutLosses(t *testing.T) {
+	r := Build(nil, []storage.Trade{{Ticker: "SBER", PnL: 5}}, at(1, 0), at(2, 0), msk)
+	if r.ProfitFactor != 0 || r.GrossProfit != 5 {
+		t.Fatalf("expected undefined profit factor reported as 0, got %+v", r)
+	}
+	if len(r.Equity) != 0 || len(r.Daily) != 0 {
+		t.Fatalf("expected no periods without snapshots")
+	}
+}
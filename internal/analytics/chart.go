@@ -0,0 +1,117 @@
+package analytics
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// Chart colours match the dashboard equity chart.
+var (
+	chartBackground = color.RGBA{0x0f, 0x11, 0x17, 0xff}
+	chartGrid       = color.RGBA{0x21, 0x26, 0x2d, 0xff}
+	chartPeak       = color.RGBA{0x30, 0x36, 0x3d, 0xff}
+	chartEquity     = color.RGBA{0x58, 0xa6, 0xff, 0xff}
+	chartDrawdown   = color.RGBA{0x4a, 0x1f, 0x22, 0xff} // drawdown fill blended over the background
+)
+
+const chartPadding = 12
+
+// EquityChartPNG draws the equity curve with its running peak and the
+// drawdown area between them. The chart has no text: values go in the caption.
+func EquityChartPNG(points []EquityPoint, width, height int) ([]byte, error) {
+	if len(points) < 2 {
+		return nil, errors.New("equity chart needs at least two points")
+	}
+	if width <= 2*chartPadding || height <= 2*chartPadding {
+		return nil, errors.New("equity chart too small")
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		lo = math.Min(lo, p.Equity)
+		hi = math.Max(hi, p.Peak)
+	}
+	if hi == lo {
+		hi, lo = hi+1, lo-1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), chartBackground)
+
+	plotW, plotH := width-2*chartPadding, height-2*chartPadding
+	x := func(i int) int { return chartPadding + i*(plotW-1)/(len(points)-1) }
+	y := func(v float64) int { return chartPadding + int(math.Round((hi-v)/(hi-lo)*float64(plotH-1))) }
+
+	for i := 0; i <= 4; i++ {
+		gy := chartPadding + i*(plotH-1)/4
+		fill(img, image.Rect(chartPadding, gy, width-chartPadding, gy+1), chartGrid)
+	}
+	for i := 1; i < len(points); i++ {
+		for px := x(i - 1); px <= x(i); px++ {
+			f := float64(px-x(i-1)) / float64(max(1, x(i)-x(i-1)))
+			eq := points[i-1].Equity + f*(points[i].Equity-points[i-1].Equity)
+			pk := points[i-1].Peak + f*(points[i].Peak-points[i-1].Peak)
+			if eq < pk {
+				fill(img, image.Rect(px, y(pk), px+1, y(eq)), chartDrawdown)
+			}
+		}
+	}
+	for i := 1; i < len(points); i++ {
+		line(img, x(i-1), y(points[i-1].Peak), x(i), y(points[i].Peak), 1, chartPeak)
+		line(img, x(i-1), y(points[i-1].Equity), x(i), y(points[i].Equity), 2, chartEquity)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// line draws a segment with Bresenham's algorithm using square pens of the given width.
+func line(img *image.RGBA, x0, y0, x1, y1, width int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		fill(img, image.Rect(x0, y0, x0+width, y0+width), c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// Digest is the end-of-session summary of a day or week: results, activity,
+// costs and the book carried into the next session.
+type Digest struct {
+	Weekly bool      `json:"weekly"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	Period       Report `json:"period"`   // equity and round trips of the digest period
+	Trailing     Report `json:"trailing"` // longer window for risk metrics
+	TrailingDays int    `json:"trailing_days"`
+
+	RealizedPnL   float64       `json:"realized_pnl"`   // round trips closed in the period, net of commission
+	UnrealizedPnL float64       `json:"unrealized_pnl"` // open positions in the latest snapshot
+	Opened        []DigestTrade `json:"opened"`
+	Closed        []DigestTrade `json:"closed"`
+	FeesRub       float64       `json:"fees_rub"` // estimated from commission_pct on traded notional
+
+	Blocks       []ReasonCount `json:"blocks"` // most frequent first
+	AICostRub    float64       `json:"ai_cost_rub"`
+	Cycles       int           `json:"cycles"`
+	FailedCycles int           `json:"failed_cycles"`
+
+	Book []BookPosition `json:"book"`
+}
+
+// DigestTrade is a position opened or closed in the digest period.
+type DigestTrade struct {
+	Ticker    string    `json:"ticker"`
+	Time      time.Time `json:"time"`
+	Price     float64   `json:"price"` // entry price
+	Quantity  int64     `json:"quantity"`
+	PnL       float64   `json:"pnl"`        // closed trades only
+	ReturnPct float64   `json:"return_pct"` // closed trades only
+}
+
+type ReasonCount struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// BookPosition is an open position with its distance to the protective orders.
+type BookPosition struct {
+	Ticker       string  `json:"ticker"`
+	Quantity     int64   `json:"quantity"`
+	EntryPrice   float64 `json:"entry_price"`
+	CurrentPrice float64 `json:"current_price"` // 0 when the snapshot has no quote
+	PnL          float64 `json:"pnl"`
+	StopLoss     float64 `json:"stop_loss"`
+	TakeProfit   float64 `json:"take_profit"`
+	StopDistPct  float64 `json:"stop_dist_pct"` // from the current price, negative below it; 0 when unknown
+	TakeDistPct  float64 `json:"take_dist_pct"`
+}
+
+// SnapshotPosition is a position as stored in PortfolioSnapshot.PositionsJSON.
+type SnapshotPosition struct {
+	Ticker       string
+	Quantity     float64
+	AvgPrice     float64
+	CurrentPrice float64
+	PnL          float64
+}
+
+// DigestInput is the raw data of a digest, already limited to the period.
+type DigestInput struct {
+	Weekly       bool
+	From, To     time.Time
+	Period       Report
+	Trailing     Report
+	TrailingDays int
+
+	Trades        []storage.Trade       // every trade record created in the period
+	RoundTrips    []storage.Trade       // BUY trades closed in the period
+	OpenTrades    []storage.Trade       // BUY trades still open
+	Positions     []SnapshotPosition    // latest portfolio snapshot
+	Cycles        []storage.AnalysisLog // analysis cycles of the period
+	BlockReasons  []string              // guard block reasons, one per blocked decision
+	CommissionPct float64               // per side
+}
+
+// NewDigest assembles a digest from its raw data.
+func NewDigest(in DigestInput) Digest {
+	d := Digest{
+		Weekly:       in.Weekly,
+		From:         in.From,
+		To:           in.To,
+		Period:       in.Period,
+		Trailing:     in.Trailing,
+		TrailingDays: in.TrailingDays,
+	}
+
+	for _, t := range in.Trades {
+		d.FeesRub += t.Price * float64(t.Quantity) * in.CommissionPct / 100
+		if t.Action == "BUY" {
+			d.Opened = append(d.Opened, DigestTrade{Ticker: t.Ticker, Time: t.CreatedAt, Price: t.Price, Quantity: t.Quantity})
+		}
+	}
+	for _, t := range in.RoundTrips {
+		d.RealizedPnL += t.PnL
+		closed := DigestTrade{Ticker: t.Ticker, Time: t.UpdatedAt, Price: t.Price, Quantity: t.Quantity, PnL: t.PnL}
+		if notional := t.Price * float64(t.Quantity); notional > 0 {
+			closed.ReturnPct = t.PnL / notional * 100
+		}
+		d.Closed = append(d.Closed, closed)
+	}
+
+	for _, c := range in.Cycles {
+		d.Cycles++
+		if c.Error != "" {
+			d.FailedCycles++
+		}
+		d.AICostRub += c.CostRub
+	}
+	d.Blocks = countReasons(in.BlockReasons)
+
+	positions := make(map[string]SnapshotPosition, len(in.Positions))
+	for _, p := range in.Positions {
+		positions[p.Ticker] = p
+		d.UnrealizedPnL += p.PnL
+	}
+	for _, t := range in.OpenTrades {
+		b := BookPosition{
+			Ticker:     t.Ticker,
+			Quantity:   t.Quantity,
+			EntryPrice: t.Price,
+			StopLoss:   t.StopLossPrice,
+			TakeProfit: t.TakeProfitPrice,
+		}
+		if p, ok := positions[t.Ticker]; ok && p.CurrentPrice > 0 {
+			b.CurrentPrice, b.PnL = p.CurrentPrice, p.PnL
+			if b.StopLoss > 0 {
+				b.StopDistPct = pctChange(b.CurrentPrice, b.StopLoss)
+			}
+			if b.TakeProfit > 0 {
+				b.TakeDistPct = pctChange(b.CurrentPrice, b.TakeProfit)
+			}
+		}
+		d.Book = append(d.Book, b)
+	}
+	sort.Slice(d.Book, func(i, j int) bool { return d.Book[i].Ticker < d.Book[j].Ticker })
+	return d
+}
+
+func countReasons(reasons []string) []ReasonCount {
+	counts := make(map[string]int)
+	for _, r := range reasons {
+		counts[r]++
+	}
+	out := make([]ReasonCount, 0, len(counts))
+	for r, n := range counts {
+		out = append(out, ReasonCount{Reason: r, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Reason < out[j].Reason
+	})
+	return out
+}
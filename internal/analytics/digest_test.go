@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+func TestNewDigest_ActivityCostsAndBook(t *testing.T) {
+	d := NewDigest(DigestInput{
+		From: at(2, 0),
+		To:   at(2, 19),
+		Trades: []storage.Trade{
+			{Ticker: "SBER", Action: "BUY", Price: 300, Quantity: 10, CreatedAt: at(2, 11)},
+			{Ticker: "GAZP", Action: "SELL", Price: 110, Quantity: 10, CreatedAt: at(2, 15)},
+		},
+		RoundTrips: []storage.Trade{
+			{Ticker: "GAZP", Action: "BUY", Price: 100, Quantity: 10, PnL: 99, UpdatedAt: at(2, 15)},
+		},
+		OpenTrades: []storage.Trade{
+			{Ticker: "SBER", Action: "BUY", Price: 300, Quantity: 10, StopLossPrice: 285, TakeProfitPrice: 330},
+			{Ticker: "LKOH", Action: "BUY", Price: 7000, Quantity: 1},
+		},
+		Positions: []SnapshotPosition{{Ticker: "SBER", Quantity: 10, AvgPrice: 300, CurrentPrice: 300, PnL: 0}, {Ticker: "LKOH", PnL: -12}},
+		Cycles: []storage.AnalysisLog{
+			{CostRub: 1.5},
+			{CostRub: 2, Error: "timeout"},
+		},
+		BlockReasons:  []string{"лимит сделок за день", "RSI перекупленность", "лимит сделок за день"},
+		CommissionPct: 0.05,
+	})
+
+	if len(d.Opened) != 1 || d.Opened[0].Ticker != "SBER" {
+		t.Errorf("expected SBER opened, got %+v", d.Opened)
+	}
+	if len(d.Closed) != 1 || d.Closed[0].ReturnPct != 9.9 || d.RealizedPnL != 99 {
+		t.Errorf("unexpected closed %+v, realized %.2f", d.Closed, d.RealizedPnL)
+	}
+	if math.Abs(d.FeesRub-(3000+1100)*0.0005) > 1e-9 {
+		t.Errorf("unexpected fees %.4f", d.FeesRub)
+	}
+	if d.UnrealizedPnL != -12 {
+		t.Errorf("unexpected unrealized %.2f", d.UnrealizedPnL)
+	}
+	if d.Cycles != 2 || d.FailedCycles != 1 || d.AICostRub != 3.5 {
+		t.Errorf("unexpected cycles %d/%d cost %.2f", d.Cycles, d.FailedCycles, d.AICostRub)
+	}
+	if len(d.Blocks) != 2 || d.Blocks[0] != (ReasonCount{Reason: "лимит сделок за день", Count: 2}) {
+		t.Errorf("unexpected blocks %+v", d.Blocks)
+	}
+
+	if len(d.Book) != 2 || d.Book[0].Ticker != "LKOH" || d.Book[1].Ticker != "SBER" {
+		t.Fatalf("expected book sorted by ticker, got %+v", d.Book)
+	}
+	if lkoh := d.Book[0]; lkoh.CurrentPrice != 0 || lkoh.StopDistPct != 0 {
+		t.Errorf("expected no distances without a quote, got %+v", lkoh)
+	}
+	if sber := d.Book[1]; sber.StopDistPct != -5 || sber.TakeDistPct != 10 {
+		t.Errorf("expected SL -5%% and TP +10%%, got %+v", sber)
+	}
+}
+
+func TestEquityChartPNG(t *testing.T) {
+	points := EquityCurve([]storage.PortfolioSnapshot{
+		snap(at(2, 10), 100),
+		snap(at(2, 12), 110),
+		snap(at(2, 14), 99),
+		snap(at(3, 10), 105),
+	})
+	data, err := EquityChartPNG(points, 400, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Errorf("unexpected size %v", b)
+	}
+
+	if _, err := EquityChartPNG(points[:1], 400, 200); err == nil {
+		t.Error("expected error for a single point")
+	}
+}
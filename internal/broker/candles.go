@@ -1,6 +1,8 @@
 package broker
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -83,6 +85,7 @@ func (bc *BrokerClient) fetchOneTicker(ticker string) (*CandleSnapshot, error) {
 		0,
 	)
 	if err != nil {
+		apiFailed("GetCandles")
 		return nil, err
 	}
 
@@ -184,6 +187,72 @@ func absDuration(d time.Duration) time.Duration {
 	return d
 }
 
+// Bar is a single timestamped candle.
+type Bar struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+// candleIntervals maps interval names to the API interval and the widest window
+// T-Invest allows per GetCandles request for it.
+var candleIntervals = map[string]struct {
+	interval pb.CandleInterval
+	window   time.Duration
+}{
+	"5m":  {pb.CandleInterval_CANDLE_INTERVAL_5_MIN, 24 * time.Hour},
+	"15m": {pb.CandleInterval_CANDLE_INTERVAL_15_MIN, 24 * time.Hour},
+	"1h":  {pb.CandleInterval_CANDLE_INTERVAL_HOUR, 7 * 24 * time.Hour},
+	"1d":  {pb.CandleInterval_CANDLE_INTERVAL_DAY, 365 * 24 * time.Hour},
+}
+
+const maxCandleWindows = 7
+
+// GetBars returns up to count most recent candles for a ticker at the given interval
+// ("5m", "15m", "1h", "1d"), stepping back window by window over weekends and holidays.
+func (bc *BrokerClient) GetBars(ticker, interval string, count int) ([]Bar, error) {
+	iv, ok := candleIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported candle interval %q", interval)
+	}
+	uid, err := bc.ResolveTickerToUID(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	md := bc.Client.NewMarketDataServiceClient()
+	var bars []Bar
+	to := time.Now()
+	for i := 0; i < maxCandleWindows && len(bars) < count; i++ {
+		from := to.Add(-iv.window)
+		resp, err := md.GetCandles(uid, iv.interval, from, to, pb.GetCandlesRequest_CANDLE_SOURCE_EXCHANGE, 0)
+		if err != nil {
+			apiFailed("GetCandles")
+			return nil, fmt.Errorf("get candles %s %s: %w", ticker, interval, err)
+		}
+		for _, c := range resp.GetCandles() {
+			bars = append(bars, Bar{
+				Time:   c.GetTime().AsTime(),
+				Open:   c.GetOpen().ToFloat(),
+				High:   c.GetHigh().ToFloat(),
+				Low:    c.GetLow().ToFloat(),
+				Close:  c.GetClose().ToFloat(),
+				Volume: float64(c.GetVolume()),
+			})
+		}
+		to = from
+	}
+
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	if len(bars) > count {
+		bars = bars[len(bars)-count:]
+	}
+	return bars, nil
+}
+
 // FilterTradable checks which instrument UIDs are available for API trading.
 func (bc *BrokerClient) FilterTradable(uids []string) (map[string]bool, error) {
 	if len(uids) == 0 {
@@ -193,6 +262,7 @@ func (bc *BrokerClient) FilterTradable(uids []string) (map[string]bool, error) {
 	md := bc.Client.NewMarketDataServiceClient()
 	resp, err := md.GetTradingStatuses(uids)
 	if err != nil {
+		apiFailed("GetTradingStatuses")
 		return nil, err
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/camuig/rus-trader/internal/config"
 	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/metrics"
 )
 
 const (
@@ -72,6 +73,11 @@ func (bc *BrokerClient) setupSandbox() error {
 	return nil
 }
 
+// apiFailed counts a failed call of a broker API method.
+func apiFailed(method string) {
+	metrics.BrokerErrors.Inc(method)
+}
+
 func (bc *BrokerClient) AccountID() string {
 	return bc.Client.Config.AccountId
 }
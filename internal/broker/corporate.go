@@ -0,0 +1,106 @@
+package broker
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/russianinvestments/invest-api-go-sdk/proto"
+)
+
+// Dividend is a dividend of a share as reported by T-Invest.
+type Dividend struct {
+	LastBuyDate time.Time // last day to buy with the right to the dividend, zero if unknown
+	RecordDate  time.Time
+	Amount      float64 // per share, net
+	Currency    string
+	YieldPct    float64 // to the close price before the record date
+}
+
+// ReportDate is a scheduled financial report of the issuer.
+type ReportDate struct {
+	Date       time.Time
+	PeriodYear int
+	PeriodNum  int    // quarter or half-year number, 0 for annual reports
+	PeriodType string // "quarter", "half" or "year"
+}
+
+// Dividends returns the dividends of a ticker with a record date in [from, to].
+func (bc *BrokerClient) Dividends(ticker string, from, to time.Time) ([]Dividend, error) {
+	uid, err := bc.ResolveTickerToUID(ticker)
+	if err != nil {
+		return nil, err
+	}
+	instruments := bc.Client.NewInstrumentsServiceClient()
+	resp, err := instruments.GetDividents(uid, from, to)
+	if err != nil {
+		apiFailed("GetDividends")
+		return nil, fmt.Errorf("get dividends %s: %w", ticker, err)
+	}
+
+	var result []Dividend
+	for _, d := range resp.GetDividends() {
+		div := Dividend{
+			LastBuyDate: timestampDate(d.GetLastBuyDate()),
+			RecordDate:  timestampDate(d.GetRecordDate()),
+		}
+		if net := d.GetDividendNet(); net != nil {
+			div.Amount = net.ToFloat()
+			div.Currency = net.GetCurrency()
+		}
+		if y := d.GetYieldValue(); y != nil {
+			div.YieldPct = y.ToFloat()
+		}
+		if div.RecordDate.IsZero() && div.LastBuyDate.IsZero() {
+			continue
+		}
+		result = append(result, div)
+	}
+	return result, nil
+}
+
+// ReportDates returns the financial reports of a ticker's issuer scheduled in [from, to].
+func (bc *BrokerClient) ReportDates(ticker string, from, to time.Time) ([]ReportDate, error) {
+	uid, err := bc.ResolveTickerToUID(ticker)
+	if err != nil {
+		return nil, err
+	}
+	instruments := bc.Client.NewInstrumentsServiceClient()
+	resp, err := instruments.GetAssetReports(uid, from, to)
+	if err != nil {
+		apiFailed("GetAssetReports")
+		return nil, fmt.Errorf("get asset reports %s: %w", ticker, err)
+	}
+
+	var result []ReportDate
+	for _, e := range resp.GetEvents() {
+		date := timestampDate(e.GetReportDate())
+		if date.IsZero() {
+			continue
+		}
+		report := ReportDate{
+			Date:       date,
+			PeriodYear: int(e.GetPeriodYear()),
+			PeriodNum:  int(e.GetPeriodNum()),
+		}
+		switch e.GetPeriodType() {
+		case pb.AssetReportPeriodType_PERIOD_TYPE_QUARTER:
+			report.PeriodType = "quarter"
+		case pb.AssetReportPeriodType_PERIOD_TYPE_SEMIANNUAL:
+			report.PeriodType = "half"
+		case pb.AssetReportPeriodType_PERIOD_TYPE_ANNUAL:
+			report.PeriodType = "year"
+		}
+		result = append(result, report)
+	}
+	return result, nil
+}
+
+// timestampDate returns the calendar date of an API timestamp as midnight UTC,
+// or zero when the timestamp is not set. T-Invest sends dates as midnight UTC.
+func timestampDate(ts interface{ AsTime() time.Time }) time.Time {
+	t := ts.AsTime().UTC()
+	if t.Unix() <= 0 {
+		return time.Time{}
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
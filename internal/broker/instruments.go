@@ -5,6 +5,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	pb "github.com/russianinvestments/invest-api-go-sdk/proto"
 )
 
 var instrumentCache sync.Map // instrumentUID -> ticker
@@ -25,6 +27,7 @@ func (bc *BrokerClient) resolveInstrumentUID(uid string) (string, error) {
 	instruments := bc.Client.NewInstrumentsServiceClient()
 	resp, err := instruments.InstrumentByUid(uid)
 	if err != nil {
+		apiFailed("InstrumentByUid")
 		return "", fmt.Errorf("instrument by uid %s: %w", uid, err)
 	}
 
@@ -38,6 +41,7 @@ func (bc *BrokerClient) ResolveTickerToUID(ticker string) (string, error) {
 	instruments := bc.Client.NewInstrumentsServiceClient()
 	resp, err := instruments.FindInstrument(ticker)
 	if err != nil {
+		apiFailed("FindInstrument")
 		return "", fmt.Errorf("find instrument %s: %w", ticker, err)
 	}
 
@@ -76,6 +80,7 @@ func (bc *BrokerClient) GetTickerBrief(ticker string) (string, error) {
 	instruments := bc.Client.NewInstrumentsServiceClient()
 	resp, err := instruments.InstrumentByUid(uid)
 	if err != nil {
+		apiFailed("InstrumentByUid")
 		return "", fmt.Errorf("instrument by uid %s: %w", uid, err)
 	}
 
@@ -96,6 +101,23 @@ func (bc *BrokerClient) GetTickerBrief(ticker string) (string, error) {
 	return brief, nil
 }
 
+// ShareNames returns the instrument names of shares traded on TQBR, by ticker.
+func (bc *BrokerClient) ShareNames() (map[string]string, error) {
+	instruments := bc.Client.NewInstrumentsServiceClient()
+	resp, err := instruments.Shares(pb.InstrumentStatus_INSTRUMENT_STATUS_BASE)
+	if err != nil {
+		apiFailed("Shares")
+		return nil, fmt.Errorf("list shares: %w", err)
+	}
+	names := make(map[string]string)
+	for _, share := range resp.GetInstruments() {
+		if share.GetClassCode() == "TQBR" && share.GetName() != "" {
+			names[share.GetTicker()] = share.GetName()
+		}
+	}
+	return names, nil
+}
+
 func formatTickerBrief(name, instrumentType string, lot int32, currency, country string) string {
 	parts := make([]string, 0, 5)
 	if name != "" {
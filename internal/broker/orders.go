@@ -52,6 +52,7 @@ func (bc *BrokerClient) BuyWithPrice(instrumentID string, lots int64, limitPrice
 	}
 
 	if err != nil {
+		apiFailed("PostOrder")
 		return nil, fmt.Errorf("buy order: %w", err)
 	}
 
@@ -96,6 +97,7 @@ func (bc *BrokerClient) SellWithPrice(instrumentID string, lots int64, limitPric
 	}
 
 	if err != nil {
+		apiFailed("PostOrder")
 		return nil, fmt.Errorf("sell order: %w", err)
 	}
 
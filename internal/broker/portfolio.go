@@ -36,6 +36,7 @@ func (bc *BrokerClient) GetPortfolio() (*PortfolioInfo, error) {
 		sandbox := bc.Client.NewSandboxServiceClient()
 		r, err := sandbox.GetSandboxPortfolio(accountID, currency)
 		if err != nil {
+			apiFailed("GetSandboxPortfolio")
 			return nil, fmt.Errorf("get sandbox portfolio: %w", err)
 		}
 		resp = r.PortfolioResponse
@@ -43,6 +44,7 @@ func (bc *BrokerClient) GetPortfolio() (*PortfolioInfo, error) {
 		ops := bc.Client.NewOperationsServiceClient()
 		r, err := ops.GetPortfolio(accountID, currency)
 		if err != nil {
+			apiFailed("GetPortfolio")
 			return nil, fmt.Errorf("get portfolio: %w", err)
 		}
 		resp = r.PortfolioResponse
@@ -98,6 +100,7 @@ func (bc *BrokerClient) GetLastPrice(instrumentUID string) float64 {
 	md := bc.Client.NewMarketDataServiceClient()
 	resp, err := md.GetLastPrices([]string{instrumentUID})
 	if err != nil {
+		apiFailed("GetLastPrices")
 		bc.Logger.Error("get last price", "instrument", instrumentUID, "error", err)
 		return 0
 	}
@@ -111,27 +114,46 @@ func (bc *BrokerClient) GetLastPrice(instrumentUID string) float64 {
 	return 0
 }
 
-// GetSpreadPct fetches the orderbook and calculates bid/ask spread as percentage.
-// Returns 0 if orderbook is unavailable (e.g., sandbox mode).
-func (bc *BrokerClient) GetSpreadPct(instrumentUID string) float64 {
+type OrderBookLevel struct {
+	Price float64 `json:"price"`
+	Lots  int64   `json:"lots"`
+}
+
+type OrderBook struct {
+	Bids      []OrderBookLevel `json:"bids"`
+	Asks      []OrderBookLevel `json:"asks"`
+	SpreadPct float64          `json:"spread_pct"`
+}
+
+// GetOrderBook fetches the order book up to the given depth.
+func (bc *BrokerClient) GetOrderBook(instrumentUID string, depth int32) (*OrderBook, error) {
 	md := bc.Client.NewMarketDataServiceClient()
-	resp, err := md.GetOrderBook(instrumentUID, 1) // depth=1, only best bid/ask
+	resp, err := md.GetOrderBook(instrumentUID, depth)
 	if err != nil {
-		bc.Logger.Debug("get orderbook", "instrument", instrumentUID, "error", err)
-		return 0
+		apiFailed("GetOrderBook")
+		return nil, fmt.Errorf("get order book: %w", err)
 	}
 
-	asks := resp.GetAsks()
-	bids := resp.GetBids()
-	if len(asks) == 0 || len(bids) == 0 {
-		return 0
+	book := &OrderBook{}
+	for _, b := range resp.GetBids() {
+		book.Bids = append(book.Bids, OrderBookLevel{Price: b.GetPrice().ToFloat(), Lots: b.GetQuantity()})
 	}
+	for _, a := range resp.GetAsks() {
+		book.Asks = append(book.Asks, OrderBookLevel{Price: a.GetPrice().ToFloat(), Lots: a.GetQuantity()})
+	}
+	if len(book.Bids) > 0 && len(book.Asks) > 0 && book.Bids[0].Price > 0 {
+		book.SpreadPct = (book.Asks[0].Price - book.Bids[0].Price) / book.Bids[0].Price * 100
+	}
+	return book, nil
+}
 
-	bestAsk := asks[0].GetPrice().ToFloat()
-	bestBid := bids[0].GetPrice().ToFloat()
-	if bestBid <= 0 {
+// GetSpreadPct fetches the orderbook and calculates bid/ask spread as percentage.
+// Returns 0 if orderbook is unavailable (e.g., sandbox mode).
+func (bc *BrokerClient) GetSpreadPct(instrumentUID string) float64 {
+	book, err := bc.GetOrderBook(instrumentUID, 1) // depth=1, only best bid/ask
+	if err != nil {
+		bc.Logger.Debug("get orderbook", "instrument", instrumentUID, "error", err)
 		return 0
 	}
-
-	return (bestAsk - bestBid) / bestBid * 100
+	return book.SpreadPct
 }
@@ -26,6 +26,7 @@ func (bc *BrokerClient) PlaceStopLoss(instrumentID string, lots int64, stopPrice
 		OrderID:       investgo.CreateUid(),
 	})
 	if err != nil {
+		apiFailed("PostStopOrder")
 		return "", fmt.Errorf("place stop loss: %w", err)
 	}
 
@@ -50,6 +51,7 @@ func (bc *BrokerClient) PlaceTakeProfit(instrumentID string, lots int64, targetP
 		OrderID:       investgo.CreateUid(),
 	})
 	if err != nil {
+		apiFailed("PostStopOrder")
 		return "", fmt.Errorf("place take profit: %w", err)
 	}
 
@@ -65,11 +67,13 @@ func (bc *BrokerClient) CancelStopOrders(slOrderID, tpOrderID string) {
 
 	if slOrderID != "" {
 		if _, err := stopOrders.CancelStopOrder(bc.AccountID(), slOrderID); err != nil {
+			apiFailed("CancelStopOrder")
 			bc.Logger.Error("cancel stop loss", "order_id", slOrderID, "error", err)
 		}
 	}
 	if tpOrderID != "" {
 		if _, err := stopOrders.CancelStopOrder(bc.AccountID(), tpOrderID); err != nil {
+			apiFailed("CancelStopOrder")
 			bc.Logger.Error("cancel take profit", "order_id", tpOrderID, "error", err)
 		}
 	}
@@ -0,0 +1,180 @@
+// Package calibration checks how well the model's confidence predicts realised
+// trade outcomes and fits a monotone curve mapping raw confidence to hit rate.
+package calibration
+
+import (
+	"math"
+	"sort"
+
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// Outcome is a closed position with the confidence the model gave when opening it.
+type Outcome struct {
+	Confidence int     // raw model confidence, 0-100
+	PnL        float64 // realised P&L after commission, RUB
+	ReturnPct  float64 // P&L relative to entry notional, %
+}
+
+// Bucket aggregates outcomes for a confidence range [From, To].
+type Bucket struct {
+	From         int
+	To           int
+	Trades       int
+	Wins         int
+	HitRate      float64 // % of trades with positive P&L
+	AvgReturnPct float64
+	AvgPnL       float64
+	Calibrated   float64 // fitted hit rate after monotone smoothing, %
+}
+
+// OutcomesFromTrades converts closed BUY trades with a recorded confidence into outcomes.
+func OutcomesFromTrades(trades []storage.Trade) []Outcome {
+	out := make([]Outcome, 0, len(trades))
+	for _, t := range trades {
+		if t.Action != "BUY" || t.Status != "closed" || t.Confidence <= 0 {
+			continue
+		}
+		o := Outcome{Confidence: t.Confidence, PnL: t.PnL}
+		if notional := t.Price * float64(t.Quantity); notional > 0 {
+			o.ReturnPct = t.PnL / notional * 100
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// BuildBuckets groups outcomes into confidence buckets of the given size.
+// Only non-empty buckets are returned, ordered by confidence.
+func BuildBuckets(outcomes []Outcome, size int) []Bucket {
+	if size <= 0 {
+		size = 10
+	}
+
+	byFrom := make(map[int]*Bucket)
+	for _, o := range outcomes {
+		c := clamp(o.Confidence, 0, 100)
+		from := c / size * size
+		if from >= 100 {
+			from = 99 / size * size // 100 belongs to the top bucket
+		}
+		b, ok := byFrom[from]
+		if !ok {
+			to := from + size - 1
+			if to >= 99 {
+				to = 100
+			}
+			b = &Bucket{From: from, To: to}
+			byFrom[from] = b
+		}
+		b.Trades++
+		if o.PnL > 0 {
+			b.Wins++
+		}
+		b.AvgReturnPct += o.ReturnPct
+		b.AvgPnL += o.PnL
+	}
+
+	buckets := make([]Bucket, 0, len(byFrom))
+	for _, b := range byFrom {
+		b.HitRate = float64(b.Wins) / float64(b.Trades) * 100
+		b.AvgReturnPct /= float64(b.Trades)
+		b.AvgPnL /= float64(b.Trades)
+		buckets = append(buckets, *b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].From < buckets[j].From })
+
+	fitIsotonic(buckets)
+	return buckets
+}
+
+// fitIsotonic sets Calibrated to a non-decreasing fit of bucket hit rates
+// (pool adjacent violators, weighted by trade count).
+func fitIsotonic(buckets []Bucket) {
+	type block struct {
+		sum    float64
+		weight float64
+		count  int
+	}
+	blocks := make([]block, 0, len(buckets))
+	for _, b := range buckets {
+		blocks = append(blocks, block{sum: b.HitRate * float64(b.Trades), weight: float64(b.Trades), count: 1})
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sum/prev.weight <= last.sum/last.weight {
+				break
+			}
+			blocks = blocks[:len(blocks)-2]
+			blocks = append(blocks, block{sum: prev.sum + last.sum, weight: prev.weight + last.weight, count: prev.count + last.count})
+		}
+	}
+
+	i := 0
+	for _, bl := range blocks {
+		for k := 0; k < bl.count; k++ {
+			buckets[i].Calibrated = bl.sum / bl.weight
+			i++
+		}
+	}
+}
+
+// Curve maps raw model confidence to the empirically observed hit rate.
+type Curve struct {
+	points []point
+	trades int
+}
+
+type point struct {
+	x float64 // bucket midpoint, raw confidence
+	y float64 // calibrated hit rate, %
+}
+
+// Fit builds a calibration curve from buckets. It returns nil when fewer than
+// minTrades outcomes are available, so callers fall back to raw confidence.
+func Fit(buckets []Bucket, minTrades int) *Curve {
+	c := &Curve{}
+	for _, b := range buckets {
+		c.trades += b.Trades
+		c.points = append(c.points, point{x: float64(b.From+b.To) / 2, y: b.Calibrated})
+	}
+	if len(c.points) == 0 || c.trades < minTrades {
+		return nil
+	}
+	return c
+}
+
+// Trades is the number of outcomes the curve was fitted on.
+func (c *Curve) Trades() int {
+	return c.trades
+}
+
+// Apply remaps raw confidence through the curve, interpolating linearly between
+// bucket midpoints and holding the end values flat outside them.
+func (c *Curve) Apply(raw int) int {
+	x := float64(raw)
+	pts := c.points
+	if x <= pts[0].x {
+		return round(pts[0].y)
+	}
+	for i := 1; i < len(pts); i++ {
+		if x <= pts[i].x {
+			a, b := pts[i-1], pts[i]
+			return round(a.y + (b.y-a.y)*(x-a.x)/(b.x-a.x))
+		}
+	}
+	return round(pts[len(pts)-1].y)
+}
+
+func round(v float64) int {
+	return clamp(int(math.Round(v)), 0, 100)
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,112 @@
+package calibration
+
+import (
+	"testing"
+
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+func outcomes(confidence, wins, losses int) []Outcome {
+	var out []Outcome
+	for i := 0; i < wins; i++ {
+		out = append(out, Outcome{Confidence: confidence, PnL: 100, ReturnPct: 2})
+	}
+	for i := 0; i < losses; i++ {
+		out = append(out, Outcome{Confidence: confidence, PnL: -50, ReturnPct: -1})
+	}
+	return out
+}
+
+func TestBuildBuckets_HitRateAndReturns(t *testing.T) {
+	var all []Outcome
+	all = append(all, outcomes(72, 3, 1)...)
+	all = append(all, outcomes(95, 1, 1)...)
+	all = append(all, outcomes(100, 1, 0)...)
+
+	buckets := BuildBuckets(all, 10)
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(buckets))
+	}
+
+	b := buckets[0]
+	if b.From != 70 || b.To != 79 || b.Trades != 4 || b.Wins != 3 {
+		t.Fatalf("unexpected first bucket %+v", b)
+	}
+	if b.HitRate != 75 || b.AvgReturnPct != 1.25 || b.AvgPnL != 62.5 {
+		t.Fatalf("unexpected first bucket stats %+v", b)
+	}
+
+	top := buckets[1]
+	if top.From != 90 || top.To != 100 || top.Trades != 3 {
+		t.Fatalf("confidence 100 must fall into the top bucket, got %+v", top)
+	}
+}
+
+func TestBuildBuckets_CalibratedIsMonotone(t *testing.T) {
+	var all []Outcome
+	all = append(all, outcomes(65, 2, 2)...) // 50%
+	all = append(all, outcomes(75, 3, 1)...) // 75%
+	all = append(all, outcomes(85, 1, 3)...) // 25%: overconfident
+	all = append(all, outcomes(95, 4, 0)...) // 100%
+
+	buckets := BuildBuckets(all, 10)
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i].Calibrated < buckets[i-1].Calibrated {
+			t.Fatalf("calibrated rates must be non-decreasing: %+v", buckets)
+		}
+	}
+	// 75% and 25% buckets with equal weight pool to 50%.
+	if buckets[1].Calibrated != 50 || buckets[2].Calibrated != 50 {
+		t.Fatalf("expected pooled 50%%, got %.1f and %.1f", buckets[1].Calibrated, buckets[2].Calibrated)
+	}
+}
+
+func TestFit_RequiresMinTrades(t *testing.T) {
+	buckets := BuildBuckets(outcomes(80, 3, 2), 10)
+	if Fit(buckets, 10) != nil {
+		t.Fatalf("expected nil curve below min trades")
+	}
+	if Fit(nil, 0) != nil {
+		t.Fatalf("expected nil curve without data")
+	}
+	if c := Fit(buckets, 5); c == nil || c.Trades() != 5 {
+		t.Fatalf("expected curve fitted on 5 trades")
+	}
+}
+
+func TestCurve_ApplyInterpolates(t *testing.T) {
+	var all []Outcome
+	all = append(all, outcomes(60, 1, 3)...) // 25%, midpoint 64.5
+	all = append(all, outcomes(80, 3, 1)...) // 75%, midpoint 84.5
+
+	curve := Fit(BuildBuckets(all, 10), 1)
+	cases := map[int]int{
+		50:  25, // below first midpoint: flat
+		64:  25,
+		75:  51, // 25 + 50 * (75-64.5)/20
+		95:  75, // above last midpoint: flat
+		100: 75,
+	}
+	for raw, want := range cases {
+		if got := curve.Apply(raw); got != want {
+			t.Fatalf("Apply(%d) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func TestOutcomesFromTrades_SkipsUnusable(t *testing.T) {
+	trades := []storage.Trade{
+		{Action: "BUY", Status: "closed", Confidence: 80, Price: 100, Quantity: 10, PnL: 20},
+		{Action: "BUY", Status: "open", Confidence: 80, Price: 100, Quantity: 10},
+		{Action: "BUY", Status: "closed", Confidence: 0, Price: 100, Quantity: 10, PnL: 5},
+		{Action: "SELL", Status: "closed", Confidence: 80, Price: 100, Quantity: 10, PnL: 20},
+	}
+
+	got := OutcomesFromTrades(trades)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 outcome, got %d", len(got))
+	}
+	if got[0].ReturnPct != 2 {
+		t.Fatalf("expected 2%% return, got %.2f", got[0].ReturnPct)
+	}
+}
@@ -2,19 +2,30 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
 )
 
+
+
 type Config struct {
-	Tinkoff  TinkoffConfig  `yaml:"tinkoff"`
-	DeepSeek DeepSeekConfig `yaml:"deepseek"`
-	Trading  TradingConfig  `yaml:"trading"`
-	Telegram TelegramConfig `yaml:"telegram"`
-	Web      WebConfig      `yaml:"web"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	Tinkoff         TinkoffConfig         `yaml:"tinkoff"`
+	DeepSeek        DeepSeekConfig        `yaml:"deepseek"`
+	Trading         TradingConfig         `yaml:"trading"`
+	Universe        UniverseConfig        `yaml:"universe"`
+	Telegram        TelegramConfig        `yaml:"telegram"`
+	Notify          NotifyConfig          `yaml:"notify"`
+	News            NewsConfig            `yaml:"news"`
+	CorporateEvents CorporateEventsConfig `yaml:"corporate_events"`
+	Market          MarketConfig          `yaml:"market"`
+	MOEX            MOEXConfig            `yaml:"moex"`
+	Web             WebConfig             `yaml:"web"`
+	Logging         LoggingConfig         `yaml:"logging"`
 }
 
 type TinkoffConfig struct {
@@ -32,6 +43,41 @@ type DeepSeekConfig struct {
 	MaxTickerNewsItems  int    `yaml:"max_ticker_news_items"`
 	MaxWorldNewsItems   int    `yaml:"max_world_news_items"`
 	MaxNewsTitleChars   int    `yaml:"max_news_title_chars"`
+
+	Pricing        map[string]ModelPrice `yaml:"pricing"`          // model name -> price per 1M tokens (USD)
+	UsdRubRate     float64               `yaml:"usd_rub_rate"`     // conversion rate for RUB cost reporting
+	DailyBudgetRub float64               `yaml:"daily_budget_rub"` // AI spend cap per day, 0=disabled
+
+	Tools   AgentToolsConfig `yaml:"tools"`
+	Prompts PromptsConfig    `yaml:"prompts"`
+}
+
+// PromptsConfig selects prompt template versions. With several variants each
+// analysis cycle is assigned to one of them at random, proportionally to weight.
+type PromptsConfig struct {
+	Dir      string          `yaml:"dir"` // custom versions in <dir>/<version>/{system,user}.tmpl; built-in used otherwise
+	Variants []PromptVariant `yaml:"variants"`
+}
+
+type PromptVariant struct {
+	Version string `yaml:"version"`
+	Weight  int    `yaml:"weight"`
+}
+
+// AgentToolsConfig controls tool/function calling during analysis.
+type AgentToolsConfig struct {
+	Enabled             bool `yaml:"enabled"`
+	MaxCalls            int  `yaml:"max_calls"`             // tool calls per cycle
+	CallTimeoutSeconds  int  `yaml:"call_timeout_seconds"`  // per tool call
+	TotalTimeoutSeconds int  `yaml:"total_timeout_seconds"` // all tool calls in a cycle
+	MaxResultChars      int  `yaml:"max_result_chars"`      // tool result truncation
+}
+
+// ModelPrice is the per-model token price in USD per 1M tokens.
+type ModelPrice struct {
+	InputPerMTok       float64 `yaml:"input_per_mtok"`        // prompt tokens, cache miss
+	CachedInputPerMTok float64 `yaml:"cached_input_per_mtok"` // prompt tokens, cache hit
+	OutputPerMTok      float64 `yaml:"output_per_mtok"`       // completion tokens incl. reasoning
 }
 
 type TradingConfig struct {
@@ -53,16 +99,194 @@ type TradingConfig struct {
 	TrailingLockProfitPct float64 `yaml:"trailing_lock_profit_pct"` // % to TP to lock 50% profit
 	LimitOrderSlippage   float64 `yaml:"limit_order_slippage"`    // % slippage for limit orders, 0=market
 	NoLastHourBuy        bool    `yaml:"no_last_hour_buy"`        // block BUY after 17:50 MSK
+	BlockBuyNewsSentiment float64 `yaml:"block_buy_news_sentiment"` // block BUY when news sentiment <= value (-1..0), 0=disabled
+	NoBuyBeforeDividendDays int `yaml:"no_buy_before_dividend_days"` // block BUY this many days before an ex-dividend date, 0=disabled
+	NoBuyBeforeReportDays int `yaml:"no_buy_before_report_days"` // block BUY this many days before a financial report, 0=disabled
+	ExitBeforeReportDays int `yaml:"exit_before_report_days"` // close positions this many days before a financial report, 0=disabled
+	RiskOffBlockBuy bool `yaml:"risk_off_block_buy"` // block BUY in the risk-off market regime
+	RiskOffSizePct float64 `yaml:"risk_off_size_pct"` // position size in the risk-off regime, % of normal, 0=unchanged
+
+	Calibration CalibrationConfig `yaml:"calibration"`
+}
+
+// UniverseConfig selects the TQBR shares analysed during the day. The
+// universe is rebuilt once a day from average turnover and liquidity filters.
+type UniverseConfig struct {
+	Size              int      `yaml:"size"`                 // tickers kept, by average turnover
+	LookbackDays      int      `yaml:"lookback_days"`        // trading days of turnover and spread history
+	MinAvgTurnoverRub float64  `yaml:"min_avg_turnover_rub"` // 0=disabled
+	MinPrice          float64  `yaml:"min_price"`            // RUB per share, 0=disabled
+	MinLotValueRub    float64  `yaml:"min_lot_value_rub"`    // price of one lot, 0=disabled
+	MaxAvgSpreadPct   float64  `yaml:"max_avg_spread_pct"`   // average bid/offer spread, 0=disabled
+	Indexes           []string `yaml:"indexes"`              // keep members of any of these indexes, e.g. IMOEX, MOEXBMI; empty=all shares
+	Include           []string `yaml:"include"`              // always in the universe
+	Exclude           []string `yaml:"exclude"`              // never in the universe
+}
+
+// CalibrationConfig controls confidence calibration against realised trade outcomes.
+type CalibrationConfig struct {
+	Enabled      bool `yaml:"enabled"`       // remap confidence through the fitted curve before gating and sizing
+	MinTrades    int  `yaml:"min_trades"`    // closed trades required before the curve is applied
+	LookbackDays int  `yaml:"lookback_days"` // history window for fitting
+	BucketSize   int  `yaml:"bucket_size"`   // confidence bucket width
 }
 
 type TelegramConfig struct {
-	Enabled  bool   `yaml:"enabled"`
-	BotToken string `yaml:"bot_token"`
-	ChatID   int64  `yaml:"chat_id"`
+	Enabled      bool   `yaml:"enabled"`
+	BotToken     string `yaml:"bot_token"`
+	ChatID       int64  `yaml:"chat_id"`
+	DailyReport  bool   `yaml:"daily_report"`  // digest after the session close
+	WeeklyReport bool   `yaml:"weekly_report"` // digest of the week on Friday after the close
+	ReportChart  bool   `yaml:"report_chart"`  // attach a PNG equity chart to digests
+}
+
+// NotifyConfig routes alerts to delivery channels. With telegram enabled and no
+// telegram channel listed, a telegram channel receiving everything is added.
+type NotifyConfig struct {
+	QueueSize   int             `yaml:"queue_size"`   // pending messages per channel; overflow is dropped
+	MaxAttempts int             `yaml:"max_attempts"` // delivery attempts per message, with exponential backoff
+	Channels    []NotifyChannel `yaml:"channels"`
+}
+
+// Notification event kinds and severities accepted in routing rules.
+var (
+	NotifyEvents     = []string{"buy", "sell", "blocked", "error", "status", "report"}
+	NotifySeverities = []string{"info", "warning", "error"}
+)
+
+type NotifyChannel struct {
+	Name        string   `yaml:"name"`         // defaults to the type
+	Type        string   `yaml:"type"`         // telegram, webhook, email or file
+	Events      []string `yaml:"events"`       // event kinds to deliver, empty = all
+	MinSeverity string   `yaml:"min_severity"` // info, warning or error
+
+	URL     string            `yaml:"url"`     // webhook: JSON POST target
+	Headers map[string]string `yaml:"headers"` // webhook: extra headers, e.g. Authorization
+	SMTP    SMTPConfig        `yaml:"smtp"`    // email
+	Path    string            `yaml:"path"`    // file: JSON lines appended
+}
+
+type SMTPConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"` // 465 = implicit TLS, otherwise STARTTLS when offered
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// NewsConfig controls news sources and how news are matched to tickers.
+type NewsConfig struct {
+	Presets             []string            `yaml:"presets"`              // built-in source sets, see NewsPresets
+	Sources             []NewsSource        `yaml:"sources"`              // custom sources; a preset source with the same name is overridden
+	NamesRefreshHours   int                 `yaml:"names_refresh_hours"`  // how often company names are reloaded from ISS and T-Invest
+	NamesCache          string              `yaml:"names_cache"`          // local copy of the names, used until the first refresh
+	Aliases             map[string][]string `yaml:"aliases"`              // extra names per ticker, e.g. SBER: [Греф]
+	IgnoreNames         []string            `yaml:"ignore_names"`         // generated names that cause false matches
+	DuplicateSimilarity float64             `yaml:"duplicate_similarity"` // title shingle similarity (0..1) from which headlines are near-duplicates
+	KeepDays            int                 `yaml:"keep_days"`            // how long seen headlines are kept in the database
+}
+
+// NewsSource is a headline feed.
+type NewsSource struct {
+	Name           string  `yaml:"name"`
+	Type           string  `yaml:"type"` // rss, atom, iss (MOEX sitenews) or json (JSON Feed)
+	URL            string  `yaml:"url"`
+	Language       string  `yaml:"language"`        // ru or en
+	Scope          string  `yaml:"scope"`           // tickers (matched to tickers), market (background) or all
+	Weight         float64 `yaml:"weight"`          // ranking multiplier, default 1
+	TimeoutSeconds int     `yaml:"timeout_seconds"` // per-fetch timeout, default 10
+	Enabled        *bool   `yaml:"enabled"`         // default true
+}
+
+// CorporateEventsConfig controls how dividend and report dates are loaded.
+type CorporateEventsConfig struct {
+	Sources       []string `yaml:"sources"`        // tinvest (dividends and reports) and/or iss (dividends); empty disables events
+	LookaheadDays int      `yaml:"lookahead_days"` // how far ahead events are shown to the model
+	RefreshHours  int      `yaml:"refresh_hours"`  // how long the events of a ticker are cached
+}
+
+// DefaultISSURL is the MOEX ISS API root.
+const DefaultISSURL = "https://iss.moex.com/iss"
+
+// MOEXConfig points the MOEX client at ISS. iss_url can be set to a local
+// stand-in (see moex/moextest) to run the bot without iss.moex.com.
+type MOEXConfig struct {
+	ISSURL string `yaml:"iss_url"` // ISS root, without a trailing slash
+}
+
+// MarketConfig controls the market context: the trends of the index, bonds,
+// the ruble and oil that classify the market regime.
+type MarketConfig struct {
+	Instruments    []MarketInstrument `yaml:"instruments"`     // default IMOEX, RGBI, USD/RUB and Brent; empty disables the context
+	KeyRatePct     float64            `yaml:"key_rate_pct"`    // Bank of Russia key rate shown to the model, 0=not shown
+	RefreshMinutes int                `yaml:"refresh_minutes"` // how long the context is cached
+}
+
+// MarketInstrument is an ISS security whose daily candles feed the market context.
+type MarketInstrument struct {
+	Name     string `yaml:"name"`     // shown in the prompt
+	Role     string `yaml:"role"`     // equity, bonds, fx or oil
+	Engine   string `yaml:"engine"`   // ISS engine, e.g. stock
+	Market   string `yaml:"market"`   // ISS market, e.g. index
+	Security string `yaml:"security"` // ISS security, e.g. IMOEX
+	Asset    string `yaml:"asset"`    // FORTS asset code; the nearest contract is used instead of security
+}
+
+// MarketRoles are the accepted MarketInstrument roles.
+var MarketRoles = []string{"equity", "bonds", "fx", "oil"}
+
+// DefaultMarketInstruments are used when market.instruments is not set.
+// USD/RUB is the perpetual future, as exchange USD trading has stopped.
+var DefaultMarketInstruments = []MarketInstrument{
+	{Name: "IMOEX", Role: "equity", Engine: "stock", Market: "index", Security: "IMOEX"},
+	{Name: "RGBI", Role: "bonds", Engine: "stock", Market: "index", Security: "RGBI"},
+	{Name: "USD/RUB", Role: "fx", Engine: "futures", Market: "forts", Security: "USDRUBF"},
+	{Name: "Brent", Role: "oil", Engine: "futures", Market: "forts", Asset: "BR"},
 }
 
 type WebConfig struct {
-	Port int `yaml:"port"`
+	Port               int       `yaml:"port"`
+	LiveRefreshSeconds int       `yaml:"live_refresh_seconds"` // broker portfolio poll for the live dashboard
+	TLS                TLSConfig `yaml:"tls"`
+	Auth               WebAuth   `yaml:"auth"`
+	DevAssetsDir       string    `yaml:"-"` // set by the -dev flag: serve templates and static files from disk
+}
+
+// TLSConfig enables HTTPS with a local certificate. Both files or neither.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// Web roles: viewers can only read, operators can also control trading.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+)
+
+// WebAuth configures dashboard and API authentication.
+type WebAuth struct {
+	Enabled         bool       `yaml:"enabled"`
+	SessionTTLHours int        `yaml:"session_ttl_hours"`
+	Users           []WebUser  `yaml:"users"`  // UI session login and HTTP basic auth
+	Tokens          []APIToken `yaml:"tokens"` // bearer tokens for the API
+}
+
+type WebUser struct {
+	Username     string `yaml:"username"`
+	PasswordHash string `yaml:"password_hash"` // bcrypt
+	Role         string `yaml:"role"`
+}
+
+type APIToken struct {
+	Name        string `yaml:"name"`
+	TokenSHA256 string `yaml:"token_sha256"` // hex SHA-256 of the token
+	Role        string `yaml:"role"`
 }
 
 type LoggingConfig struct {
@@ -111,6 +335,30 @@ func setDefaults(cfg *Config) {
 	if cfg.DeepSeek.MaxNewsTitleChars == 0 {
 		cfg.DeepSeek.MaxNewsTitleChars = 120
 	}
+	if cfg.DeepSeek.Pricing == nil {
+		cfg.DeepSeek.Pricing = map[string]ModelPrice{
+			"deepseek-reasoner": {InputPerMTok: 0.55, CachedInputPerMTok: 0.14, OutputPerMTok: 2.19},
+			"deepseek-chat":     {InputPerMTok: 0.27, CachedInputPerMTok: 0.07, OutputPerMTok: 1.10},
+		}
+	}
+	if cfg.DeepSeek.UsdRubRate == 0 {
+		cfg.DeepSeek.UsdRubRate = 90
+	}
+	if cfg.DeepSeek.Tools.MaxCalls == 0 {
+		cfg.DeepSeek.Tools.MaxCalls = 6
+	}
+	if cfg.DeepSeek.Tools.CallTimeoutSeconds == 0 {
+		cfg.DeepSeek.Tools.CallTimeoutSeconds = 10
+	}
+	if cfg.DeepSeek.Tools.TotalTimeoutSeconds == 0 {
+		cfg.DeepSeek.Tools.TotalTimeoutSeconds = 45
+	}
+	if cfg.DeepSeek.Tools.MaxResultChars == 0 {
+		cfg.DeepSeek.Tools.MaxResultChars = 4000
+	}
+	if len(cfg.DeepSeek.Prompts.Variants) == 0 {
+		cfg.DeepSeek.Prompts.Variants = []PromptVariant{{Version: "v1", Weight: 100}}
+	}
 	if cfg.Trading.Interval == "" {
 		cfg.Trading.Interval = "15m"
 	}
@@ -159,9 +407,92 @@ func setDefaults(cfg *Config) {
 	if cfg.Trading.LimitOrderSlippage == 0 {
 		cfg.Trading.LimitOrderSlippage = 0.1
 	}
+	if cfg.Trading.Calibration.MinTrades == 0 {
+		cfg.Trading.Calibration.MinTrades = 30
+	}
+	if cfg.Trading.Calibration.LookbackDays == 0 {
+		cfg.Trading.Calibration.LookbackDays = 90
+	}
+	if cfg.Trading.Calibration.BucketSize == 0 {
+		cfg.Trading.Calibration.BucketSize = 10
+	}
+	if cfg.Notify.QueueSize == 0 {
+		cfg.Notify.QueueSize = 100
+	}
+	if cfg.Notify.MaxAttempts == 0 {
+		cfg.Notify.MaxAttempts = 5
+	}
+	hasTelegram := false
+	for i := range cfg.Notify.Channels {
+		ch := &cfg.Notify.Channels[i]
+		if ch.Name == "" {
+			ch.Name = ch.Type
+		}
+		if ch.MinSeverity == "" {
+			ch.MinSeverity = "info"
+		}
+		if ch.Type == "email" && ch.SMTP.Port == 0 {
+			ch.SMTP.Port = 587
+		}
+		hasTelegram = hasTelegram || ch.Type == "telegram"
+	}
+	if cfg.Telegram.Enabled && !hasTelegram {
+		cfg.Notify.Channels = append(cfg.Notify.Channels, NotifyChannel{Name: "telegram", Type: "telegram", MinSeverity: "info"})
+	}
+	if cfg.News.Presets == nil {
+		cfg.News.Presets = []string{"moex", "world"}
+	}
+	if cfg.News.NamesRefreshHours == 0 {
+		cfg.News.NamesRefreshHours = 24
+	}
+	if cfg.News.NamesCache == "" {
+		cfg.News.NamesCache = "data/ticker_names.json"
+	}
+	if cfg.News.DuplicateSimilarity == 0 {
+		cfg.News.DuplicateSimilarity = 0.6
+	}
+	if cfg.News.KeepDays == 0 {
+		cfg.News.KeepDays = 7
+	}
+	if cfg.CorporateEvents.Sources == nil {
+		cfg.CorporateEvents.Sources = []string{"tinvest", "iss"}
+	}
+	if cfg.CorporateEvents.LookaheadDays == 0 {
+		cfg.CorporateEvents.LookaheadDays = 30
+	}
+	if cfg.CorporateEvents.RefreshHours == 0 {
+		cfg.CorporateEvents.RefreshHours = 12
+	}
+	if cfg.Universe.Size == 0 {
+		cfg.Universe.Size = 50
+	}
+	if cfg.Universe.LookbackDays == 0 {
+		cfg.Universe.LookbackDays = 20
+	}
+	for _, list := range [][]string{cfg.Universe.Indexes, cfg.Universe.Include, cfg.Universe.Exclude} {
+		for i := range list {
+			list[i] = strings.ToUpper(strings.TrimSpace(list[i]))
+		}
+	}
+	if cfg.Market.Instruments == nil {
+		cfg.Market.Instruments = append([]MarketInstrument(nil), DefaultMarketInstruments...)
+	}
+	if cfg.Market.RefreshMinutes == 0 {
+		cfg.Market.RefreshMinutes = 60
+	}
+	if cfg.MOEX.ISSURL == "" {
+		cfg.MOEX.ISSURL = DefaultISSURL
+	}
+	cfg.MOEX.ISSURL = strings.TrimRight(cfg.MOEX.ISSURL, "/")
 	if cfg.Web.Port == 0 {
 		cfg.Web.Port = 8080
 	}
+	if cfg.Web.LiveRefreshSeconds == 0 {
+		cfg.Web.LiveRefreshSeconds = 30
+	}
+	if cfg.Web.Auth.SessionTTLHours == 0 {
+		cfg.Web.Auth.SessionTTLHours = 12
+	}
 	if cfg.Logging.Level == "" {
 		cfg.Logging.Level = "info"
 	}
@@ -174,9 +505,21 @@ func (c *Config) Validate() error {
 	if c.DeepSeek.APIKey == "" {
 		return fmt.Errorf("deepseek.api_key is required")
 	}
+	if c.DeepSeek.DailyBudgetRub < 0 {
+		return fmt.Errorf("deepseek.daily_budget_rub must be >= 0")
+	}
+	if err := c.DeepSeek.Prompts.validate(); err != nil {
+		return err
+	}
 	if _, err := time.ParseDuration(c.Trading.Interval); err != nil {
 		return fmt.Errorf("invalid trading.interval %q: %w", c.Trading.Interval, err)
 	}
+	if bs := c.Trading.Calibration.BucketSize; bs < 1 || bs > 50 {
+		return fmt.Errorf("trading.calibration.bucket_size must be between 1 and 50")
+	}
+	if err := c.Web.validate(); err != nil {
+		return err
+	}
 	if c.Telegram.Enabled {
 		if c.Telegram.BotToken == "" {
 			return fmt.Errorf("telegram.bot_token is required when telegram is enabled")
@@ -185,9 +528,238 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("telegram.chat_id is required when telegram is enabled")
 		}
 	}
+	if err := c.validateNotify(); err != nil {
+		return err
+	}
+	if c.News.NamesRefreshHours < 1 {
+		return fmt.Errorf("news.names_refresh_hours must be positive")
+	}
+	if c.News.DuplicateSimilarity <= 0 || c.News.DuplicateSimilarity > 1 {
+		return fmt.Errorf("news.duplicate_similarity must be in (0, 1]")
+	}
+	if c.News.KeepDays < 2 {
+		return fmt.Errorf("news.keep_days must be at least 2")
+	}
+	if err := c.News.validateSources(); err != nil {
+		return err
+	}
+	if c.Trading.BlockBuyNewsSentiment < -1 || c.Trading.BlockBuyNewsSentiment > 0 {
+		return fmt.Errorf("trading.block_buy_news_sentiment must be between -1 and 0")
+	}
+	if c.Trading.NoBuyBeforeDividendDays < 0 || c.Trading.NoBuyBeforeReportDays < 0 || c.Trading.ExitBeforeReportDays < 0 {
+		return fmt.Errorf("trading.no_buy_before_dividend_days, no_buy_before_report_days and exit_before_report_days must not be negative")
+	}
+	for _, src := range c.CorporateEvents.Sources {
+		if src != "tinvest" && src != "iss" {
+			return fmt.Errorf("corporate_events.sources: unknown source %q (want tinvest or iss)", src)
+		}
+	}
+	if c.CorporateEvents.LookaheadDays < 1 {
+		return fmt.Errorf("corporate_events.lookahead_days must be positive")
+	}
+	if c.CorporateEvents.RefreshHours < 1 {
+		return fmt.Errorf("corporate_events.refresh_hours must be positive")
+	}
+	if c.Trading.RiskOffSizePct < 0 || c.Trading.RiskOffSizePct > 100 {
+		return fmt.Errorf("trading.risk_off_size_pct must be between 0 and 100")
+	}
+	if err := c.Universe.validate(); err != nil {
+		return err
+	}
+	if c.Market.RefreshMinutes < 1 {
+		return fmt.Errorf("market.refresh_minutes must be positive")
+	}
+	if u, err := url.Parse(c.MOEX.ISSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("moex.iss_url must be an http(s) url")
+	}
+	for i, inst := range c.Market.Instruments {
+		if inst.Name == "" || inst.Engine == "" || inst.Market == "" || (inst.Security == "") == (inst.Asset == "") {
+			return fmt.Errorf("market.instruments[%d]: name, engine, market and one of security or asset are required", i)
+		}
+		if !contains(MarketRoles, inst.Role) {
+			return fmt.Errorf("market.instruments[%d]: role must be one of %v", i, MarketRoles)
+		}
+	}
 	return nil
 }
 
+func (u UniverseConfig) validate() error {
+	if u.Size < 1 {
+		return fmt.Errorf("universe.size must be positive")
+	}
+	if u.LookbackDays < 1 || u.LookbackDays > 60 {
+		return fmt.Errorf("universe.lookback_days must be between 1 and 60")
+	}
+	if u.MinAvgTurnoverRub < 0 || u.MinPrice < 0 || u.MinLotValueRub < 0 || u.MaxAvgSpreadPct < 0 {
+		return fmt.Errorf("universe: min_avg_turnover_rub, min_price, min_lot_value_rub and max_avg_spread_pct must not be negative")
+	}
+	for _, t := range u.Include {
+		if contains(u.Exclude, t) {
+			return fmt.Errorf("universe: %s is in both include and exclude", t)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateNotify() error {
+	n := c.Notify
+	if n.QueueSize < 1 || n.MaxAttempts < 1 {
+		return fmt.Errorf("notify: queue_size and max_attempts must be positive")
+	}
+	seen := make(map[string]bool)
+	for _, ch := range n.Channels {
+		if seen[ch.Name] {
+			return fmt.Errorf("notify.channels: duplicate name %q", ch.Name)
+		}
+		seen[ch.Name] = true
+		if !contains(NotifySeverities, ch.MinSeverity) {
+			return fmt.Errorf("notify.channels: %q min_severity must be one of %v", ch.Name, NotifySeverities)
+		}
+		for _, e := range ch.Events {
+			if !contains(NotifyEvents, e) {
+				return fmt.Errorf("notify.channels: %q unknown event %q, expected %v", ch.Name, e, NotifyEvents)
+			}
+		}
+		switch ch.Type {
+		case "telegram":
+			if !c.Telegram.Enabled {
+				return fmt.Errorf("notify.channels: %q needs telegram.enabled with bot_token and chat_id", ch.Name)
+			}
+		case "webhook":
+			if ch.URL == "" {
+				return fmt.Errorf("notify.channels: %q url is required", ch.Name)
+			}
+		case "email":
+			if ch.SMTP.Host == "" || ch.SMTP.From == "" || len(ch.SMTP.To) == 0 {
+				return fmt.Errorf("notify.channels: %q smtp host, from and to are required", ch.Name)
+			}
+		case "file":
+			if ch.Path == "" {
+				return fmt.Errorf("notify.channels: %q path is required", ch.Name)
+			}
+		default:
+			return fmt.Errorf("notify.channels: %q type must be telegram, webhook, email or file", ch.Name)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (p PromptsConfig) validate() error {
+	seen := make(map[string]bool)
+	total := 0
+	for _, v := range p.Variants {
+		if v.Version == "" {
+			return fmt.Errorf("deepseek.prompts.variants: version is required")
+		}
+		if seen[v.Version] {
+			return fmt.Errorf("deepseek.prompts.variants: duplicate version %q", v.Version)
+		}
+		seen[v.Version] = true
+		if v.Weight < 0 {
+			return fmt.Errorf("deepseek.prompts.variants: weight of %q must be >= 0", v.Version)
+		}
+		total += v.Weight
+	}
+	if total == 0 {
+		return fmt.Errorf("deepseek.prompts.variants: at least one variant needs a positive weight")
+	}
+	return nil
+}
+
+func (w WebConfig) validate() error {
+	if w.LiveRefreshSeconds < 5 {
+		return fmt.Errorf("web.live_refresh_seconds must be at least 5")
+	}
+	if (w.TLS.CertFile == "") != (w.TLS.KeyFile == "") {
+		return fmt.Errorf("web.tls: cert_file and key_file must be set together")
+	}
+	if !w.Auth.Enabled {
+		return nil
+	}
+	if len(w.Auth.Users) == 0 && len(w.Auth.Tokens) == 0 {
+		return fmt.Errorf("web.auth: at least one user or token is required when auth is enabled")
+	}
+	validRole := func(role string) bool { return role == RoleViewer || role == RoleOperator }
+	seen := make(map[string]bool)
+	for _, u := range w.Auth.Users {
+		if u.Username == "" {
+			return fmt.Errorf("web.auth.users: username is required")
+		}
+		if seen[u.Username] {
+			return fmt.Errorf("web.auth.users: duplicate username %q", u.Username)
+		}
+		seen[u.Username] = true
+		if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+			return fmt.Errorf("web.auth.users: %q password_hash is not a bcrypt hash", u.Username)
+		}
+		if !validRole(u.Role) {
+			return fmt.Errorf("web.auth.users: %q role must be %s or %s", u.Username, RoleViewer, RoleOperator)
+		}
+	}
+	for _, t := range w.Auth.Tokens {
+		if t.Name == "" {
+			return fmt.Errorf("web.auth.tokens: name is required")
+		}
+		if len(t.TokenSHA256) != 64 {
+			return fmt.Errorf("web.auth.tokens: %q token_sha256 must be a hex SHA-256", t.Name)
+		}
+		if !validRole(t.Role) {
+			return fmt.Errorf("web.auth.tokens: %q role must be %s or %s", t.Name, RoleViewer, RoleOperator)
+		}
+	}
+	return nil
+}
+
+// Redacted returns a copy of the config with secrets masked, safe to expose over the API.
+func (c *Config) Redacted() Config {
+	out := *c
+	out.Tinkoff.Token = redact(out.Tinkoff.Token)
+	out.DeepSeek.APIKey = redact(out.DeepSeek.APIKey)
+	out.Telegram.BotToken = redact(out.Telegram.BotToken)
+
+	out.Notify.Channels = make([]NotifyChannel, len(c.Notify.Channels))
+	for i, ch := range c.Notify.Channels {
+		ch.SMTP.Password = redact(ch.SMTP.Password)
+		ch.URL = redact(ch.URL) // webhook URLs often embed a secret
+		if len(ch.Headers) > 0 {
+			headers := make(map[string]string, len(ch.Headers))
+			for k, v := range ch.Headers {
+				headers[k] = redact(v)
+			}
+			ch.Headers = headers
+		}
+		out.Notify.Channels[i] = ch
+	}
+
+	out.Web.Auth.Users = make([]WebUser, len(c.Web.Auth.Users))
+	for i, u := range c.Web.Auth.Users {
+		u.PasswordHash = redact(u.PasswordHash)
+		out.Web.Auth.Users[i] = u
+	}
+	out.Web.Auth.Tokens = make([]APIToken, len(c.Web.Auth.Tokens))
+	for i, t := range c.Web.Auth.Tokens {
+		t.TokenSHA256 = redact(t.TokenSHA256)
+		out.Web.Auth.Tokens[i] = t
+	}
+	return out
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "***"
+}
+
 func (c *Config) IsSandbox() bool {
 	return c.Tinkoff.Sandbox
 }
@@ -205,6 +777,17 @@ func (c *Config) TradingInterval() time.Duration {
 	return d
 }
 
+// ModelPrice returns the configured token price for a model.
+func (c *Config) ModelPrice(model string) (ModelPrice, bool) {
+	p, ok := c.DeepSeek.Pricing[model]
+	return p, ok
+}
+
+// CalibrationSince is the start of the confidence calibration window.
+func (c *Config) CalibrationSince() time.Time {
+	return time.Now().AddDate(0, 0, -c.Trading.Calibration.LookbackDays)
+}
+
 func (c *Config) DeepSeekTimeout() time.Duration {
 	return time.Duration(c.DeepSeek.TimeoutSeconds) * time.Second
 }
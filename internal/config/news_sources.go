@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// News source types and scopes.
+var (
+	NewsSourceTypes  = []string{"rss", "atom", "iss", "json"}
+	NewsSourceScopes = []string{"tickers", "market", "all"}
+)
+
+// NewsPresets are the built-in source sets for news.presets. Feed addresses
+// change from time to time: a broken one shows up in the dashboard feed status
+// and can be fixed with a news.sources entry of the same name.
+var NewsPresets = map[string][]NewsSource{
+	// MOEX site news, the original ticker news source.
+	"moex": {
+		{Name: "MOEX", Type: "iss", URL: DefaultISSURL + "/sitenews.json?lang=ru", Language: "ru", Scope: "tickers"},
+	},
+	// World headlines for the market background.
+	"world": {
+		{Name: "BBC World", Type: "rss", URL: "https://feeds.bbci.co.uk/news/world/rss.xml", Language: "en", Scope: "market"},
+		{Name: "NYT World", Type: "rss", URL: "https://rss.nytimes.com/services/xml/rss/nyt/World.xml", Language: "en", Scope: "market"},
+		{Name: "WSJ World", Type: "rss", URL: "https://feeds.a.dj.com/rss/RSSWorldNews.xml", Language: "en", Scope: "market"},
+		{Name: "Guardian World", Type: "rss", URL: "https://www.theguardian.com/world/rss", Language: "en", Scope: "market"},
+		{Name: "Al Jazeera", Type: "rss", URL: "https://www.aljazeera.com/xml/rss/all.xml", Language: "en", Scope: "market"},
+	},
+	// Russian financial outlets: matched to tickers and used as background.
+	"ru_finance": {
+		{Name: "Интерфакс", Type: "rss", URL: "https://www.interfax.ru/rss.asp", Language: "ru", Scope: "all"},
+		{Name: "РБК", Type: "rss", URL: "https://rssexport.rbc.ru/rbcnews/news/30/full.rss", Language: "ru", Scope: "all"},
+		{Name: "Коммерсантъ", Type: "rss", URL: "https://www.kommersant.ru/RSS/section-economics.xml", Language: "ru", Scope: "all"},
+		{Name: "Ведомости", Type: "rss", URL: "https://www.vedomosti.ru/rss/news", Language: "ru", Scope: "all"},
+		{Name: "ТАСС", Type: "rss", URL: "https://tass.ru/rss/v2.xml", Language: "ru", Scope: "all"},
+		{Name: "ПРАЙМ", Type: "rss", URL: "https://1prime.ru/export/rss2/index.xml", Language: "ru", Scope: "all"},
+	},
+	// Issuer disclosures and regulator releases, matched to tickers only.
+	"disclosure": {
+		{Name: "e-disclosure", Type: "rss", URL: "https://www.e-disclosure.ru/rss/events.xml", Language: "ru", Scope: "tickers", Weight: 1.5},
+		{Name: "Банк России", Type: "rss", URL: "https://www.cbr.ru/rss/RssPress", Language: "ru", Scope: "market"},
+	},
+}
+
+// IsEnabled reports whether the source is fetched; sources are on by default.
+func (s NewsSource) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// Timeout returns the per-fetch timeout in seconds.
+func (s NewsSource) Timeout() int {
+	if s.TimeoutSeconds <= 0 {
+		return 10
+	}
+	return s.TimeoutSeconds
+}
+
+// ResolvedSources returns the preset sources followed by the custom ones.
+// A custom source named like a preset source overrides its non-empty fields.
+func (n NewsConfig) ResolvedSources() []NewsSource {
+	var sources []NewsSource
+	index := make(map[string]int)
+	for _, preset := range n.Presets {
+		for _, src := range NewsPresets[preset] {
+			if _, ok := index[src.Name]; ok {
+				continue
+			}
+			index[src.Name] = len(sources)
+			sources = append(sources, src)
+		}
+	}
+	for _, custom := range n.Sources {
+		i, ok := index[custom.Name]
+		if !ok {
+			index[custom.Name] = len(sources)
+			sources = append(sources, custom)
+			continue
+		}
+		base := &sources[i]
+		if custom.Type != "" {
+			base.Type = custom.Type
+		}
+		if custom.URL != "" {
+			base.URL = custom.URL
+		}
+		if custom.Language != "" {
+			base.Language = custom.Language
+		}
+		if custom.Scope != "" {
+			base.Scope = custom.Scope
+		}
+		if custom.Weight != 0 {
+			base.Weight = custom.Weight
+		}
+		if custom.TimeoutSeconds != 0 {
+			base.TimeoutSeconds = custom.TimeoutSeconds
+		}
+		if custom.Enabled != nil {
+			base.Enabled = custom.Enabled
+		}
+	}
+
+	for i := range sources {
+		src := &sources[i]
+		if src.Language == "" {
+			src.Language = "ru"
+		}
+		if src.Scope == "" {
+			src.Scope = "all"
+		}
+		if src.Weight == 0 {
+			src.Weight = 1
+		}
+	}
+	return sources
+}
+
+func (n NewsConfig) validateSources() error {
+	for _, preset := range n.Presets {
+		if _, ok := NewsPresets[preset]; !ok {
+			return fmt.Errorf("news.presets: unknown preset %q", preset)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, src := range n.Sources {
+		if src.Name == "" {
+			return fmt.Errorf("news.sources: name is required")
+		}
+		if seen[src.Name] {
+			return fmt.Errorf("news.sources: duplicate name %q", src.Name)
+		}
+		seen[src.Name] = true
+	}
+	for _, src := range n.ResolvedSources() {
+		if !contains(NewsSourceTypes, src.Type) {
+			return fmt.Errorf("news.sources: %q type must be one of %v", src.Name, NewsSourceTypes)
+		}
+		if u, err := url.Parse(src.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("news.sources: %q needs an http(s) url", src.Name)
+		}
+		if !contains(NewsSourceScopes, src.Scope) {
+			return fmt.Errorf("news.sources: %q scope must be one of %v", src.Name, NewsSourceScopes)
+		}
+		if src.Weight < 0 {
+			return fmt.Errorf("news.sources: %q weight must be positive", src.Name)
+		}
+	}
+	return nil
+}
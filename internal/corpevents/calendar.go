@@ -0,0 +1,235 @@
+package corpevents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/broker"
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+)
+
+// fetchConcurrency limits parallel requests when many tickers are refreshed.
+const fetchConcurrency = 4
+
+// Source loads the events of a ticker dated within [from, to]. It may return
+// the events it did load together with an error.
+type Source interface {
+	Name() string
+	Events(ctx context.Context, ticker string, from, to time.Time) ([]Event, error)
+}
+
+// Calendar caches the events of every requested ticker for refresh_hours.
+type Calendar struct {
+	sources   []Source
+	lookahead int
+	refresh   time.Duration
+	loc       *time.Location
+	logger    *logger.Logger
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	events  []Event
+	fetched time.Time
+}
+
+func NewCalendar(bc *broker.BrokerClient, mc *moex.Client, cfg *config.Config, log *logger.Logger) *Calendar {
+	var sources []Source
+	for _, name := range cfg.CorporateEvents.Sources {
+		switch name {
+		case "tinvest":
+			sources = append(sources, tinvestSource{broker: bc})
+		case "iss":
+			sources = append(sources, issSource{client: mc})
+		}
+	}
+	return newCalendar(sources, cfg.CorporateEvents, cfg.MOEXLocation(), log)
+}
+
+func newCalendar(sources []Source, cfg config.CorporateEventsConfig, loc *time.Location, log *logger.Logger) *Calendar {
+	return &Calendar{
+		sources:   sources,
+		lookahead: cfg.LookaheadDays,
+		refresh:   time.Duration(cfg.RefreshHours) * time.Hour,
+		loc:       loc,
+		logger:    log,
+		cache:     make(map[string]cacheEntry),
+	}
+}
+
+// Upcoming returns the events of the tickers from today to lookahead_days
+// ahead, nearest first, loading tickers whose cache is missing or expired.
+// A ticker whose sources all fail keeps its previous events.
+func (c *Calendar) Upcoming(ctx context.Context, tickers []string, now time.Time) map[string][]Event {
+	if len(c.sources) == 0 {
+		return nil
+	}
+	today := civilDate(now.In(c.loc))
+	// a week back covers last buy dates of ex-dates that are still ahead
+	from, to := today.AddDate(0, 0, -7), today.AddDate(0, 0, c.lookahead+7)
+
+	var stale []string
+	c.mu.Lock()
+	for _, t := range tickers {
+		if e, ok := c.cache[t]; !ok || now.Sub(e.fetched) >= c.refresh {
+			stale = append(stale, t)
+		}
+	}
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+	for _, ticker := range stale {
+		wg.Add(1)
+		go func(ticker string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if events, ok := c.load(ctx, ticker, from, to); ok {
+				c.mu.Lock()
+				c.cache[ticker] = cacheEntry{events: events, fetched: now}
+				c.mu.Unlock()
+			}
+		}(ticker)
+	}
+	wg.Wait()
+
+	result := make(map[string][]Event)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range tickers {
+		for _, e := range c.cache[t].events {
+			if d := e.DaysUntil(now, c.loc); d >= 0 && d <= c.lookahead {
+				result[t] = append(result[t], e)
+			}
+		}
+	}
+	return result
+}
+
+// load queries every source; ok is false when all of them failed.
+func (c *Calendar) load(ctx context.Context, ticker string, from, to time.Time) ([]Event, bool) {
+	var all []Event
+	failed := 0
+	for _, src := range c.sources {
+		events, err := src.Events(ctx, ticker, from, to)
+		if err != nil {
+			c.logger.Warn("load corporate events", "ticker", ticker, "source", src.Name(), "error", err)
+			if len(events) == 0 {
+				failed++
+			}
+		}
+		all = append(all, events...)
+	}
+	if failed == len(c.sources) {
+		return nil, false
+	}
+	return merge(all), true
+}
+
+type tinvestSource struct {
+	broker *broker.BrokerClient
+}
+
+func (s tinvestSource) Name() string { return "T-Invest" }
+
+// Events bounds the broker calls, which take no context, by ctx; a call that
+// outlives it finishes in the background.
+func (s tinvestSource) Events(ctx context.Context, ticker string, from, to time.Time) ([]Event, error) {
+	type outcome struct {
+		events []Event
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		events, err := s.events(ticker, from, to)
+		done <- outcome{events, err}
+	}()
+	select {
+	case o := <-done:
+		return o.events, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s tinvestSource) events(ticker string, from, to time.Time) ([]Event, error) {
+	dividends, err := s.broker.Dividends(ticker, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, d := range dividends {
+		events = append(events, Event{
+			Ticker:   ticker,
+			Kind:     Dividend,
+			Date:     exDividendDate(d.LastBuyDate, d.RecordDate),
+			Amount:   d.Amount,
+			Currency: d.Currency,
+			YieldPct: d.YieldPct,
+			Source:   s.Name(),
+		})
+	}
+
+	reports, err := s.broker.ReportDates(ticker, from, to)
+	if err != nil {
+		return events, err
+	}
+	for _, r := range reports {
+		events = append(events, Event{
+			Ticker: ticker,
+			Kind:   Report,
+			Date:   r.Date,
+			Period: formatPeriod(r),
+			Source: s.Name(),
+		})
+	}
+	return events, nil
+}
+
+func formatPeriod(r broker.ReportDate) string {
+	if r.PeriodYear == 0 {
+		return ""
+	}
+	switch r.PeriodType {
+	case "quarter":
+		return fmt.Sprintf("%d кв. %d", r.PeriodNum, r.PeriodYear)
+	case "half":
+		return fmt.Sprintf("%d п/г %d", r.PeriodNum, r.PeriodYear)
+	}
+	return fmt.Sprintf("%d год", r.PeriodYear)
+}
+
+type issSource struct {
+	client *moex.Client
+}
+
+func (s issSource) Name() string { return "MOEX" }
+
+func (s issSource) Events(ctx context.Context, ticker string, from, to time.Time) ([]Event, error) {
+	dividends, err := s.client.FetchDividends(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, d := range dividends {
+		if d.RecordDate.Before(from) || d.RecordDate.After(to) {
+			continue
+		}
+		events = append(events, Event{
+			Ticker:   ticker,
+			Kind:     Dividend,
+			Date:     exDividendDate(time.Time{}, d.RecordDate),
+			Amount:   d.Value,
+			Currency: d.Currency,
+			Source:   s.Name(),
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,136 @@
+// Package corpevents keeps the upcoming corporate events of tickers: dividend
+// cut-offs from T-Invest and MOEX ISS and financial report dates from T-Invest.
+package corpevents
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	Dividend Kind = "dividend"
+	Report   Kind = "report"
+)
+
+// Event is a corporate event of a ticker. Dates are calendar dates stored as
+// midnight UTC.
+type Event struct {
+	Ticker   string
+	Kind     Kind
+	Date     time.Time // ex-dividend date (first day without the dividend) or report date
+	Amount   float64   // dividend per share
+	Currency string
+	YieldPct float64 // dividend yield, 0 if unknown
+	Period   string  // reported period, e.g. "2 кв. 2024"
+	Source   string  // T-Invest or MOEX
+}
+
+// DaysUntil returns the number of calendar days from today (MSK) to the event.
+func (e Event) DaysUntil(now time.Time, loc *time.Location) int {
+	return int(math.Round(e.Date.Sub(civilDate(now.In(loc))).Hours() / 24))
+}
+
+// Describe renders the event for the prompt, e.g.
+// "экс-дивидендная дата 11.07 (через 3 дн.): 33.30 RUB, доходность 10.6%".
+func (e Event) Describe(now time.Time, loc *time.Location) string {
+	when := fmt.Sprintf("%s (%s)", e.Date.Format("02.01"), formatDays(e.DaysUntil(now, loc)))
+	switch e.Kind {
+	case Dividend:
+		s := "экс-дивидендная дата " + when
+		if e.Amount > 0 {
+			s += fmt.Sprintf(": %.2f %s", e.Amount, strings.ToUpper(e.Currency))
+			if e.YieldPct > 0 {
+				s += fmt.Sprintf(", доходность %.1f%%", e.YieldPct)
+			}
+		}
+		return s
+	case Report:
+		if e.Period != "" {
+			return fmt.Sprintf("отчётность за %s %s", e.Period, when)
+		}
+		return "отчётность " + when
+	}
+	return string(e.Kind) + " " + when
+}
+
+func formatDays(days int) string {
+	switch days {
+	case 0:
+		return "сегодня"
+	case 1:
+		return "завтра"
+	}
+	return fmt.Sprintf("через %d дн.", days)
+}
+
+// Next returns the nearest event of the given kind within days from today,
+// and whether there is one. Events of today count.
+func Next(events []Event, kind Kind, days int, now time.Time, loc *time.Location) (Event, bool) {
+	for _, e := range events {
+		if e.Kind != kind {
+			continue
+		}
+		if d := e.DaysUntil(now, loc); d >= 0 && d <= days {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
+// exDividendDate returns the first trading day without the right to the
+// dividend. With T+1 settlement it is the day after the last buy date, or
+// the record date itself when the last buy date is unknown. Weekends are
+// skipped; exchange holidays are not known and ignored.
+func exDividendDate(lastBuy, record time.Time) time.Time {
+	if !lastBuy.IsZero() {
+		d := lastBuy.AddDate(0, 0, 1)
+		for isWeekend(d) {
+			d = d.AddDate(0, 0, 1)
+		}
+		return d
+	}
+	d := record
+	for isWeekend(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+func isWeekend(d time.Time) bool {
+	return d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
+}
+
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// merge drops dividends reported by a later source within a few days of one
+// already known, keeping the first source's copy, and sorts events by date.
+func merge(events []Event) []Event {
+	var out []Event
+	for _, e := range events {
+		dup := false
+		for _, kept := range out {
+			if kept.Kind == e.Kind && kept.Source != e.Source && absDays(kept.Date.Sub(e.Date)) <= 3 {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Date.Before(out[j].Date)
+	})
+	return out
+}
+
+func absDays(d time.Duration) float64 {
+	return math.Abs(d.Hours() / 24)
+}
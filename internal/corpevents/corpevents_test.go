@@ -0,0 +1,107 @@
+package corpevents
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+)
+
+var msk = time.FixedZone("MSK", 3*60*60)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestExDividendDate(t *testing.T) {
+	tests := []struct {
+		name            string
+		lastBuy, record time.Time
+		want            time.Time
+	}{
+		{"day after last buy", date(2024, 7, 10), date(2024, 7, 11), date(2024, 7, 11)},
+		{"last buy on Friday", date(2024, 7, 12), date(2024, 7, 15), date(2024, 7, 15)},
+		{"record date only", time.Time{}, date(2024, 7, 11), date(2024, 7, 11)},
+		{"record date on Sunday", time.Time{}, date(2024, 7, 14), date(2024, 7, 12)},
+	}
+	for _, tt := range tests {
+		if got := exDividendDate(tt.lastBuy, tt.record); !got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.name, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestEvent_DescribeAndNext(t *testing.T) {
+	now := time.Date(2024, 7, 8, 22, 30, 0, 0, time.UTC) // 9 July 01:30 MSK
+	div := Event{Ticker: "SBER", Kind: Dividend, Date: date(2024, 7, 11), Amount: 33.3, Currency: "rub", YieldPct: 10.6}
+	report := Event{Ticker: "SBER", Kind: Report, Date: date(2024, 7, 9), Period: "2 кв. 2024"}
+
+	if got := div.Describe(now, msk); got != "экс-дивидендная дата 11.07 (через 2 дн.): 33.30 RUB, доходность 10.6%" {
+		t.Errorf("dividend: %q", got)
+	}
+	if got := report.Describe(now, msk); got != "отчётность за 2 кв. 2024 09.07 (сегодня)" {
+		t.Errorf("report: %q", got)
+	}
+
+	events := []Event{report, div}
+	if _, ok := Next(events, Dividend, 1, now, msk); ok {
+		t.Error("dividend in 2 days must not be within 1 day")
+	}
+	if e, ok := Next(events, Dividend, 2, now, msk); !ok || e.Ticker != "SBER" {
+		t.Error("dividend in 2 days must be within 2 days")
+	}
+	if _, ok := Next(events, Report, 0, now, msk); !ok {
+		t.Error("report of today must count")
+	}
+}
+
+type fakeSource struct {
+	name   string
+	events []Event
+	err    error
+	calls  int
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) Events(ctx context.Context, ticker string, from, to time.Time) ([]Event, error) {
+	s.calls++
+	return s.events, s.err
+}
+
+func TestCalendar_MergesCachesAndKeepsEventsOnFailure(t *testing.T) {
+	tinvest := &fakeSource{name: "T-Invest", events: []Event{
+		{Ticker: "SBER", Kind: Dividend, Date: date(2024, 7, 11), Amount: 33.3, YieldPct: 10.6, Source: "T-Invest"},
+		{Ticker: "SBER", Kind: Report, Date: date(2024, 7, 30), Source: "T-Invest"},
+		{Ticker: "SBER", Kind: Report, Date: date(2024, 12, 1), Source: "T-Invest"},
+	}}
+	iss := &fakeSource{name: "MOEX", events: []Event{
+		{Ticker: "SBER", Kind: Dividend, Date: date(2024, 7, 11), Amount: 33.3, Source: "MOEX"},
+		{Ticker: "SBER", Kind: Dividend, Date: date(2024, 7, 1), Amount: 10, Source: "MOEX"},
+	}}
+	cal := newCalendar([]Source{tinvest, iss}, config.CorporateEventsConfig{LookaheadDays: 30, RefreshHours: 12}, msk, logger.New("error"))
+
+	now := time.Date(2024, 7, 9, 9, 0, 0, 0, msk)
+	got := cal.Upcoming(context.Background(), []string{"SBER"}, now)["SBER"]
+	if len(got) != 2 {
+		t.Fatalf("expected the dividend and the July report, got %+v", got)
+	}
+	if got[0].Kind != Dividend || got[0].Source != "T-Invest" || got[1].Kind != Report {
+		t.Fatalf("expected the T-Invest dividend first, got %+v", got)
+	}
+
+	cal.Upcoming(context.Background(), []string{"SBER"}, now.Add(time.Hour))
+	if tinvest.calls != 1 {
+		t.Errorf("expected cached events within refresh_hours, got %d calls", tinvest.calls)
+	}
+
+	tinvest.err, tinvest.events = errors.New("unavailable"), nil
+	iss.err, iss.events = errors.New("unavailable"), nil
+	got = cal.Upcoming(context.Background(), []string{"SBER"}, now.Add(13*time.Hour))["SBER"]
+	if tinvest.calls != 2 || len(got) != 2 {
+		t.Errorf("expected a refresh attempt keeping the old events, got %d calls, %+v", tinvest.calls, got)
+	}
+}
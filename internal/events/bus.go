@@ -0,0 +1,175 @@
+// Package events is an in-process publish/subscribe bus for trading activity:
+// analysis cycles, AI decisions, guard rejections, orders and portfolio updates.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	CycleStarted  Type = "cycle_started"
+	CycleFinished Type = "cycle_finished"
+	Decision      Type = "decision"
+	Blocked       Type = "blocked"
+	Order         Type = "order"        // order submitted to the broker
+	Fill          Type = "fill"         // order executed
+	OrderFailed   Type = "order_failed" // broker rejected or failed the order
+	Portfolio     Type = "portfolio"    // raw broker portfolio, *broker.PortfolioInfo
+	Positions     Type = "positions"    // dashboard view of positions and P&L, built by the web server
+)
+
+// Event is a single bus message. IDs increase monotonically per bus.
+type Event struct {
+	ID   uint64    `json:"id"`
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+// Payloads of the activity events.
+
+type CycleFinishedData struct {
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	Decisions  int    `json:"decisions"`
+	Allowed    int    `json:"allowed"`
+	Blocked    int    `json:"blocked"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type DecisionData struct {
+	Ticker     string `json:"ticker"`
+	Action     string `json:"action"`
+	Confidence int    `json:"confidence"`
+	Reasoning  string `json:"reasoning"`
+}
+
+type BlockedData struct {
+	Ticker string `json:"ticker"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+type OrderData struct {
+	Ticker     string  `json:"ticker"`
+	Action     string  `json:"action"`
+	Lots       int64   `json:"lots"`
+	LimitPrice float64 `json:"limit_price,omitempty"` // 0 = market order
+}
+
+type FillData struct {
+	Ticker  string  `json:"ticker"`
+	Action  string  `json:"action"`
+	Lots    int64   `json:"lots"`
+	Price   float64 `json:"price"`
+	OrderID string  `json:"order_id"`
+	PnL     float64 `json:"pnl,omitempty"` // realised P&L, SELL only
+}
+
+type OrderFailedData struct {
+	Ticker string `json:"ticker"`
+	Action string `json:"action"`
+	Error  string `json:"error"`
+}
+
+const (
+	defaultHistory   = 200
+	subscriberBuffer = 64
+)
+
+// Bus fans events out to subscribers and keeps a short history for replay.
+// Publishing never blocks: a subscriber that falls behind loses events.
+// A nil *Bus is valid and discards everything.
+type Bus struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history []Event // ring buffer, oldest first once full
+	start   int
+	subs    map[chan Event]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		history: make([]Event, 0, defaultHistory),
+		subs:    make(map[chan Event]struct{}),
+	}
+}
+
+// Publish stamps and delivers an event to all current subscribers.
+func (b *Bus) Publish(t Type, data any) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	ev := Event{ID: b.nextID, Type: t, Time: time.Now(), Data: data}
+	if len(b.history) < cap(b.history) {
+		b.history = append(b.history, ev)
+	} else {
+		b.history[b.start] = ev
+		b.start = (b.start + 1) % len(b.history)
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default: // slow subscriber, drop
+		}
+	}
+}
+
+// Subscribe returns a channel of new events and a function that unsubscribes
+// and closes the channel.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	if b == nil {
+		close(ch)
+		return ch, func() {}
+	}
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// LastID is the ID of the most recently published event, 0 if none.
+func (b *Bus) LastID() uint64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID
+}
+
+// Since returns retained events with ID greater than afterID, oldest first.
+func (b *Bus) Since(afterID uint64) []Event {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []Event
+	for i := 0; i < len(b.history); i++ {
+		ev := b.history[(b.start+i)%len(b.history)]
+		if ev.ID > afterID {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
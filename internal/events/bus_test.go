@@ -0,0 +1,83 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBus_DeliversToSubscribers(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe()
+	defer cancel()
+
+	bus.Publish(Decision, DecisionData{Ticker: "SBER", Action: "BUY"})
+
+	ev := <-ch
+	if ev.ID != 1 || ev.Type != Decision {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if d, ok := ev.Data.(DecisionData); !ok || d.Ticker != "SBER" {
+		t.Fatalf("unexpected payload %+v", ev.Data)
+	}
+}
+
+func TestBus_DropsForSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe()
+	defer cancel()
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		bus.Publish(Order, nil)
+	}
+	if len(ch) != subscriberBuffer {
+		t.Fatalf("expected buffer of %d, got %d", subscriberBuffer, len(ch))
+	}
+}
+
+func TestBus_CancelClosesChannel(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe()
+	cancel()
+	cancel() // idempotent
+
+	if _, ok := <-ch; ok {
+		t.Fatalf("expected closed channel")
+	}
+	bus.Publish(Fill, nil) // must not panic on closed subscriber
+}
+
+func TestBus_SinceReplaysRingBuffer(t *testing.T) {
+	bus := NewBus()
+	for i := 0; i < defaultHistory+5; i++ {
+		bus.Publish(Order, i)
+	}
+
+	all := bus.Since(0)
+	if len(all) != defaultHistory {
+		t.Fatalf("expected %d retained events, got %d", defaultHistory, len(all))
+	}
+	if all[0].ID != 6 || all[len(all)-1].ID != defaultHistory+5 {
+		t.Fatalf("unexpected range %d..%d", all[0].ID, all[len(all)-1].ID)
+	}
+
+	if bus.LastID() != defaultHistory+5 {
+		t.Fatalf("unexpected last id %d", bus.LastID())
+	}
+
+	tail := bus.Since(defaultHistory + 3)
+	if len(tail) != 2 || tail[0].ID != defaultHistory+4 {
+		t.Fatalf("unexpected tail %+v", tail)
+	}
+}
+
+func TestBus_NilIsNoop(t *testing.T) {
+	var bus *Bus
+	bus.Publish(CycleStarted, nil)
+	if got := bus.Since(0); got != nil {
+		t.Fatalf("expected no history, got %v", got)
+	}
+	ch, cancel := bus.Subscribe()
+	defer cancel()
+	if _, ok := <-ch; ok {
+		t.Fatalf("expected closed channel from nil bus")
+	}
+}
@@ -2,28 +2,35 @@ package executor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/camuig/rus-trader/internal/ai"
 	"github.com/camuig/rus-trader/internal/broker"
+	"github.com/camuig/rus-trader/internal/calibration"
 	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/events"
 	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/metrics"
+	"github.com/camuig/rus-trader/internal/notify"
 	"github.com/camuig/rus-trader/internal/storage"
-	"github.com/camuig/rus-trader/internal/telegram"
 )
 
 type Executor struct {
-	broker   *broker.BrokerClient
-	repo     *storage.Repository
-	notifier *telegram.Notifier
-	config   *config.Config
-	logger   *logger.Logger
+	broker      *broker.BrokerClient
+	repo        *storage.Repository
+	notifier    *notify.Dispatcher
+	events      *events.Bus
+	config      *config.Config
+	logger      *logger.Logger
+	calibration *calibration.Curve // nil = use raw confidence
 }
 
 func NewExecutor(
 	bc *broker.BrokerClient,
 	repo *storage.Repository,
-	notifier *telegram.Notifier,
+	notifier *notify.Dispatcher,
+	bus *events.Bus,
 	cfg *config.Config,
 	log *logger.Logger,
 ) *Executor {
@@ -31,56 +38,116 @@ func NewExecutor(
 		broker:   bc,
 		repo:     repo,
 		notifier: notifier,
+		events:   bus,
 		config:   cfg,
 		logger:   log,
 	}
 }
 
-func (e *Executor) Execute(decisions []ai.AIDecision) {
+// SetCalibration sets the confidence calibration curve applied before gating and sizing.
+// A nil curve disables remapping.
+func (e *Executor) SetCalibration(curve *calibration.Curve) {
+	e.calibration = curve
+}
+
+// Outcome statuses.
+const (
+	OutcomeExecuted = "executed"
+	OutcomeSkipped  = "skipped"
+	OutcomeFailed   = "failed"
+	OutcomeHold     = "hold"
+)
+
+// Outcome records what the executor did with one decision.
+type Outcome struct {
+	Ticker  string  `json:"ticker"`
+	Action  string  `json:"action"`
+	Status  string  `json:"status"`
+	Reason  string  `json:"reason,omitempty"` // why it was skipped or failed
+	Lots    int64   `json:"lots,omitempty"`
+	Price   float64 `json:"price,omitempty"`
+	OrderID string  `json:"order_id,omitempty"`
+	PnL     float64 `json:"pnl,omitempty"` // SELL only
+}
+
+// Execute places orders for the decisions and returns one outcome per decision.
+func (e *Executor) Execute(decisions []ai.AIDecision) []Outcome {
+	outcomes := make([]Outcome, 0, len(decisions))
 	for _, d := range decisions {
+		outcome := Outcome{Ticker: d.Ticker, Action: d.Action}
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
 					e.logger.Error("panic in executor", "ticker", d.Ticker, "panic", fmt.Sprint(r))
+					outcome = failed(d, fmt.Errorf("panic: %v", r))
 				}
 			}()
 
 			switch d.Action {
 			case "BUY":
-				e.executeBuy(d)
+				outcome = e.executeBuy(d)
 			case "SELL":
-				e.executeSell(d)
+				outcome = e.executeSell(d)
 			case "HOLD":
 				e.logger.Info("HOLD decision", "ticker", d.Ticker, "reasoning", d.Reasoning)
+				outcome.Status = OutcomeHold
 			default:
 				e.logger.Info("unknown action", "action", d.Action, "ticker", d.Ticker)
+				outcome = skipped(d, "неизвестное действие")
 			}
 		}()
+		if d.Action == "BUY" || d.Action == "SELL" {
+			metrics.Orders.Inc(d.Action, outcome.Status)
+		}
+		outcomes = append(outcomes, outcome)
 	}
+	return outcomes
+}
+
+func skipped(d ai.AIDecision, reason string) Outcome {
+	return Outcome{Ticker: d.Ticker, Action: d.Action, Status: OutcomeSkipped, Reason: reason}
+}
+
+func failed(d ai.AIDecision, err error) Outcome {
+	return Outcome{Ticker: d.Ticker, Action: d.Action, Status: OutcomeFailed, Reason: err.Error()}
 }
 
-func (e *Executor) executeBuy(d ai.AIDecision) {
-	if d.Confidence < e.config.Trading.MinConfidence {
+func (e *Executor) executeBuy(d ai.AIDecision) Outcome {
+	confidence := d.Confidence
+	calibrated := 0
+	if d.Manual {
+		confidence = 100 // operator orders use the full max_position_rub
+	} else if e.calibration != nil {
+		calibrated = e.calibration.Apply(d.Confidence)
+		confidence = calibrated
+		e.logger.Info("confidence calibrated",
+			"ticker", d.Ticker, "raw", d.Confidence, "calibrated", calibrated)
+	}
+
+	if confidence < e.config.Trading.MinConfidence {
 		e.logger.Info("BUY skipped: low confidence",
-			"ticker", d.Ticker, "confidence", d.Confidence, "min", e.config.Trading.MinConfidence)
-		return
+			"ticker", d.Ticker, "confidence", confidence, "min", e.config.Trading.MinConfidence)
+		return skipped(d, fmt.Sprintf("низкая уверенность (%d < %d)", confidence, e.config.Trading.MinConfidence))
 	}
 
 	// Check if position already exists
 	if existing, _ := e.repo.GetOpenTradeByTicker(d.Ticker); existing != nil {
 		e.logger.Info("BUY skipped: position already open", "ticker", d.Ticker)
-		return
+		return skipped(d, "позиция по тикеру уже открыта")
 	}
 
 	// Check available balance
 	availableRub, err := e.broker.GetAvailableRub()
 	if err != nil {
 		e.logger.Error("get available balance", "error", err)
-		return
+		return failed(d, fmt.Errorf("get available balance: %w", err))
 	}
 
-	// Scale position size by confidence
-	maxPosition := scalePositionByConfidence(e.config.Trading.MaxPositionRub, d.Confidence)
+	// Scale position size by confidence and the guard's size scale
+	maxPosition := scalePositionByConfidence(e.config.Trading.MaxPositionRub, confidence)
+	if d.SizeScale > 0 {
+		maxPosition *= d.SizeScale
+	}
 	if maxPosition > availableRub {
 		maxPosition = availableRub
 	}
@@ -89,7 +156,7 @@ func (e *Executor) executeBuy(d ai.AIDecision) {
 	instrumentUID, err := e.broker.ResolveTickerToUID(d.Ticker)
 	if err != nil {
 		e.logger.Error("resolve ticker", "ticker", d.Ticker, "error", err)
-		return
+		return failed(d, fmt.Errorf("resolve ticker: %w", err))
 	}
 
 	// Get real last price for lots calculation
@@ -103,7 +170,7 @@ func (e *Executor) executeBuy(d ai.AIDecision) {
 	}
 	if lastPrice <= 0 {
 		e.logger.Error("cannot determine price for lots calculation", "ticker", d.Ticker)
-		return
+		return skipped(d, "нет цены для расчёта лотов")
 	}
 
 	// Check spread before buying
@@ -112,28 +179,32 @@ func (e *Executor) executeBuy(d ai.AIDecision) {
 	if maxSpread > 0 && spreadPct > maxSpread {
 		e.logger.Info("BUY skipped: spread too wide",
 			"ticker", d.Ticker, "spread", spreadPct, "max", maxSpread)
-		return
+		return skipped(d, fmt.Sprintf("широкий спред (%.2f%% > %.2f%%)", spreadPct, maxSpread))
 	}
 
 	lots := e.broker.CalculateLots(instrumentUID, lastPrice, maxPosition)
 	if lots < 1 {
 		e.logger.Info("BUY skipped: insufficient balance for 1 lot", "ticker", d.Ticker)
-		return
+		return skipped(d, "недостаточно средств на 1 лот")
 	}
 
 	// Execute buy order (limit if configured, otherwise market)
 	var result *broker.OrderResult
 	slippage := e.config.Trading.LimitOrderSlippage
+	order := events.OrderData{Ticker: d.Ticker, Action: "BUY", Lots: lots}
 	if slippage > 0 && lastPrice > 0 {
-		limitPrice := lastPrice * (1 + slippage/100)
-		result, err = e.broker.BuyWithPrice(instrumentUID, lots, limitPrice)
+		order.LimitPrice = lastPrice * (1 + slippage/100)
+		e.events.Publish(events.Order, order)
+		result, err = e.broker.BuyWithPrice(instrumentUID, lots, order.LimitPrice)
 	} else {
+		e.events.Publish(events.Order, order)
 		result, err = e.broker.Buy(instrumentUID, lots)
 	}
 	if err != nil {
 		e.logger.Error("buy order failed", "ticker", d.Ticker, "error", err)
 		e.notifier.NotifyError("BUY "+d.Ticker, err)
-		return
+		e.events.Publish(events.OrderFailed, events.OrderFailedData{Ticker: d.Ticker, Action: "BUY", Error: err.Error()})
+		return failed(d, err)
 	}
 
 	executedPrice := result.ExecutedPrice
@@ -154,60 +225,76 @@ func (e *Executor) executeBuy(d ai.AIDecision) {
 
 	// Save trade to DB
 	trade := &storage.Trade{
-		Ticker:            d.Ticker,
-		Action:            "BUY",
-		Price:             executedPrice,
-		Quantity:          result.ExecutedLots,
-		OrderID:           result.OrderID,
-		StopLossPrice:     slPrice,
-		TakeProfitPrice:   tpPrice,
-		StopLossOrderID:   slOrderID,
-		TakeProfitOrderID: tpOrderID,
-		Reasoning:         d.Reasoning,
-		Status:            "open",
+		Ticker:               d.Ticker,
+		Action:               "BUY",
+		Price:                executedPrice,
+		Quantity:             result.ExecutedLots,
+		OrderID:              result.OrderID,
+		StopLossPrice:        slPrice,
+		TakeProfitPrice:      tpPrice,
+		StopLossOrderID:      slOrderID,
+		TakeProfitOrderID:    tpOrderID,
+		Reasoning:            d.Reasoning,
+		PromptVersion:        d.PromptVersion,
+		Confidence:           d.Confidence,
+		CalibratedConfidence: calibrated,
+		AnalysisLogID:        d.AnalysisLogID,
+		Status:               "open",
 	}
 	if err := e.repo.SaveTrade(trade); err != nil {
 		e.logger.Error("save trade", "error", err)
 	}
 
 	e.notifier.NotifyBuy(d.Ticker, executedPrice, result.ExecutedLots, slPrice, tpPrice, d.Reasoning)
+	e.events.Publish(events.Fill, events.FillData{
+		Ticker: d.Ticker, Action: "BUY", Lots: result.ExecutedLots, Price: executedPrice, OrderID: result.OrderID,
+	})
 	e.logger.Info("BUY executed",
 		"ticker", d.Ticker, "price", executedPrice, "lots", result.ExecutedLots,
 		"sl", slPrice, "tp", tpPrice)
+	return Outcome{
+		Ticker: d.Ticker, Action: "BUY", Status: OutcomeExecuted,
+		Lots: result.ExecutedLots, Price: executedPrice, OrderID: result.OrderID,
+	}
 }
 
-func (e *Executor) executeSell(d ai.AIDecision) {
+func (e *Executor) executeSell(d ai.AIDecision) Outcome {
 	// Find open trade
 	openTrade, err := e.repo.GetOpenTradeByTicker(d.Ticker)
 	if err != nil {
 		e.logger.Info("SELL skipped: no open position", "ticker", d.Ticker)
-		return
+		return skipped(d, "нет открытой позиции")
 	}
 
 	instrumentUID, err := e.broker.ResolveTickerToUID(d.Ticker)
 	if err != nil {
 		e.logger.Error("resolve ticker for sell", "ticker", d.Ticker, "error", err)
-		return
+		return failed(d, fmt.Errorf("resolve ticker: %w", err))
 	}
 
 	// Execute sell order (limit if configured, otherwise market)
 	var result *broker.OrderResult
 	slippage := e.config.Trading.LimitOrderSlippage
+	order := events.OrderData{Ticker: d.Ticker, Action: "SELL", Lots: openTrade.Quantity}
 	if slippage > 0 {
 		lastPrice := e.broker.GetLastPrice(instrumentUID)
 		if lastPrice > 0 {
-			limitPrice := lastPrice * (1 - slippage/100)
-			result, err = e.broker.SellWithPrice(instrumentUID, openTrade.Quantity, limitPrice)
+			order.LimitPrice = lastPrice * (1 - slippage/100)
+			e.events.Publish(events.Order, order)
+			result, err = e.broker.SellWithPrice(instrumentUID, openTrade.Quantity, order.LimitPrice)
 		} else {
+			e.events.Publish(events.Order, order)
 			result, err = e.broker.Sell(instrumentUID, openTrade.Quantity)
 		}
 	} else {
+		e.events.Publish(events.Order, order)
 		result, err = e.broker.Sell(instrumentUID, openTrade.Quantity)
 	}
 	if err != nil {
 		e.logger.Error("sell order failed", "ticker", d.Ticker, "error", err)
 		e.notifier.NotifyError("SELL "+d.Ticker, err)
-		return
+		e.events.Publish(events.OrderFailed, events.OrderFailedData{Ticker: d.Ticker, Action: "SELL", Error: err.Error()})
+		return failed(d, err)
 	}
 
 	// Cancel stop orders
@@ -228,22 +315,82 @@ func (e *Executor) executeSell(d ai.AIDecision) {
 
 	// Save sell trade record
 	sellTrade := &storage.Trade{
-		Ticker:    d.Ticker,
-		Action:    "SELL",
-		Price:     result.ExecutedPrice,
-		Quantity:  result.ExecutedLots,
-		OrderID:   result.OrderID,
-		PnL:       pnl,
-		Reasoning: d.Reasoning,
-		Status:    "closed",
+		Ticker:        d.Ticker,
+		Action:        "SELL",
+		Price:         result.ExecutedPrice,
+		Quantity:      result.ExecutedLots,
+		OrderID:       result.OrderID,
+		PnL:           pnl,
+		Reasoning:     d.Reasoning,
+		PromptVersion: d.PromptVersion,
+		AnalysisLogID: d.AnalysisLogID,
+		Status:        "closed",
 	}
 	if err := e.repo.SaveTrade(sellTrade); err != nil {
 		e.logger.Error("save sell trade", "error", err)
 	}
 
 	e.notifier.NotifySell(d.Ticker, result.ExecutedPrice, result.ExecutedLots, pnl, d.Reasoning)
+	e.events.Publish(events.Fill, events.FillData{
+		Ticker: d.Ticker, Action: "SELL", Lots: result.ExecutedLots, Price: result.ExecutedPrice,
+		OrderID: result.OrderID, PnL: pnl,
+	})
 	e.logger.Info("SELL executed",
 		"ticker", d.Ticker, "price", result.ExecutedPrice, "lots", result.ExecutedLots, "pnl", pnl)
+	return Outcome{
+		Ticker: d.Ticker, Action: "SELL", Status: OutcomeExecuted,
+		Lots: result.ExecutedLots, Price: result.ExecutedPrice, OrderID: result.OrderID, PnL: pnl,
+	}
+}
+
+// ErrStopsCrossPrice rejects stop levels that would trigger at once.
+var ErrStopsCrossPrice = errors.New("SL должен быть ниже текущей цены, а TP выше")
+
+// ReplaceStops moves the trade's stop orders to the given prices and saves the
+// trade. A zero price keeps the current level. The new orders are placed before
+// the old ones are cancelled, so a failure leaves the position with its
+// current protection.
+func (e *Executor) ReplaceStops(trade *storage.Trade, stopLoss, takeProfit float64) error {
+	if stopLoss <= 0 {
+		stopLoss = trade.StopLossPrice
+	}
+	if takeProfit <= 0 {
+		takeProfit = trade.TakeProfitPrice
+	}
+
+	instrumentUID, err := e.broker.ResolveTickerToUID(trade.Ticker)
+	if err != nil {
+		return fmt.Errorf("resolve ticker: %w", err)
+	}
+	lastPrice := e.broker.GetLastPrice(instrumentUID)
+	if lastPrice <= 0 {
+		return fmt.Errorf("нет текущей цены %s для проверки SL/TP", trade.Ticker)
+	}
+	if stopLoss >= lastPrice || takeProfit <= lastPrice {
+		return fmt.Errorf("%w: SL %.2f, цена %.2f, TP %.2f", ErrStopsCrossPrice, stopLoss, lastPrice, takeProfit)
+	}
+
+	slOrderID, err := e.broker.PlaceStopLoss(instrumentUID, trade.Quantity, stopLoss)
+	if err != nil {
+		e.notifier.NotifyError("SL/TP "+trade.Ticker, err)
+		return err
+	}
+	tpOrderID, err := e.broker.PlaceTakeProfit(instrumentUID, trade.Quantity, takeProfit)
+	if err != nil {
+		e.broker.CancelStopOrders(slOrderID, "")
+		e.notifier.NotifyError("SL/TP "+trade.Ticker, err)
+		return err
+	}
+	e.broker.CancelStopOrders(trade.StopLossOrderID, trade.TakeProfitOrderID)
+
+	trade.StopLossOrderID, trade.TakeProfitOrderID = slOrderID, tpOrderID
+	trade.StopLossPrice, trade.TakeProfitPrice = stopLoss, takeProfit
+	if err := e.repo.UpdateTrade(trade); err != nil {
+		return fmt.Errorf("update trade: %w", err)
+	}
+
+	e.logger.Info("stop orders replaced", "ticker", trade.Ticker, "sl", stopLoss, "tp", takeProfit, "price", lastPrice)
+	return nil
 }
 
 // scalePositionByConfidence scales max position size based on AI confidence level.
@@ -258,6 +405,18 @@ func scalePositionByConfidence(maxRub float64, confidence int) float64 {
 	}
 }
 
+// OutcomesToJSON serializes executor outcomes for the analysis log.
+func OutcomesToJSON(outcomes []Outcome) string {
+	if len(outcomes) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(outcomes)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 func DecisionsToJSON(decisions []ai.AIDecision) string {
 	data, err := json.Marshal(decisions)
 	if err != nil {
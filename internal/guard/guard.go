@@ -1,28 +1,61 @@
 package guard
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/camuig/rus-trader/internal/ai"
 	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/corpevents"
+	"github.com/camuig/rus-trader/internal/events"
 	"github.com/camuig/rus-trader/internal/indicators"
 	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/metrics"
+	"github.com/camuig/rus-trader/internal/regime"
 	"github.com/camuig/rus-trader/internal/storage"
 )
 
 type BlockedDecision struct {
-	Decision ai.AIDecision
-	Reason   string
+	Decision ai.AIDecision `json:"decision"`
+	Reason   string        `json:"reason"`
+}
+
+// BlockedToJSON serializes guard rejections for the analysis log.
+func BlockedToJSON(blocked []BlockedDecision) string {
+	if len(blocked) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(blocked)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// BlockedFromJSON parses rejections stored by BlockedToJSON.
+func BlockedFromJSON(s string) ([]BlockedDecision, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var blocked []BlockedDecision
+	err := json.Unmarshal([]byte(s), &blocked)
+	return blocked, err
 }
 
 type TradeGuard struct {
 	repo       *storage.Repository
+	events     *events.Bus
 	config     *config.Config
 	logger     *logger.Logger
 	indicators map[string]indicators.Indicators // ticker -> indicators
+	sentiment  map[string]float64               // ticker -> news sentiment [-1, 1]
+	corpEvents map[string][]corpevents.Event    // ticker -> upcoming corporate events
+	regime     regime.Regime                    // market regime, empty when unknown
 	loc        *time.Location                   // MSK timezone
+	exitsOnly  bool                             // block all new entries (AI budget exceeded)
 }
 
 type filterState struct {
@@ -37,12 +70,15 @@ type filterState struct {
 	boughtThisCycle    map[string]struct{}
 }
 
-func NewTradeGuard(repo *storage.Repository, cfg *config.Config, log *logger.Logger) *TradeGuard {
+func NewTradeGuard(repo *storage.Repository, bus *events.Bus, cfg *config.Config, log *logger.Logger) *TradeGuard {
 	return &TradeGuard{
 		repo:       repo,
+		events:     bus,
 		config:     cfg,
 		logger:     log,
 		indicators: make(map[string]indicators.Indicators),
+		sentiment:  make(map[string]float64),
+		corpEvents: make(map[string][]corpevents.Event),
 		loc:        cfg.MOEXLocation(),
 	}
 }
@@ -52,6 +88,26 @@ func (g *TradeGuard) SetIndicators(ind map[string]indicators.Indicators) {
 	g.indicators = ind
 }
 
+// SetNewsSentiment sets the aggregated news sentiment per ticker.
+func (g *TradeGuard) SetNewsSentiment(sentiment map[string]float64) {
+	g.sentiment = sentiment
+}
+
+// SetCorporateEvents sets the upcoming corporate events per ticker.
+func (g *TradeGuard) SetCorporateEvents(events map[string][]corpevents.Event) {
+	g.corpEvents = events
+}
+
+// SetRegime sets the market regime for the risk-off rules.
+func (g *TradeGuard) SetRegime(r regime.Regime) {
+	g.regime = r
+}
+
+// SetExitsOnly switches the guard into exits-only mode, where every BUY is blocked.
+func (g *TradeGuard) SetExitsOnly(exitsOnly bool) {
+	g.exitsOnly = exitsOnly
+}
+
 func (g *TradeGuard) Filter(decisions []ai.AIDecision) (allowed, blocked []BlockedDecision) {
 	ordered := prioritizeDecisions(decisions)
 	state := g.loadFilterState()
@@ -61,7 +117,10 @@ func (g *TradeGuard) Filter(decisions []ai.AIDecision) (allowed, blocked []Block
 			blocked = append(blocked, BlockedDecision{Decision: d, Reason: reason})
 			g.logger.Info("decision blocked",
 				"ticker", d.Ticker, "action", d.Action, "reason", reason)
+			g.events.Publish(events.Blocked, events.BlockedData{Ticker: d.Ticker, Action: d.Action, Reason: reason})
+			metrics.GuardBlocks.Inc(ReasonCategory(reason))
 		} else {
+			d = g.scale(d)
 			allowed = append(allowed, BlockedDecision{Decision: d})
 			state.apply(d)
 		}
@@ -69,6 +128,15 @@ func (g *TradeGuard) Filter(decisions []ai.AIDecision) (allowed, blocked []Block
 	return allowed, blocked
 }
 
+// ReasonCategory strips the details from a block reason, e.g.
+// "лимит открытых позиций (3/3)" -> "лимит открытых позиций", for use as a metric label.
+func ReasonCategory(reason string) string {
+	if i := strings.IndexAny(reason, "(:"); i > 0 {
+		reason = reason[:i]
+	}
+	return strings.TrimSpace(reason)
+}
+
 func (g *TradeGuard) AllowedDecisions(decisions []ai.AIDecision) []ai.AIDecision {
 	allowed, _ := g.Filter(decisions)
 	result := make([]ai.AIDecision, len(allowed))
@@ -91,6 +159,10 @@ func (g *TradeGuard) check(d ai.AIDecision, state *filterState) string {
 func (g *TradeGuard) checkBuy(d ai.AIDecision, state *filterState) string {
 	cfg := g.config.Trading
 
+	if g.exitsOnly {
+		return "режим только выходов: превышен дневной бюджет AI"
+	}
+
 	if _, soldNow := state.soldThisCycle[d.Ticker]; soldNow {
 		return fmt.Sprintf("cooldown после продажи (осталось %d мин)", cfg.CooldownMinutes)
 	}
@@ -135,10 +207,35 @@ func (g *TradeGuard) checkBuy(d ai.AIDecision, state *filterState) string {
 		}
 	}
 
-	// 5. Pre-validation: no BUY in last hour of trading
+	// 5. Pre-validation: strongly negative news
+	if limit := cfg.BlockBuyNewsSentiment; limit < 0 {
+		if s, ok := g.sentiment[d.Ticker]; ok && s <= limit {
+			return fmt.Sprintf("негативный новостной фон (%.2f <= %.2f)", s, limit)
+		}
+	}
+
+	// 6. Market regime
+	if g.regime == regime.RiskOff && cfg.RiskOffBlockBuy {
+		return "рынок в режиме risk-off"
+	}
+
+	// 7. No BUY shortly before an ex-dividend date or a financial report
+	now := time.Now()
+	if days := cfg.NoBuyBeforeDividendDays; days > 0 {
+		if e, ok := corpevents.Next(g.corpEvents[d.Ticker], corpevents.Dividend, days, now, g.loc); ok {
+			return fmt.Sprintf("перед экс-дивидендной датой (%s, дней: %d)", e.Date.Format("02.01"), e.DaysUntil(now, g.loc))
+		}
+	}
+	if days := cfg.NoBuyBeforeReportDays; days > 0 {
+		if e, ok := corpevents.Next(g.corpEvents[d.Ticker], corpevents.Report, days, now, g.loc); ok {
+			return fmt.Sprintf("перед отчётностью (%s, дней: %d)", e.Date.Format("02.01"), e.DaysUntil(now, g.loc))
+		}
+	}
+
+	// 8. Pre-validation: no BUY in last hour of trading
 	if cfg.NoLastHourBuy {
-		now := time.Now().In(g.loc)
-		totalMinutes := now.Hour()*60 + now.Minute()
+		msk := now.In(g.loc)
+		totalMinutes := msk.Hour()*60 + msk.Minute()
 		if totalMinutes >= 1070 { // 17:50 MSK
 			return "запрет BUY в последний час торгов"
 		}
@@ -147,6 +244,45 @@ func (g *TradeGuard) checkBuy(d ai.AIDecision, state *filterState) string {
 	return ""
 }
 
+// scale reduces the position size of an allowed BUY in the risk-off regime.
+// Operator orders keep the full size.
+func (g *TradeGuard) scale(d ai.AIDecision) ai.AIDecision {
+	pct := g.config.Trading.RiskOffSizePct
+	if d.Action != "BUY" || d.Manual || g.regime != regime.RiskOff || pct <= 0 || pct >= 100 {
+		return d
+	}
+	d.SizeScale = pct / 100
+	g.logger.Info("position size reduced", "ticker", d.Ticker, "regime", g.regime, "size_pct", pct)
+	return d
+}
+
+// ReportExits returns SELL decisions for open positions whose issuer reports
+// within exit_before_report_days. They still pass Filter like AI decisions.
+func (g *TradeGuard) ReportExits() []ai.AIDecision {
+	days := g.config.Trading.ExitBeforeReportDays
+	if days <= 0 {
+		return nil
+	}
+	openTrades, err := g.repo.GetOpenTrades()
+	if err != nil {
+		g.logger.Error("load open trades for report exits", "error", err)
+		return nil
+	}
+	now := time.Now()
+	var exits []ai.AIDecision
+	for _, t := range openTrades {
+		if e, ok := corpevents.Next(g.corpEvents[t.Ticker], corpevents.Report, days, now, g.loc); ok {
+			exits = append(exits, ai.AIDecision{
+				Action:     "SELL",
+				Ticker:     t.Ticker,
+				Confidence: 100,
+				Reasoning:  fmt.Sprintf("выход перед отчётностью (%s, дней: %d)", e.Date.Format("02.01"), e.DaysUntil(now, g.loc)),
+			})
+		}
+	}
+	return exits
+}
+
 func (g *TradeGuard) checkSell(d ai.AIDecision, state *filterState) string {
 	cfg := g.config.Trading
 
@@ -8,7 +8,9 @@ import (
 
 	"github.com/camuig/rus-trader/internal/ai"
 	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/corpevents"
 	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/regime"
 	"github.com/camuig/rus-trader/internal/storage"
 )
 
@@ -191,6 +193,152 @@ func TestFilter_DuplicateSellDoesNotFreeTwoSlots(t *testing.T) {
 	}
 }
 
+func TestFilter_ExitsOnlyBlocksBuysButAllowsSells(t *testing.T) {
+	g, repo := newTestGuard(t, config.TradingConfig{
+		MaxOpenPositions: 5,
+		MaxDailyTrades:   100,
+		CooldownMinutes:  120,
+		MinHoldMinutes:   0,
+	})
+
+	saveTrade(t, repo, &storage.Trade{
+		Ticker:    "SBER",
+		Action:    "BUY",
+		Price:     100,
+		Quantity:  1,
+		Status:    "open",
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+	})
+
+	g.SetExitsOnly(true)
+	allowed, blocked := g.Filter([]ai.AIDecision{
+		{Action: "BUY", Ticker: "MOEX"},
+		{Action: "SELL", Ticker: "SBER"},
+	})
+
+	if len(allowed) != 1 || allowed[0].Decision.Action != "SELL" {
+		t.Fatalf("expected only SELL SBER to be allowed, got %+v", allowed)
+	}
+	if len(blocked) != 1 || blocked[0].Decision.Ticker != "MOEX" {
+		t.Fatalf("expected BUY MOEX to be blocked, got %+v", blocked)
+	}
+	if !strings.Contains(blocked[0].Reason, "бюджет AI") {
+		t.Fatalf("expected AI budget block reason, got %q", blocked[0].Reason)
+	}
+}
+
+func TestFilter_BlocksBuyOnNegativeNews(t *testing.T) {
+	g, _ := newTestGuard(t, config.TradingConfig{
+		MaxOpenPositions:      5,
+		MaxDailyTrades:        100,
+		CooldownMinutes:       120,
+		BlockBuyNewsSentiment: -0.5,
+	})
+
+	g.SetNewsSentiment(map[string]float64{"SBER": -0.7, "GAZP": -0.3})
+	allowed, blocked := g.Filter([]ai.AIDecision{
+		{Action: "BUY", Ticker: "SBER"},
+		{Action: "BUY", Ticker: "GAZP"},
+		{Action: "BUY", Ticker: "MOEX"},
+	})
+
+	if len(allowed) != 2 {
+		t.Fatalf("expected GAZP and MOEX to be allowed, got %+v", allowed)
+	}
+	if len(blocked) != 1 || blocked[0].Decision.Ticker != "SBER" {
+		t.Fatalf("expected BUY SBER to be blocked, got %+v", blocked)
+	}
+	if !strings.Contains(blocked[0].Reason, "негативный новостной фон") {
+		t.Fatalf("expected news sentiment block reason, got %q", blocked[0].Reason)
+	}
+}
+
+func TestFilter_BlocksBuyBeforeCorporateEvents(t *testing.T) {
+	g, _ := newTestGuard(t, config.TradingConfig{
+		MaxOpenPositions:        5,
+		MaxDailyTrades:          100,
+		CooldownMinutes:         120,
+		NoBuyBeforeDividendDays: 3,
+		NoBuyBeforeReportDays:   1,
+	})
+
+	g.SetCorporateEvents(map[string][]corpevents.Event{
+		"SBER": {{Ticker: "SBER", Kind: corpevents.Dividend, Date: daysFromToday(g, 2)}},
+		"GAZP": {{Ticker: "GAZP", Kind: corpevents.Dividend, Date: daysFromToday(g, 5)}},
+		"LKOH": {{Ticker: "LKOH", Kind: corpevents.Report, Date: daysFromToday(g, 1)}},
+		"MOEX": {{Ticker: "MOEX", Kind: corpevents.Report, Date: daysFromToday(g, 2)}},
+	})
+	allowed, blocked := g.Filter([]ai.AIDecision{
+		{Action: "BUY", Ticker: "SBER"},
+		{Action: "BUY", Ticker: "GAZP"},
+		{Action: "BUY", Ticker: "LKOH"},
+		{Action: "BUY", Ticker: "MOEX"},
+	})
+
+	if len(allowed) != 2 || len(blocked) != 2 {
+		t.Fatalf("expected GAZP and MOEX to be allowed, got allowed %+v, blocked %+v", allowed, blocked)
+	}
+	if blocked[0].Decision.Ticker != "SBER" || !strings.HasPrefix(blocked[0].Reason, "перед экс-дивидендной датой") {
+		t.Errorf("unexpected dividend block: %+v", blocked[0])
+	}
+	if blocked[1].Decision.Ticker != "LKOH" || !strings.HasPrefix(blocked[1].Reason, "перед отчётностью") {
+		t.Errorf("unexpected report block: %+v", blocked[1])
+	}
+}
+
+func TestReportExits_SellsOpenPositionsBeforeReport(t *testing.T) {
+	g, repo := newTestGuard(t, config.TradingConfig{ExitBeforeReportDays: 1})
+	for _, ticker := range []string{"SBER", "GAZP"} {
+		saveTrade(t, repo, &storage.Trade{Ticker: ticker, Action: "BUY", Price: 100, Quantity: 1, Status: "open"})
+	}
+	g.SetCorporateEvents(map[string][]corpevents.Event{
+		"SBER": {{Ticker: "SBER", Kind: corpevents.Report, Date: daysFromToday(g, 1)}},
+		"GAZP": {{Ticker: "GAZP", Kind: corpevents.Report, Date: daysFromToday(g, 3)}},
+		"LKOH": {{Ticker: "LKOH", Kind: corpevents.Report, Date: daysFromToday(g, 0)}},
+	})
+
+	exits := g.ReportExits()
+	if len(exits) != 1 || exits[0].Action != "SELL" || exits[0].Ticker != "SBER" {
+		t.Fatalf("expected SELL SBER only, got %+v", exits)
+	}
+}
+
+func TestFilter_RiskOffRegime(t *testing.T) {
+	g, _ := newTestGuard(t, config.TradingConfig{
+		MaxOpenPositions: 5,
+		MaxDailyTrades:   100,
+		CooldownMinutes:  120,
+		RiskOffSizePct:   50,
+	})
+
+	g.SetRegime(regime.RiskOff)
+	allowed, _ := g.Filter([]ai.AIDecision{
+		{Action: "BUY", Ticker: "SBER"},
+		{Action: "BUY", Ticker: "GAZP", Manual: true},
+	})
+	if len(allowed) != 2 || allowed[0].Decision.SizeScale != 0.5 || allowed[1].Decision.SizeScale != 0 {
+		t.Fatalf("expected half size for the model's BUY only, got %+v", allowed)
+	}
+
+	g.config.Trading.RiskOffBlockBuy = true
+	_, blocked := g.Filter([]ai.AIDecision{{Action: "BUY", Ticker: "SBER"}})
+	if len(blocked) != 1 || blocked[0].Reason != "рынок в режиме risk-off" {
+		t.Fatalf("expected BUY to be blocked in risk-off, got %+v", blocked)
+	}
+
+	g.SetRegime(regime.Neutral)
+	allowed, _ = g.Filter([]ai.AIDecision{{Action: "BUY", Ticker: "SBER"}})
+	if len(allowed) != 1 || allowed[0].Decision.SizeScale != 0 {
+		t.Fatalf("expected full-size BUY outside risk-off, got %+v", allowed)
+	}
+}
+
+// daysFromToday returns the calendar date n days after today in MSK.
+func daysFromToday(g *TradeGuard, n int) time.Time {
+	now := time.Now().In(g.loc)
+	return time.Date(now.Year(), now.Month(), now.Day()+n, 0, 0, 0, 0, time.UTC)
+}
+
 func newTestGuard(t *testing.T, trading config.TradingConfig) (*TradeGuard, *storage.Repository) {
 	t.Helper()
 
@@ -204,7 +352,7 @@ func newTestGuard(t *testing.T, trading config.TradingConfig) (*TradeGuard, *sto
 	cfg := &config.Config{
 		Trading: trading,
 	}
-	return NewTradeGuard(repo, cfg, logger.New("error")), repo
+	return NewTradeGuard(repo, nil, cfg, logger.New("error")), repo
 }
 
 func saveTrade(t *testing.T, repo *storage.Repository, trade *storage.Trade) {
@@ -213,3 +361,16 @@ func saveTrade(t *testing.T, repo *storage.Repository, trade *storage.Trade) {
 		t.Fatalf("save trade %s %s: %v", trade.Action, trade.Ticker, err)
 	}
 }
+
+func TestReasonCategory_StripsDetails(t *testing.T) {
+	cases := map[string]string{
+		"лимит открытых позиций (3/3)":                     "лимит открытых позиций",
+		"режим только выходов: превышен дневной бюджет AI": "режим только выходов",
+		"позиция по тикеру уже открыта":                    "позиция по тикеру уже открыта",
+	}
+	for reason, want := range cases {
+		if got := ReasonCategory(reason); got != want {
+			t.Errorf("ReasonCategory(%q) = %q, want %q", reason, got, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package history downloads MOEX ISS bars for a list of tickers into a sink,
+// the database, CSV or Parquet files, resuming each ticker after the last bar the sink
+// already has. Days requested before the first stored bar are added to the
+// database; files can only be appended to, so for them the gap is reported.
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+)
+
+// Fetch loads the bars of a ticker within [from, till] and passes them to
+// page as they arrive, oldest first.
+type Fetch func(ctx context.Context, ticker string, from, till time.Time, page func([]moex.Candle) error) error
+
+// Sink stores the bars of one interval.
+type Sink interface {
+	// First returns the start of the earliest stored bar of a ticker, zero if none.
+	First(ticker string) (time.Time, error)
+	// Last returns the start of the latest stored bar of a ticker, zero if none.
+	Last(ticker string) (time.Time, error)
+	Write(ticker string, bars []moex.Candle) error
+	// Appends reports whether bars can only follow the stored ones, so the
+	// days before the first stored bar cannot be added.
+	Appends() bool
+}
+
+// Flusher is a Sink that buffers bars; Flush writes out the bars of a ticker.
+// The downloader calls it when a ticker is done, also after a failure.
+type Flusher interface {
+	Flush(ticker string) error
+}
+
+// Result is the outcome of one ticker.
+type Result struct {
+	Ticker     string
+	From       time.Time // first day requested
+	Resumed    bool      // From follows bars already in the sink
+	Backfilled bool      // the days before the first stored bar were requested too
+	Gap        time.Time // the first stored bar, when the days before it are missing and the sink cannot add them
+	Bars       int       // bars written
+	Err        error
+}
+
+// Downloader writes each page to the sink as soon as it arrives, so an
+// interrupted download loses at most one page (or what a Flusher has not
+// written yet) and continues on the next run.
+type Downloader struct {
+	fetch  Fetch
+	sink   Sink
+	logger *logger.Logger
+}
+
+func NewDownloader(fetch Fetch, sink Sink, log *logger.Logger) *Downloader {
+	return &Downloader{fetch: fetch, sink: sink, logger: log}
+}
+
+// Run downloads the tickers one after another for the days from..till. A
+// failed ticker is reported in its result and does not stop the others;
+// cancelling ctx does.
+func (d *Downloader) Run(ctx context.Context, tickers []string, from, till time.Time) []Result {
+	results := make([]Result, 0, len(tickers))
+	for _, ticker := range tickers {
+		if ctx.Err() != nil {
+			results = append(results, Result{Ticker: ticker, Err: ctx.Err()})
+			continue
+		}
+		r := d.download(ctx, ticker, from, till)
+		if r.Err != nil {
+			d.logger.Warn("download history", "ticker", ticker, "bars", r.Bars, "error", r.Err)
+		} else if !r.Gap.IsZero() {
+			d.logger.Warn("download history: days before the stored bars are missing", "ticker", ticker,
+				"from", from.Format("2006-01-02"), "first_stored", r.Gap.Format("2006-01-02"), "bars", r.Bars)
+		} else {
+			d.logger.Info("download history", "ticker", ticker, "from", r.From.Format("2006-01-02"), "bars", r.Bars)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (d *Downloader) download(ctx context.Context, ticker string, from, till time.Time) Result {
+	r := Result{Ticker: ticker, From: from}
+	last, err := d.sink.Last(ticker)
+	if err != nil {
+		r.Err = fmt.Errorf("read last bar: %w", err)
+		return r
+	}
+	r.Err = d.fetchRange(ctx, ticker, from, till, last, &r)
+	if f, ok := d.sink.(Flusher); ok {
+		if err := f.Flush(ticker); err != nil && r.Err == nil {
+			r.Err = fmt.Errorf("write bars: %w", err)
+		}
+	}
+	return r
+}
+
+// fetchRange downloads the days from..till that the sink is missing, given its
+// last stored bar.
+func (d *Downloader) fetchRange(ctx context.Context, ticker string, from, till, last time.Time, r *Result) error {
+	if !last.IsZero() && !last.Before(from) {
+		first, err := d.sink.First(ticker)
+		if err != nil {
+			return fmt.Errorf("read first bar: %w", err)
+		}
+		// stored bars may start after from, e.g. after an earlier run with a
+		// later -from; a share listed after from has a gap that stays empty
+		if firstDay := dayOf(first); firstDay.After(from) {
+			if d.sink.Appends() {
+				r.Gap = first
+			} else {
+				r.Backfilled = true
+				if err := d.write(ctx, ticker, from, minTime(firstDay, till), r, func(b moex.Candle) bool { return b.Begin.Before(first) }); err != nil {
+					return err
+				}
+			}
+		}
+		// ISS takes whole days: the day of the last bar is requested again and
+		// the bars already stored are skipped
+		if !r.Backfilled {
+			r.From = dayOf(last)
+		}
+		r.Resumed = true
+		from = dayOf(last)
+	}
+	if from.After(till) {
+		return nil
+	}
+	return d.write(ctx, ticker, from, till, r, func(b moex.Candle) bool { return !r.Resumed || b.Begin.After(last) })
+}
+
+// write fetches the days from..till and stores the bars that keep passes.
+func (d *Downloader) write(ctx context.Context, ticker string, from, till time.Time, r *Result, keep func(moex.Candle) bool) error {
+	return d.fetch(ctx, ticker, from, till, func(bars []moex.Candle) error {
+		out := bars[:0:0]
+		for _, b := range bars {
+			if keep(b) {
+				out = append(out, b)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		if err := d.sink.Write(ticker, out); err != nil {
+			return fmt.Errorf("write bars: %w", err)
+		}
+		r.Bars += len(out)
+		return nil
+	})
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,207 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 7, d, 0, 0, 0, 0, time.UTC)
+}
+
+// fakeISS serves one daily bar per day, two days per page.
+type fakeISS struct {
+	requests []time.Time // from of every fetch
+	fail     map[string]error
+}
+
+func (f *fakeISS) fetch(ctx context.Context, ticker string, from, till time.Time, page func([]moex.Candle) error) error {
+	f.requests = append(f.requests, from)
+	if err := f.fail[ticker]; err != nil {
+		return err
+	}
+	var bars []moex.Candle
+	for t := from; !t.After(till); t = t.AddDate(0, 0, 1) {
+		bars = append(bars, moex.Candle{Begin: t, Open: 100, High: 101, Low: 99, Close: 100.5, Volume: 10, Value: 1005})
+		if len(bars) == 2 {
+			if err := page(bars); err != nil {
+				return err
+			}
+			bars = nil
+		}
+	}
+	if len(bars) > 0 {
+		return page(bars)
+	}
+	return nil
+}
+
+func TestDownloader_ResumesAfterLastStoredBar(t *testing.T) {
+	sink, err := NewCSVSink(t.TempDir(), "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &fakeISS{}
+	d := NewDownloader(iss.fetch, sink, logger.New("error"))
+
+	res := d.Run(context.Background(), []string{"SBER"}, day(1), day(5))
+	if res[0].Err != nil || res[0].Bars != 5 || res[0].Resumed {
+		t.Fatalf("first run: %+v", res[0])
+	}
+
+	res = d.Run(context.Background(), []string{"SBER"}, day(1), day(8))
+	if res[0].Err != nil || res[0].Bars != 3 || !res[0].Resumed || !res[0].From.Equal(day(5)) {
+		t.Fatalf("second run must resume on the last stored day: %+v", res[0])
+	}
+	if last, _ := sink.Last("SBER"); !last.Equal(day(8)) {
+		t.Errorf("last bar %s, want %s", last, day(8))
+	}
+
+	data, err := os.ReadFile(sink.Path("SBER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 9 || lines[0] != "time,open,high,low,close,volume,value" || lines[1] != "2024-07-01 00:00:00,100,101,99,100.5,10,1005" {
+		t.Errorf("unexpected file:\n%s", data)
+	}
+
+	iss.requests = nil
+	res = d.Run(context.Background(), []string{"SBER"}, day(1), day(7))
+	if res[0].Bars != 0 || len(iss.requests) != 0 {
+		t.Errorf("an up-to-date ticker must not be fetched: %+v, %d requests", res[0], len(iss.requests))
+	}
+}
+
+func TestDownloader_EarlierFromThanStoredBars(t *testing.T) {
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "history-test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStoreSink(storage.NewRepository(db), "1d")
+	files, err := NewCSVSink(t.TempDir(), "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sink := range []Sink{store, files} {
+		iss := &fakeISS{}
+		d := NewDownloader(iss.fetch, sink, logger.New("error"))
+		if res := d.Run(context.Background(), []string{"SBER"}, day(10), day(12)); res[0].Err != nil || res[0].Bars != 3 {
+			t.Fatalf("%T: first run: %+v", sink, res[0])
+		}
+
+		iss.requests = nil
+		r := d.Run(context.Background(), []string{"SBER"}, day(1), day(14))[0]
+		if r.Err != nil || !r.Resumed {
+			t.Fatalf("%T: second run: %+v", sink, r)
+		}
+		first, _ := sink.First("SBER")
+		if last, _ := sink.Last("SBER"); !last.Equal(day(14)) {
+			t.Errorf("%T: last bar %s, want %s", sink, last, day(14))
+		}
+		if sink.Appends() {
+			// the file cannot take older rows: the gap is reported, not skipped silently
+			if r.Bars != 2 || r.Backfilled || !r.Gap.Equal(day(10)) || !first.Equal(day(10)) || len(iss.requests) != 1 {
+				t.Errorf("%T: expected the gap before %s reported, got %+v, first %s, %d requests", sink, day(10), r, first, len(iss.requests))
+			}
+			continue
+		}
+		// days 1-9 before the stored bars and 13-14 after them
+		if r.Bars != 11 || !r.Backfilled || !r.Gap.IsZero() || !r.From.Equal(day(1)) || !first.Equal(day(1)) {
+			t.Errorf("%T: expected the days before %s downloaded, got %+v, first %s", sink, day(10), r, first)
+		}
+	}
+}
+
+func TestParquetSink_ResumesWithNewParts(t *testing.T) {
+	sink, err := NewParquetSink(t.TempDir(), "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &fakeISS{}
+	d := NewDownloader(iss.fetch, sink, logger.New("error"))
+
+	res := d.Run(context.Background(), []string{"SBER", "GAZP"}, day(1), day(5))
+	if res[0].Err != nil || res[0].Bars != 5 || res[1].Err != nil || res[1].Bars != 5 {
+		t.Fatalf("first run: %+v", res)
+	}
+	res = d.Run(context.Background(), []string{"SBER"}, day(1), day(8))
+	if res[0].Err != nil || res[0].Bars != 3 || !res[0].From.Equal(day(5)) {
+		t.Fatalf("second run must resume on the last stored day: %+v", res[0])
+	}
+
+	parts, err := filepath.Glob(filepath.Join(sink.Dir("SBER"), "*"))
+	if err != nil || len(parts) != 2 || filepath.Base(parts[1]) != "part-00002.parquet" {
+		t.Fatalf("expected two parts, got %v, %v", parts, err)
+	}
+	var rows []parquetBar
+	for _, p := range parts {
+		part, err := parquet.ReadFile[parquetBar](p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, part...)
+	}
+	if len(rows) != 8 || !rows[0].Time.Equal(day(1)) || rows[0].Close != 100.5 || rows[7].Value != 1005 {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+	if last, _ := sink.Last("SBER"); !last.Equal(day(8)) {
+		t.Errorf("last bar %s, want %s", last, day(8))
+	}
+	if last, _ := sink.Last("LKOH"); !last.IsZero() {
+		t.Errorf("expected no bars of a new ticker, got %s", last)
+	}
+}
+
+func TestDownloader_FailedTickerDoesNotStopOthers(t *testing.T) {
+	sink, err := NewCSVSink(t.TempDir(), "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &fakeISS{fail: map[string]error{"GAZP": errors.New("status 503")}}
+	res := NewDownloader(iss.fetch, sink, logger.New("error")).Run(context.Background(), []string{"GAZP", "SBER"}, day(1), day(3))
+	if res[0].Err == nil || res[1].Err != nil || res[1].Bars != 3 {
+		t.Fatalf("unexpected results %+v", res)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res = NewDownloader(iss.fetch, sink, logger.New("error")).Run(ctx, []string{"LKOH"}, day(1), day(3))
+	if !errors.Is(res[0].Err, context.Canceled) {
+		t.Errorf("expected a cancelled result, got %+v", res[0])
+	}
+}
+
+func TestCSVSink_LastReadsOnlyTheTail(t *testing.T) {
+	sink, err := NewCSVSink(t.TempDir(), "1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, err := sink.Last("SBER"); err != nil || !last.IsZero() {
+		t.Fatalf("missing file: %s, %v", last, err)
+	}
+
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	var bars []moex.Candle
+	for i := 0; i < 500; i++ {
+		bars = append(bars, moex.Candle{Begin: start.Add(time.Duration(i) * time.Hour), Close: 250.123456})
+	}
+	if err := sink.Write("SBER", bars); err != nil {
+		t.Fatal(err)
+	}
+	if last, err := sink.Last("SBER"); err != nil || !last.Equal(bars[499].Begin) {
+		t.Fatalf("got %s, %v; want %s", last, err, bars[499].Begin)
+	}
+}
@@ -0,0 +1,155 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/camuig/rus-trader/internal/moex"
+)
+
+// parquetFlushRows bounds the bars a ParquetSink holds before writing a part.
+const parquetFlushRows = 100_000
+
+// parquetBar is a row of a Parquet part. ISS times are Moscow wall-clock
+// times, so they are stored as local timestamps.
+type parquetBar struct {
+	Time   time.Time `parquet:"time,timestamp(millisecond:local)"`
+	Open   float64   `parquet:"open"`
+	High   float64   `parquet:"high"`
+	Low    float64   `parquet:"low"`
+	Close  float64   `parquet:"close"`
+	Volume float64   `parquet:"volume"`
+	Value  float64   `parquet:"value"`
+}
+
+// ParquetSink writes the bars of a ticker as parts of a dataset,
+// <dir>/<ticker>_<interval>/part-00001.parquet, ... A Parquet file cannot be
+// appended to, so bars are buffered and written as a new part when the
+// buffer fills and when the ticker is done; a later run adds parts.
+type ParquetSink struct {
+	dir      string
+	interval string
+
+	ticker string // of buf
+	buf    []parquetBar
+}
+
+func NewParquetSink(dir, interval string) (*ParquetSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+	return &ParquetSink{dir: dir, interval: interval}, nil
+}
+
+// Dir is the dataset of a ticker.
+func (s *ParquetSink) Dir(ticker string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%s", ticker, s.interval))
+}
+
+// First returns the first row of the first part, or the first buffered bar.
+func (s *ParquetSink) First(ticker string) (time.Time, error) {
+	parts, err := s.parts(ticker)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(parts) == 0 {
+		if ticker == s.ticker && len(s.buf) > 0 {
+			return s.buf[0].Time, nil
+		}
+		return time.Time{}, nil
+	}
+	rows, err := parquet.ReadFile[parquetBar](parts[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", parts[0], err)
+	}
+	if len(rows) == 0 {
+		return time.Time{}, nil
+	}
+	return rows[0].Time.UTC(), nil
+}
+
+// Appends is true: a part cannot hold bars older than the parts before it.
+func (s *ParquetSink) Appends() bool { return true }
+
+// Last returns the last buffered bar, or the last row of the latest part.
+func (s *ParquetSink) Last(ticker string) (time.Time, error) {
+	if ticker == s.ticker && len(s.buf) > 0 {
+		return s.buf[len(s.buf)-1].Time, nil
+	}
+	parts, err := s.parts(ticker)
+	if err != nil || len(parts) == 0 {
+		return time.Time{}, err
+	}
+	rows, err := parquet.ReadFile[parquetBar](parts[len(parts)-1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", parts[len(parts)-1], err)
+	}
+	if len(rows) == 0 {
+		return time.Time{}, nil
+	}
+	return rows[len(rows)-1].Time.UTC(), nil
+}
+
+func (s *ParquetSink) Write(ticker string, bars []moex.Candle) error {
+	if ticker != s.ticker {
+		if err := s.Flush(s.ticker); err != nil {
+			return err
+		}
+		s.ticker = ticker
+	}
+	for _, b := range bars {
+		s.buf = append(s.buf, parquetBar{Time: b.Begin, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Volume: b.Volume, Value: b.Value})
+	}
+	if len(s.buf) >= parquetFlushRows {
+		return s.Flush(ticker)
+	}
+	return nil
+}
+
+// Flush writes the buffered bars of the ticker as the next part.
+func (s *ParquetSink) Flush(ticker string) error {
+	if ticker != s.ticker || len(s.buf) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.Dir(ticker), 0o755); err != nil {
+		return err
+	}
+	parts, err := s.parts(ticker)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(parts) > 0 {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(parts[len(parts)-1]), "part-"), ".parquet")
+		n, err := strconv.Atoi(name)
+		if err != nil {
+			return fmt.Errorf("unexpected part %s", parts[len(parts)-1])
+		}
+		next = n + 1
+	}
+
+	// a part is renamed into place only when complete, so readers and the
+	// next run never see a truncated file
+	path := filepath.Join(s.Dir(ticker), fmt.Sprintf("part-%05d.parquet", next))
+	if err := parquet.WriteFile(path+".tmp", s.buf); err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// parts lists the parts of a ticker in write order.
+func (s *ParquetSink) parts(ticker string) ([]string, error) {
+	// zero-padded numbers keep the glob's lexical order
+	return filepath.Glob(filepath.Join(s.Dir(ticker), "part-*.parquet"))
+}
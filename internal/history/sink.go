@@ -0,0 +1,187 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// timeLayout is the ISS time format, kept in CSV files.
+const timeLayout = "2006-01-02 15:04:05"
+
+var csvHeader = []string{"time", "open", "high", "low", "close", "volume", "value"}
+
+// StoreSink keeps bars in the history_bars table.
+type StoreSink struct {
+	repo     *storage.Repository
+	interval string
+}
+
+func NewStoreSink(repo *storage.Repository, interval string) *StoreSink {
+	return &StoreSink{repo: repo, interval: interval}
+}
+
+func (s *StoreSink) First(ticker string) (time.Time, error) {
+	return s.repo.GetFirstHistoryBarTime(ticker, s.interval)
+}
+
+func (s *StoreSink) Last(ticker string) (time.Time, error) {
+	return s.repo.GetLastHistoryBarTime(ticker, s.interval)
+}
+
+// Appends is false: bars are upserted by time in any order.
+func (s *StoreSink) Appends() bool { return false }
+
+func (s *StoreSink) Write(ticker string, bars []moex.Candle) error {
+	rows := make([]storage.HistoryBar, len(bars))
+	for i, b := range bars {
+		rows[i] = storage.HistoryBar{
+			Ticker:   ticker,
+			Interval: s.interval,
+			Time:     b.Begin,
+			Open:     b.Open,
+			High:     b.High,
+			Low:      b.Low,
+			Close:    b.Close,
+			Volume:   b.Volume,
+			Value:    b.Value,
+		}
+	}
+	return s.repo.SaveHistoryBars(rows)
+}
+
+// CSVSink appends bars to <dir>/<ticker>_<interval>.csv.
+type CSVSink struct {
+	dir      string
+	interval string
+}
+
+func NewCSVSink(dir, interval string) (*CSVSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+	return &CSVSink{dir: dir, interval: interval}, nil
+}
+
+// Path is the file of a ticker.
+func (s *CSVSink) Path(ticker string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%s.csv", ticker, s.interval))
+}
+
+// First reads the time of the row after the header.
+func (s *CSVSink) First(ticker string) (time.Time, error) {
+	f, err := os.Open(s.Path(ticker))
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			if t, err := s.rowTime(ticker, line, "first"); err != nil || !t.IsZero() {
+				return t, err
+			}
+		}
+	}
+	return time.Time{}, sc.Err()
+}
+
+// Last reads the time of the last row; only the end of the file is read.
+func (s *CSVSink) Last(ticker string) (time.Time, error) {
+	f, err := os.Open(s.Path(ticker))
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	line, err := lastLine(f)
+	if err != nil || line == "" {
+		return time.Time{}, err
+	}
+	return s.rowTime(ticker, line, "last")
+}
+
+// rowTime parses the time of a row, zero for the header.
+func (s *CSVSink) rowTime(ticker, line, which string) (time.Time, error) {
+	value, _, _ := strings.Cut(line, ",")
+	if value == csvHeader[0] {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(timeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %s row: %w", s.Path(ticker), which, err)
+	}
+	return t, nil
+}
+
+// Appends is true: rows are appended to the file.
+func (s *CSVSink) Appends() bool { return true }
+
+func (s *CSVSink) Write(ticker string, bars []moex.Candle) error {
+	f, err := os.OpenFile(s.Path(ticker), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		w.Write(csvHeader)
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, b := range bars {
+		w.Write([]string{b.Begin.Format(timeLayout), format(b.Open), format(b.High), format(b.Low), format(b.Close), format(b.Volume), format(b.Value)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// lastLine returns the last non-empty line of a file.
+func lastLine(f *os.File) (string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	const chunk = 4096
+	size := info.Size()
+	var tail []byte
+	for offset := size; offset > 0; {
+		n := int64(chunk)
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return "", err
+		}
+		tail = append(buf, tail...)
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return string(trimmed[i+1:]), nil
+		}
+		if offset == 0 {
+			return string(trimmed), nil
+		}
+	}
+	return "", nil
+}
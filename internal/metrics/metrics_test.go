@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestCounterAndGauge(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("orders_total", "Orders.", "action", "result")
+	g := r.NewGauge("equity", "Equity.")
+
+	c.Inc("BUY", "executed")
+	c.Add(2, "BUY", "executed")
+	c.Inc("SELL", "failed")
+	c.Add(-5, "SELL", "failed") // ignored
+	g.Set(1234.5)
+	g.Set(1000)
+
+	want := `# HELP equity Equity.
+# TYPE equity gauge
+equity 1000
+# HELP orders_total Orders.
+# TYPE orders_total counter
+orders_total{action="BUY",result="executed"} 3
+orders_total{action="SELL",result="failed"} 1
+`
+	if got := render(t, r); got != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramCumulativeBuckets(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("stage_seconds", "Stages.", []float64{1, 0.5}, "stage")
+
+	h.Observe(0.2, "ai")
+	h.Observe(0.5, "ai") // on the bucket bound, counted as le="0.5"
+	h.Observe(0.7, "ai")
+	h.Observe(3, "ai")
+
+	want := `# HELP stage_seconds Stages.
+# TYPE stage_seconds histogram
+stage_seconds_bucket{stage="ai",le="0.5"} 2
+stage_seconds_bucket{stage="ai",le="1"} 3
+stage_seconds_bucket{stage="ai",le="+Inf"} 4
+stage_seconds_sum{stage="ai"} 4.4
+stage_seconds_count{stage="ai"} 4
+`
+	if got := render(t, r); got != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("blocks_total", "Line one\nline \\two.", "reason")
+	c.Inc(`say "no"`)
+
+	got := render(t, r)
+	if !strings.Contains(got, `# HELP blocks_total Line one\nline \\two.`) {
+		t.Fatalf("help not escaped:\n%s", got)
+	}
+	if !strings.Contains(got, `blocks_total{reason="say \"no\""} 1`) {
+		t.Fatalf("label not escaped:\n%s", got)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("x_total", "X.", "a")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	c.Inc()
+}
+
+func TestUnlabelledMetricExportedBeforeFirstUpdate(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("last_success", "Last success.")
+	r.NewCounter("by_reason_total", "By reason.", "reason")
+
+	got := render(t, r)
+	if !strings.Contains(got, "\nlast_success 0\n") {
+		t.Fatalf("expected zero sample:\n%s", got)
+	}
+	if strings.Contains(got, "by_reason_total{") {
+		t.Fatalf("labelled metric should have no series yet:\n%s", got)
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+// Default is the registry served at /metrics.
+var Default = NewRegistry()
+
+// Trading bot metrics. Label values are kept to small fixed sets so that
+// series do not grow with tickers or free-form messages.
+var (
+	CycleStageSeconds = Default.NewHistogram("rustrader_cycle_stage_duration_seconds",
+		"Duration of analysis cycle stages.", DefBuckets, "stage")
+	CycleSeconds = Default.NewHistogram("rustrader_cycle_duration_seconds",
+		"Duration of complete analysis cycles.", DefBuckets)
+	Cycles = Default.NewCounter("rustrader_cycles_total",
+		"Analysis cycles by result: success, failure or skipped (outside trading hours or paused).", "result")
+	LastSuccess = Default.NewGauge("rustrader_last_success_timestamp_seconds",
+		"Unix time of the last successful analysis cycle.")
+	Paused = Default.NewGauge("rustrader_paused",
+		"1 while scheduled trading is paused by an operator.")
+
+	AISeconds = Default.NewHistogram("rustrader_ai_request_duration_seconds",
+		"Latency of AI analysis requests, including tool calls.", DefBuckets)
+	AITokens = Default.NewCounter("rustrader_ai_tokens_total",
+		"AI tokens used, by type: prompt, cached (part of prompt), completion, reasoning (part of completion).", "type")
+	AICostRub = Default.NewCounter("rustrader_ai_cost_rub_total",
+		"Estimated AI cost in roubles.")
+	AIErrors = Default.NewCounter("rustrader_ai_errors_total",
+		"Failed AI analysis requests.")
+
+	Decisions = Default.NewCounter("rustrader_decisions_total",
+		"AI decisions by action.", "action")
+	GuardBlocks = Default.NewCounter("rustrader_guard_blocks_total",
+		"Decisions blocked by the trade guard, by reason.", "reason")
+	Orders = Default.NewCounter("rustrader_orders_total",
+		"Orders by action and result: executed, skipped or failed.", "action", "result")
+	Notifications = Default.NewCounter("rustrader_notifications_total",
+		"Notifications by channel and result: sent, failed (after all retries) or dropped (queue full).", "channel", "result")
+	NewsFetches = Default.NewCounter("rustrader_news_fetches_total",
+		"News source fetches by source and result: ok or failed.", "source", "result")
+	BrokerErrors = Default.NewCounter("rustrader_broker_errors_total",
+		"Failed broker API calls by method.", "method")
+
+	OpenPositions = Default.NewGauge("rustrader_open_positions",
+		"Number of open positions.")
+	Equity = Default.NewGauge("rustrader_equity_rub",
+		"Total portfolio value in roubles.")
+	DailyPnL = Default.NewGauge("rustrader_daily_pnl_rub",
+		"Portfolio P&L for the current day in roubles.")
+)
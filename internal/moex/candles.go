@@ -0,0 +1,163 @@
+package moex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	dailyCandlesPath  = "/engines/%s/markets/%s/securities/%s/candles.json?iss.meta=off&interval=24&from=%s"
+	futuresSeriesPath = "/engines/futures/markets/forts/securities.json?iss.meta=off&iss.only=securities&securities.columns=SECID,ASSETCODE,LASTTRADEDATE"
+	frontContractDays = 3 // a contract this close to expiry is skipped for the next one
+)
+
+// Candle is an ISS candle.
+type Candle struct {
+	Begin  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	Value  float64 // turnover in the quote currency
+}
+
+type issCandlesResponse struct {
+	Candles struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"candles"`
+}
+
+// FetchDailyCandles loads daily candles of any ISS security since from,
+// oldest first, e.g. engine "stock", market "index", security "IMOEX".
+func (c *Client) FetchDailyCandles(ctx context.Context, engine, market, security string, from time.Time) ([]Candle, error) {
+	u := c.issURL + fmt.Sprintf(dailyCandlesPath, url.PathEscape(engine), url.PathEscape(market), url.PathEscape(security), from.Format("2006-01-02"))
+	body, err := c.getISS(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("fetch candles %s: %w", security, err)
+	}
+	return parseCandles(body)
+}
+
+func parseCandles(body []byte) ([]Candle, error) {
+	candles, _, err := parseCandlesPage(body)
+	return candles, err
+}
+
+// parseCandlesPage also returns the number of rows in the response, empty
+// candles included, to tell the last page.
+func parseCandlesPage(body []byte) ([]Candle, int, error) {
+	var iss issCandlesResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return nil, 0, fmt.Errorf("parse ISS response: %w", err)
+	}
+	col := make(map[string]int, len(iss.Candles.Columns))
+	for i, name := range iss.Candles.Columns {
+		col[name] = i
+	}
+	value := func(row []interface{}, name string) interface{} {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return nil
+		}
+		return row[i]
+	}
+
+	result := make([]Candle, 0, len(iss.Candles.Data))
+	for _, row := range iss.Candles.Data {
+		begin, _ := value(row, "begin").(string)
+		t, err := time.Parse("2006-01-02 15:04:05", begin)
+		if err != nil {
+			continue
+		}
+		candle := Candle{
+			Begin:  t,
+			Open:   toFloat64(value(row, "open")),
+			High:   toFloat64(value(row, "high")),
+			Low:    toFloat64(value(row, "low")),
+			Close:  toFloat64(value(row, "close")),
+			Volume: toFloat64(value(row, "volume")),
+			Value:  toFloat64(value(row, "value")),
+		}
+		if candle.Close > 0 {
+			result = append(result, candle)
+		}
+	}
+	return result, len(iss.Candles.Data), nil
+}
+
+// FrontFuture returns the FORTS contract of an asset (e.g. "BR" for Brent)
+// that expires first, skipping contracts within frontContractDays of expiry.
+func (c *Client) FrontFuture(ctx context.Context, asset string, now time.Time) (string, error) {
+	body, err := c.getISS(ctx, c.issURL+futuresSeriesPath)
+	if err != nil {
+		return "", fmt.Errorf("fetch futures series: %w", err)
+	}
+	return parseFrontFuture(body, asset, now)
+}
+
+func parseFrontFuture(body []byte, asset string, now time.Time) (string, error) {
+	var iss issSecuritiesResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return "", fmt.Errorf("parse ISS response: %w", err)
+	}
+	col := make(map[string]int, len(iss.Securities.Columns))
+	for i, name := range iss.Securities.Columns {
+		col[name] = i
+	}
+	field := func(row []interface{}, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		s, _ := row[i].(string)
+		return s
+	}
+
+	cutoff := now.AddDate(0, 0, frontContractDays)
+	var best string
+	var bestExpiry time.Time
+	for _, row := range iss.Securities.Data {
+		if field(row, "ASSETCODE") != asset {
+			continue
+		}
+		expiry, err := time.Parse("2006-01-02", field(row, "LASTTRADEDATE"))
+		if err != nil || expiry.Before(cutoff) {
+			continue
+		}
+		if best == "" || expiry.Before(bestExpiry) {
+			best, bestExpiry = field(row, "SECID"), expiry
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no active futures contract for %s", asset)
+	}
+	return best, nil
+}
+
+// getISS fetches an ISS URL and returns the body of a 200 response.
+func (c *Client) getISS(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("MOEX ISS returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,42 @@
+package moex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCandles(t *testing.T) {
+	body := []byte(`{"candles": {
+	"columns": ["open", "close", "high", "low", "value", "volume", "begin", "end"],
+	"data": [
+		[2700.5, 2750.1, 2760, 2690, 0, 0, "2024-07-08 00:00:00", "2024-07-08 23:59:59"],
+		[0, 0, 0, 0, 0, 0, "2024-07-09 00:00:00", "2024-07-09 23:59:59"]
+	]}}`)
+
+	got, err := parseCandles(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Close != 2750.1 || got[0].High != 2760 || !got[0].Begin.Equal(time.Date(2024, 7, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected candles %+v", got)
+	}
+}
+
+func TestParseFrontFuture_SkipsExpiringContracts(t *testing.T) {
+	body := []byte(`{"securities": {
+	"columns": ["SECID", "ASSETCODE", "LASTTRADEDATE"],
+	"data": [
+		["BRQ4", "BR", "2024-08-01"],
+		["BRN4", "BR", "2024-07-01"],
+		["BRU4", "BR", "2024-09-02"],
+		["SiU4", "Si", "2024-07-02"]
+	]}}`)
+
+	got, err := parseFrontFuture(body, "BR", time.Date(2024, 6, 29, 12, 0, 0, 0, time.UTC))
+	if err != nil || got != "BRQ4" {
+		t.Fatalf("got %q, %v; want BRQ4", got, err)
+	}
+	if _, err := parseFrontFuture(body, "GOLD", time.Now()); err == nil {
+		t.Fatal("expected an error for an unknown asset")
+	}
+}
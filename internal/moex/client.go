@@ -1,20 +1,99 @@
 package moex
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/camuig/rus-trader/internal/config"
 	"github.com/camuig/rus-trader/internal/logger"
 )
 
 type Client struct {
 	httpClient *http.Client
+	issURL     string // ISS root, e.g. https://iss.moex.com/iss
 	logger     *logger.Logger
+
+	mu         sync.Mutex
+	validators map[string]cachedBody // by URL, for conditional news requests
+}
+
+// cachedBody is the last response of a URL together with its validators.
+type cachedBody struct {
+	etag         string
+	lastModified string
+	body         []byte
 }
 
-func NewClient(log *logger.Logger) *Client {
+func NewClient(cfg config.MOEXConfig, log *logger.Logger) *Client {
+	issURL := strings.TrimRight(cfg.ISSURL, "/")
+	if issURL == "" {
+		issURL = config.DefaultISSURL
+	}
 	return &Client{
 		httpClient: &http.Client{Timeout: 30 * time.Second},
+		issURL:     issURL,
 		logger:     log,
+		validators: make(map[string]cachedBody),
+	}
+}
+
+// rebaseISS moves a URL under the default ISS root, such as the one of the
+// built-in MOEX news preset, to the configured root.
+func (c *Client) rebaseISS(u string) string {
+	if rest, ok := strings.CutPrefix(u, config.DefaultISSURL); ok {
+		return c.issURL + rest
+	}
+	return u
+}
+
+// getConditional fetches url with If-None-Match / If-Modified-Since when an
+// earlier response carried an ETag or Last-Modified, and returns the cached
+// body on 304 Not Modified.
+func (c *Client) getConditional(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	c.mu.Lock()
+	cached, ok := c.validators[url]
+	c.mu.Unlock()
+	if ok {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if ok && resp.StatusCode == http.StatusNotModified {
+		return cached.body, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	c.mu.Lock()
+	if etag != "" || lastModified != "" {
+		c.validators[url] = cachedBody{etag: etag, lastModified: lastModified, body: body}
+	} else {
+		delete(c.validators, url)
 	}
+	c.mu.Unlock()
+	return body, nil
 }
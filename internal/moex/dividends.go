@@ -0,0 +1,71 @@
+package moex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const dividendsPath = "/securities/%s/dividends.json?iss.meta=off"
+
+// Dividend is a dividend of a share as published by ISS.
+type Dividend struct {
+	Ticker     string
+	RecordDate time.Time // registry close date, as midnight UTC
+	Value      float64   // per share
+	Currency   string
+}
+
+type issDividendsResponse struct {
+	Dividends struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"dividends"`
+}
+
+// FetchDividends loads the declared and past dividends of a share from ISS.
+func (c *Client) FetchDividends(ctx context.Context, ticker string) ([]Dividend, error) {
+	body, err := c.getISS(ctx, c.issURL+fmt.Sprintf(dividendsPath, url.PathEscape(ticker)))
+	if err != nil {
+		return nil, fmt.Errorf("fetch dividends %s: %w", ticker, err)
+	}
+	return parseDividends(body)
+}
+
+func parseDividends(body []byte) ([]Dividend, error) {
+	var iss issDividendsResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return nil, fmt.Errorf("parse ISS response: %w", err)
+	}
+	col := make(map[string]int, len(iss.Dividends.Columns))
+	for i, name := range iss.Dividends.Columns {
+		col[name] = i
+	}
+	value := func(row []interface{}, name string) interface{} {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return nil
+		}
+		return row[i]
+	}
+
+	result := make([]Dividend, 0, len(iss.Dividends.Data))
+	for _, row := range iss.Dividends.Data {
+		ticker, _ := value(row, "secid").(string)
+		date, _ := value(row, "registryclosedate").(string)
+		recordDate, err := time.Parse("2006-01-02", date)
+		if ticker == "" || err != nil {
+			continue
+		}
+		currency, _ := value(row, "currencyid").(string)
+		result = append(result, Dividend{
+			Ticker:     ticker,
+			RecordDate: recordDate,
+			Value:      toFloat64(value(row, "value")),
+			Currency:   currency,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package moex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDividends(t *testing.T) {
+	body := []byte(`{"dividends": {
+	"columns": ["secid", "isin", "registryclosedate", "value", "currencyid"],
+	"data": [
+		["SBER", "RU0009029540", "2024-07-11", 33.3, "RUB"],
+		["SBER", "RU0009029540", null, 10, "RUB"]
+	]}}`)
+
+	got, err := parseDividends(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected rows without a record date to be skipped, got %+v", got)
+	}
+	want := Dividend{Ticker: "SBER", RecordDate: time.Date(2024, 7, 11, 0, 0, 0, 0, time.UTC), Value: 33.3, Currency: "RUB"}
+	if got[0] != want {
+		t.Errorf("got %+v, want %+v", got[0], want)
+	}
+}
@@ -2,27 +2,14 @@ package moex
 
 import (
 	"context"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"hash/fnv"
-	"io"
-	"net/http"
-	"sort"
 	"strings"
 	"time"
 )
 
-var worldNewsFeeds = []struct {
-	name string
-	url  string
-}{
-	{name: "BBC World", url: "https://feeds.bbci.co.uk/news/world/rss.xml"},
-	{name: "NYT World", url: "https://rss.nytimes.com/services/xml/rss/nyt/World.xml"},
-	{name: "WSJ World", url: "https://feeds.a.dj.com/rss/RSSWorldNews.xml"},
-	{name: "Guardian World", url: "https://www.theguardian.com/world/rss"},
-	{name: "Al Jazeera", url: "https://www.aljazeera.com/xml/rss/all.xml"},
-}
-
 type rssFeed struct {
 	Channel struct {
 		Items []rssItem `xml:"item"`
@@ -46,73 +33,14 @@ type atomEntry struct {
 	Published string `xml:"published"`
 }
 
-// FetchWorldNews returns compact world-market headlines for prompt context.
-func (c *Client) FetchWorldNews(ctx context.Context, maxItems int) ([]NewsItem, error) {
-	if maxItems <= 0 {
-		return nil, nil
-	}
-
-	cutoff := time.Now().Add(-24 * time.Hour)
-	collected := make([]NewsItem, 0, maxItems*2)
-	errs := make([]string, 0, len(worldNewsFeeds))
-
-	for _, feed := range worldNewsFeeds {
-		items, err := c.fetchFeed(ctx, feed.name, feed.url)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", feed.name, err))
-			continue
-		}
-		for _, item := range items {
-			if !item.Published.IsZero() && item.Published.Before(cutoff) {
-				continue
-			}
-			item.Title = compactSpaces(item.Title)
-			if item.Title == "" {
-				continue
-			}
-			item.ID = hashNewsID(item.Source, item.Title, item.Published)
-			collected = append(collected, item)
-		}
-	}
-
-	if len(collected) == 0 {
-		if len(errs) == 0 {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("world news unavailable: %s", strings.Join(errs, "; "))
-	}
-
-	unique := dedupeNewsByTitle(collected)
-	sort.Slice(unique, func(i, j int) bool {
-		return unique[i].Published.After(unique[j].Published)
-	})
-	if len(unique) > maxItems {
-		unique = unique[:maxItems]
-	}
-
-	return unique, nil
-}
-
-func (c *Client) fetchFeed(ctx context.Context, source, url string) ([]NewsItem, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
+// fetchFeed downloads a feed and parses it with parse (parseNewsFeed or parseJSONFeed).
+func (c *Client) fetchFeed(ctx context.Context, source, url string, parse func([]byte, string) ([]NewsItem, error)) ([]NewsItem, error) {
+	body, err := c.getConditional(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("fetch feed: %w", err)
 	}
-	body, err := io.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return nil, fmt.Errorf("read feed body: %w", err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("feed status %d", resp.StatusCode)
-	}
 
-	items, err := parseNewsFeed(body, source)
+	items, err := parse(body, source)
 	if err != nil {
 		return nil, fmt.Errorf("parse feed: %w", err)
 	}
@@ -151,6 +79,31 @@ func parseNewsFeed(body []byte, source string) ([]NewsItem, error) {
 	return nil, fmt.Errorf("unsupported feed format")
 }
 
+// jsonFeed is the JSON Feed format (https://jsonfeed.org).
+type jsonFeed struct {
+	Items []struct {
+		Title         string `json:"title"`
+		DatePublished string `json:"date_published"`
+		DateModified  string `json:"date_modified"`
+	} `json:"items"`
+}
+
+func parseJSONFeed(body []byte, source string) ([]NewsItem, error) {
+	var feed jsonFeed
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
+	items := make([]NewsItem, 0, len(feed.Items))
+	for _, it := range feed.Items {
+		items = append(items, NewsItem{
+			Source:    source,
+			Title:     it.Title,
+			Published: parseFeedTime(firstNonEmpty(it.DatePublished, it.DateModified)),
+		})
+	}
+	return items, nil
+}
+
 func parseFeedTime(value string) time.Time {
 	if value == "" {
 		return time.Time{}
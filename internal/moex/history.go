@@ -0,0 +1,208 @@
+package moex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	historyPath        = "/history/engines/stock/markets/shares/boards/%s/securities/%s.json?iss.meta=off&history.columns=TRADEDATE,OPEN,HIGH,LOW,CLOSE,VOLUME,VALUE&from=%s&till=%s&start=%d"
+	historyCandlesPath = "/engines/stock/markets/shares/securities/%s/candles.json?iss.meta=off&interval=%d&from=%s&till=%s&start=%d"
+	historyBoard       = "TQBR"
+)
+
+// Interval is an ISS candle interval.
+type Interval struct {
+	Name string // e.g. "1h"
+	Code int    // ISS interval parameter
+}
+
+// Intervals are the ISS candle intervals, shortest first.
+var Intervals = []Interval{
+	{Name: "1m", Code: 1},
+	{Name: "10m", Code: 10},
+	{Name: "1h", Code: 60},
+	{Name: "1d", Code: 24},
+	{Name: "1w", Code: 7},
+	{Name: "1mo", Code: 31},
+}
+
+// ParseInterval looks up an interval by name.
+func ParseInterval(name string) (Interval, bool) {
+	for _, iv := range Intervals {
+		if iv.Name == name {
+			return iv, true
+		}
+	}
+	return Interval{}, false
+}
+
+// History downloads long ranges of share bars from ISS page by page. Requests
+// of all downloads share one pace so that ISS does not throttle the client.
+type History struct {
+	client *Client
+	every  time.Duration // minimum gap between requests
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewHistory paces requests to requestsPerSecond; 0 means no limit.
+func NewHistory(c *Client, requestsPerSecond float64) *History {
+	h := &History{client: c}
+	if requestsPerSecond > 0 {
+		h.every = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return h
+}
+
+// Candles loads candles of a share for [from, till] and passes them to page
+// one ISS page at a time, oldest first. An error from page stops the download.
+func (h *History) Candles(ctx context.Context, ticker string, interval Interval, from, till time.Time, page func([]Candle) error) error {
+	for start := 0; ; {
+		u := h.client.issURL + fmt.Sprintf(historyCandlesPath, url.PathEscape(ticker), interval.Code,
+			from.Format("2006-01-02"), till.Format("2006-01-02"), start)
+		body, err := h.get(ctx, u)
+		if err != nil {
+			return fmt.Errorf("fetch candles %s: %w", ticker, err)
+		}
+		candles, rows, err := parseCandlesPage(body)
+		if err != nil {
+			return fmt.Errorf("candles %s: %w", ticker, err)
+		}
+		// ISS does not report the total for candles: an empty page is the end
+		if rows == 0 {
+			return nil
+		}
+		if len(candles) > 0 {
+			if err := page(candles); err != nil {
+				return err
+			}
+		}
+		start += rows
+	}
+}
+
+type issHistoryResponse struct {
+	History struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"history"`
+	Cursor struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"history.cursor"`
+}
+
+// Daily loads the end-of-day results of a share on TQBR for [from, till]
+// from the ISS history endpoint and passes them to page as daily candles.
+// Unlike Candles it carries the official turnover and skips days without
+// trades.
+func (h *History) Daily(ctx context.Context, ticker string, from, till time.Time, page func([]Candle) error) error {
+	for start := 0; ; {
+		u := h.client.issURL + fmt.Sprintf(historyPath, historyBoard, url.PathEscape(ticker),
+			from.Format("2006-01-02"), till.Format("2006-01-02"), start)
+		body, err := h.get(ctx, u)
+		if err != nil {
+			return fmt.Errorf("fetch history %s: %w", ticker, err)
+		}
+		candles, next, err := parseHistoryPage(body)
+		if err != nil {
+			return fmt.Errorf("history %s: %w", ticker, err)
+		}
+		if len(candles) > 0 {
+			if err := page(candles); err != nil {
+				return err
+			}
+		}
+		if next <= start {
+			return nil
+		}
+		start = next
+	}
+}
+
+// parseHistoryPage returns the bars of a history page and the start of the
+// next page, or 0 on the last page.
+func parseHistoryPage(body []byte) ([]Candle, int, error) {
+	var iss issHistoryResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return nil, 0, fmt.Errorf("parse ISS response: %w", err)
+	}
+	col := make(map[string]int, len(iss.History.Columns))
+	for i, name := range iss.History.Columns {
+		col[name] = i
+	}
+	value := func(row []interface{}, name string) interface{} {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return nil
+		}
+		return row[i]
+	}
+
+	result := make([]Candle, 0, len(iss.History.Data))
+	for _, row := range iss.History.Data {
+		date, _ := value(row, "TRADEDATE").(string)
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			continue
+		}
+		candle := Candle{
+			Begin:  t,
+			Open:   toFloat64(value(row, "OPEN")),
+			High:   toFloat64(value(row, "HIGH")),
+			Low:    toFloat64(value(row, "LOW")),
+			Close:  toFloat64(value(row, "CLOSE")),
+			Volume: toFloat64(value(row, "VOLUME")),
+			Value:  toFloat64(value(row, "VALUE")),
+		}
+		if candle.Close > 0 {
+			result = append(result, candle)
+		}
+	}
+
+	return result, nextPage(iss.Cursor.Data), nil
+}
+
+// nextPage reads an ISS cursor block (INDEX, TOTAL, PAGESIZE) and returns the
+// start of the next page, or 0 on the last page.
+func nextPage(cursor [][]interface{}) int {
+	if len(cursor) == 0 || len(cursor[0]) < 3 {
+		return 0
+	}
+	index, total, size := int(toFloat64(cursor[0][0])), int(toFloat64(cursor[0][1])), int(toFloat64(cursor[0][2]))
+	if size <= 0 || index+size >= total {
+		return 0
+	}
+	return index + size
+}
+
+// get waits for the pace and fetches an ISS URL.
+func (h *History) get(ctx context.Context, u string) ([]byte, error) {
+	if h.every > 0 {
+		h.mu.Lock()
+		now := time.Now()
+		at := h.next
+		if at.Before(now) {
+			at = now
+		}
+		h.next = at.Add(h.every)
+		h.mu.Unlock()
+
+		if wait := time.Until(at); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return h.client.getISS(ctx, u)
+}
@@ -0,0 +1,93 @@
+package moex
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/moex/moextest"
+)
+
+func TestHistory_DailyFollowsCursor(t *testing.T) {
+	c, srv := stubClient(t)
+	h := NewHistory(c, 0)
+
+	var bars []Candle
+	pages := 0
+	err := h.Daily(context.Background(), "SBER", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), moextest.RecordedAt, func(page []Candle) error {
+		pages++
+		bars = append(bars, page...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 2 || len(bars) != moextest.HistoryDays {
+		t.Fatalf("expected %d bars on 2 pages, got %d on %d", moextest.HistoryDays, len(bars), pages)
+	}
+	if bars[0].Value == 0 || !bars[0].Begin.Equal(time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected first bar %+v", bars[0])
+	}
+	for i := 1; i < len(bars); i++ {
+		if !bars[i].Begin.After(bars[i-1].Begin) {
+			t.Fatalf("bars out of order at %d", i)
+		}
+	}
+	req := srv.Requests()
+	if len(req) != 2 || !strings.Contains(req[1], "start=100") || !strings.Contains(req[0], "from=2024-01-01") {
+		t.Errorf("unexpected requests %v", req)
+	}
+}
+
+func TestHistory_CandlesPageUntilEmpty(t *testing.T) {
+	c, srv := stubClient(t)
+	h := NewHistory(c, 0)
+
+	n := 0
+	iv, _ := ParseInterval("1d")
+	err := h.Candles(context.Background(), "IMOEX", iv, moextest.RecordedAt.AddDate(0, -3, 0), moextest.RecordedAt, func(page []Candle) error {
+		n += len(page)
+		return nil
+	})
+	if err != nil || n != 45 {
+		t.Fatalf("expected 45 candles, got %d, %v", n, err)
+	}
+	req := srv.Requests()
+	if len(req) != 2 || !strings.Contains(req[0], "interval=24") || !strings.Contains(req[1], "start=45") {
+		t.Errorf("expected a second request past the page, got %v", req)
+	}
+}
+
+func TestHistory_PageErrorStopsDownload(t *testing.T) {
+	c, srv := stubClient(t)
+	stop := errors.New("disk full")
+	err := NewHistory(c, 0).Daily(context.Background(), "SBER", moextest.RecordedAt.AddDate(-1, 0, 0), moextest.RecordedAt, func([]Candle) error {
+		return stop
+	})
+	if !errors.Is(err, stop) || len(srv.Requests()) != 1 {
+		t.Errorf("expected the sink error after one page, got %v, %d requests", err, len(srv.Requests()))
+	}
+}
+
+func TestHistory_PacesRequests(t *testing.T) {
+	c, _ := stubClient(t)
+	h := NewHistory(c, 20) // one request per 50ms
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := h.get(context.Background(), c.issURL+"/sitenews.json"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests at 20/s took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := h.get(ctx, c.issURL+"/sitenews.json"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled wait, got %v", err)
+	}
+}
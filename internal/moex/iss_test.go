@@ -0,0 +1,200 @@
+package moex
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex/moextest"
+)
+
+func stubClient(t *testing.T) (*Client, *moextest.Server) {
+	t.Helper()
+	srv := moextest.NewServer()
+	t.Cleanup(srv.Close)
+	return NewClient(config.MOEXConfig{ISSURL: srv.ISSURL()}, logger.New("error")), srv
+}
+
+func TestFetchTopTickers_SkipsSuspendedTickers(t *testing.T) {
+	c, _ := stubClient(t)
+
+	tickers, err := c.FetchTopTickers(context.Background(), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != moextest.TopTickers {
+		t.Fatalf("expected %d tickers, got %d", moextest.TopTickers, len(tickers))
+	}
+	if tickers[0].Ticker != "SBER" || tickers[0].LastPrice != 318.45 || tickers[0].ValToday != 14826517390 {
+		t.Errorf("unexpected first ticker %+v", tickers[0])
+	}
+	for _, tk := range tickers {
+		if tk.Ticker == "POLY" || tk.Ticker == "FIVE" {
+			t.Errorf("suspended ticker %s (LAST=0 or null) must be skipped", tk.Ticker)
+		}
+	}
+
+	top, err := c.FetchTopTickers(context.Background(), 3)
+	if err != nil || len(top) != 3 {
+		t.Fatalf("expected the limit to apply, got %d tickers, %v", len(top), err)
+	}
+}
+
+func TestFetchISSNews_PagesUntilCutoff(t *testing.T) {
+	c, srv := stubClient(t)
+
+	items, err := c.fetchISSNews(context.Background(), srv.ISSURL()+"/sitenews.json?lang=ru", moextest.RecordedAt.Add(-newsWindow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != moextest.NewsWithin24h {
+		t.Fatalf("expected %d items within 24h, got %d", moextest.NewsWithin24h, len(items))
+	}
+	if items[0].ID != moextest.NewsArticleID || !items[0].HasText {
+		t.Errorf("unexpected first item %+v", items[0])
+	}
+	want := []string{"/iss/sitenews.json?lang=ru&start=0", "/iss/sitenews.json?lang=ru&start=50"}
+	if got := srv.Requests(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expected pages %v, got %v", want, got)
+	}
+}
+
+func TestFetchISSNews_StopsAfterShortPage(t *testing.T) {
+	c, srv := stubClient(t)
+
+	items, err := c.fetchISSNews(context.Background(), srv.ISSURL()+"/sitenews.json", moextest.RecordedAt.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 80 {
+		t.Fatalf("expected both pages, got %d items", len(items))
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("a page shorter than 50 rows is the last one, got %d requests", n)
+	}
+}
+
+func TestNewsFeeds_FetchAgainstStub(t *testing.T) {
+	srv := moextest.NewServer()
+	defer srv.Close()
+	srv.Now = time.Now
+
+	cfg := config.NewsConfig{Presets: []string{"moex"}, Sources: []config.NewsSource{
+		{Name: "Интерфакс", Type: "rss", URL: srv.FeedURL("interfax"), Scope: "market"},
+	}}
+	c := NewClient(config.MOEXConfig{ISSURL: srv.ISSURL()}, logger.New("error"))
+	h, err := NewNewsFeeds(c, cfg, logger.New("error")).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Tickers) != moextest.NewsWithin24h {
+		t.Errorf("expected the MOEX preset to be served by the stub, got %d ticker items", len(h.Tickers))
+	}
+	if len(h.Market) != moextest.FeedWithin24h {
+		t.Errorf("expected %d feed items within 24h, got %d", moextest.FeedWithin24h, len(h.Market))
+	}
+}
+
+func TestFetchNewsText_StripsMarkup(t *testing.T) {
+	c, _ := stubClient(t)
+
+	article, err := c.FetchNewsText(context.Background(), moextest.NewsArticleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(article.Title, "ПАО Сбербанк") || strings.Contains(article.Text, "<") || !strings.Contains(article.Text, "33,30 руб.") {
+		t.Errorf("unexpected article %+v", article)
+	}
+	if article.Published.IsZero() {
+		t.Error("expected the publication time")
+	}
+
+	if _, err := c.FetchNewsText(context.Background(), 1); err == nil {
+		t.Error("expected an error for unknown news")
+	}
+}
+
+func TestFrontFuture_FromStub(t *testing.T) {
+	c, _ := stubClient(t)
+
+	sec, err := c.FrontFuture(context.Background(), "BR", moextest.RecordedAt)
+	if err != nil || sec != "BRQ4" {
+		t.Fatalf("expected BRQ4 after BRN4 expired, got %q, %v", sec, err)
+	}
+	candles, err := c.FetchDailyCandles(context.Background(), "futures", "forts", sec, moextest.RecordedAt.AddDate(0, 0, -60))
+	if err != nil || len(candles) < 40 {
+		t.Fatalf("expected recorded candles, got %d, %v", len(candles), err)
+	}
+	if none, err := c.FetchDailyCandles(context.Background(), "stock", "index", "NOPE", moextest.RecordedAt); err != nil || len(none) != 0 {
+		t.Errorf("unknown security: expected no candles and no error, got %d, %v", len(none), err)
+	}
+}
+
+func TestClient_MalformedISSPayloads(t *testing.T) {
+	const (
+		securities = "/iss/engines/stock/markets/shares/boards/TQBR/securities.json"
+		sitenews   = "/iss/sitenews.json"
+		dividends  = "/iss/securities/SBER/dividends.json"
+		candles    = "/iss/engines/stock/markets/index/securities/IMOEX/candles.json"
+	)
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+		call   func(c *Client, srv *moextest.Server) error
+	}{
+		{"top tickers: data is not a table", securities, http.StatusOK, `{"marketdata":{"columns":["SECID"],"data":"oops"}}`,
+			func(c *Client, _ *moextest.Server) error { _, err := c.FetchTopTickers(ctx, 10); return err }},
+		{"top tickers: server error", securities, http.StatusInternalServerError, `ISS is down`,
+			func(c *Client, _ *moextest.Server) error { _, err := c.FetchTopTickers(ctx, 10); return err }},
+		{"news: HTML instead of JSON", sitenews, http.StatusOK, `<html><body>maintenance</body></html>`,
+			func(c *Client, srv *moextest.Server) error {
+				_, err := c.fetchISSNews(ctx, srv.ISSURL()+sitenews, moextest.RecordedAt.Add(-newsWindow))
+				return err
+			}},
+		{"news: renamed columns", sitenews, http.StatusOK, `{"sitenews":{"columns":["id","header","date"],"data":[[1,"x","2024-07-09 10:00:00"]]}}`,
+			func(c *Client, srv *moextest.Server) error {
+				_, err := c.fetchISSNews(ctx, srv.ISSURL()+sitenews, moextest.RecordedAt.Add(-newsWindow))
+				return err
+			}},
+		{"dividends: truncated JSON", dividends, http.StatusOK, `{"dividends":{"columns":["secid"`,
+			func(c *Client, _ *moextest.Server) error { _, err := c.FetchDividends(ctx, "SBER"); return err }},
+		{"candles: not found", candles, http.StatusNotFound, ``,
+			func(c *Client, _ *moextest.Server) error {
+				_, err := c.FetchDailyCandles(ctx, "stock", "index", "IMOEX", moextest.RecordedAt)
+				return err
+			}},
+		{"feed: not a feed", "/rss/interfax.xml", http.StatusOK, `<html><body>moved</body></html>`,
+			func(c *Client, srv *moextest.Server) error {
+				_, err := c.fetchFeed(ctx, "Интерфакс", srv.FeedURL("interfax"), parseNewsFeed)
+				return err
+			}},
+	}
+	for _, tt := range tests {
+		c, srv := stubClient(t)
+		srv.Respond(tt.path, tt.status, tt.body)
+		if err := tt.call(c, srv); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestFetchTopTickers_SkipsShortRows(t *testing.T) {
+	c, srv := stubClient(t)
+	srv.Respond("/iss/engines/stock/markets/shares/boards/TQBR/securities.json", http.StatusOK,
+		`{"marketdata":{"columns":["SECID","VALTODAY","LAST"],"data":[["SBER"],["GAZP",100,"n/a"],["LKOH",200,7100.5]]}}`)
+
+	tickers, err := c.FetchTopTickers(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 1 || tickers[0].Ticker != "LKOH" {
+		t.Errorf("expected only LKOH, got %+v", tickers)
+	}
+}
@@ -0,0 +1,228 @@
+package moex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	dayResultsPath   = "/history/engines/stock/markets/shares/boards/TQBR/securities.json?iss.meta=off&history.columns=SECID,TRADEDATE,CLOSE,VALUE,NUMTRADES&date=%s&start=%d"
+	quotesPath       = "/engines/stock/markets/shares/boards/TQBR/securities.json?iss.meta=off&iss.only=securities,marketdata&securities.columns=SECID,LOTSIZE,PREVPRICE&marketdata.columns=SECID,BID,OFFER,LAST"
+	indexMembersPath = "/statistics/engines/stock/markets/index/analytics/%s.json?iss.meta=off&iss.only=analytics,analytics.cursor&analytics.columns=secids&limit=100&start=%d"
+	maxPages         = 20 // guards against a cursor that never ends
+)
+
+// DayResult is the end-of-day result of a TQBR share.
+type DayResult struct {
+	Ticker    string
+	Date      time.Time // trading day, as midnight UTC
+	Close     float64
+	Value     float64 // turnover, RUB
+	NumTrades int
+}
+
+// FetchDayResults loads the results of all TQBR shares on one day, every
+// page of them. A day without trading has no results.
+func (c *Client) FetchDayResults(ctx context.Context, date time.Time) ([]DayResult, error) {
+	var all []DayResult
+	for start, page := 0, 0; page < maxPages; page++ {
+		body, err := c.getISS(ctx, c.issURL+fmt.Sprintf(dayResultsPath, date.Format("2006-01-02"), start))
+		if err != nil {
+			return nil, fmt.Errorf("fetch day results %s: %w", date.Format("2006-01-02"), err)
+		}
+		results, next, err := parseDayResults(body)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, results...)
+		if next == 0 {
+			break
+		}
+		start = next
+	}
+	return all, nil
+}
+
+func parseDayResults(body []byte) ([]DayResult, int, error) {
+	var iss issHistoryResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return nil, 0, fmt.Errorf("parse ISS response: %w", err)
+	}
+	col := make(map[string]int, len(iss.History.Columns))
+	for i, name := range iss.History.Columns {
+		col[name] = i
+	}
+	value := func(row []interface{}, name string) interface{} {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return nil
+		}
+		return row[i]
+	}
+
+	results := make([]DayResult, 0, len(iss.History.Data))
+	for _, row := range iss.History.Data {
+		ticker, _ := value(row, "SECID").(string)
+		date, _ := value(row, "TRADEDATE").(string)
+		t, err := time.Parse("2006-01-02", date)
+		if ticker == "" || err != nil {
+			continue
+		}
+		results = append(results, DayResult{
+			Ticker:    ticker,
+			Date:      t,
+			Close:     toFloat64(value(row, "CLOSE")),
+			Value:     toFloat64(value(row, "VALUE")),
+			NumTrades: int(toFloat64(value(row, "NUMTRADES"))),
+		})
+	}
+	return results, nextPage(iss.Cursor.Data), nil
+}
+
+// Quote is the current order book top and lot size of a TQBR share.
+type Quote struct {
+	Ticker    string
+	LotSize   int
+	Bid       float64
+	Offer     float64
+	Last      float64 // 0 before the first trade of the day
+	PrevPrice float64 // last price of the previous day
+}
+
+// SpreadPct returns the bid/offer spread in % of the mid price, 0 without
+// both sides.
+func (q Quote) SpreadPct() float64 {
+	if q.Bid <= 0 || q.Offer <= 0 || q.Offer < q.Bid {
+		return 0
+	}
+	mid := (q.Bid + q.Offer) / 2
+	return (q.Offer - q.Bid) / mid * 100
+}
+
+// Price returns the last price, or the previous day's one before the first trade.
+func (q Quote) Price() float64 {
+	if q.Last > 0 {
+		return q.Last
+	}
+	return q.PrevPrice
+}
+
+type issQuotesResponse struct {
+	issSecuritiesResponse
+	Marketdata struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"marketdata"`
+}
+
+// FetchQuotes loads the lot sizes and current quotes of all TQBR shares.
+func (c *Client) FetchQuotes(ctx context.Context) ([]Quote, error) {
+	body, err := c.getISS(ctx, c.issURL+quotesPath)
+	if err != nil {
+		return nil, fmt.Errorf("fetch quotes: %w", err)
+	}
+	return parseQuotes(body)
+}
+
+func parseQuotes(body []byte) ([]Quote, error) {
+	var iss issQuotesResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return nil, fmt.Errorf("parse ISS response: %w", err)
+	}
+	index := func(columns []string) map[string]int {
+		col := make(map[string]int, len(columns))
+		for i, name := range columns {
+			col[name] = i
+		}
+		return col
+	}
+	value := func(col map[string]int, row []interface{}, name string) interface{} {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return nil
+		}
+		return row[i]
+	}
+
+	secCol := index(iss.Securities.Columns)
+	var quotes []Quote
+	byTicker := make(map[string]int)
+	for _, row := range iss.Securities.Data {
+		ticker, _ := value(secCol, row, "SECID").(string)
+		if ticker == "" {
+			continue
+		}
+		byTicker[ticker] = len(quotes)
+		quotes = append(quotes, Quote{
+			Ticker:    ticker,
+			LotSize:   int(toFloat64(value(secCol, row, "LOTSIZE"))),
+			PrevPrice: toFloat64(value(secCol, row, "PREVPRICE")),
+		})
+	}
+	mdCol := index(iss.Marketdata.Columns)
+	for _, row := range iss.Marketdata.Data {
+		ticker, _ := value(mdCol, row, "SECID").(string)
+		i, ok := byTicker[ticker]
+		if !ok {
+			continue
+		}
+		quotes[i].Bid = toFloat64(value(mdCol, row, "BID"))
+		quotes[i].Offer = toFloat64(value(mdCol, row, "OFFER"))
+		quotes[i].Last = toFloat64(value(mdCol, row, "LAST"))
+	}
+	return quotes, nil
+}
+
+type issAnalyticsResponse struct {
+	Analytics struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"analytics"`
+	Cursor struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"analytics.cursor"`
+}
+
+// FetchIndexMembers loads the tickers in an index, e.g. IMOEX or MOEXBMI.
+func (c *Client) FetchIndexMembers(ctx context.Context, index string) ([]string, error) {
+	var members []string
+	for start, page := 0, 0; page < maxPages; page++ {
+		body, err := c.getISS(ctx, c.issURL+fmt.Sprintf(indexMembersPath, url.PathEscape(index), start))
+		if err != nil {
+			return nil, fmt.Errorf("fetch index %s: %w", index, err)
+		}
+		var iss issAnalyticsResponse
+		if err := json.Unmarshal(body, &iss); err != nil {
+			return nil, fmt.Errorf("parse index %s: %w", index, err)
+		}
+		col := -1
+		for i, name := range iss.Analytics.Columns {
+			if name == "secids" {
+				col = i
+			}
+		}
+		if col < 0 {
+			return nil, fmt.Errorf("index %s: unexpected columns %v", index, iss.Analytics.Columns)
+		}
+		for _, row := range iss.Analytics.Data {
+			if col < len(row) {
+				if ticker, _ := row[col].(string); ticker != "" {
+					members = append(members, ticker)
+				}
+			}
+		}
+		next := nextPage(iss.Cursor.Data)
+		if next == 0 {
+			break
+		}
+		start = next
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("index %s has no members", index)
+	}
+	return members, nil
+}
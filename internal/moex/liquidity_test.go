@@ -0,0 +1,78 @@
+package moex
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/moex/moextest"
+)
+
+func TestFetchDayResults_ReadsAllPages(t *testing.T) {
+	c, srv := stubClient(t)
+
+	monday := time.Date(2024, 7, 8, 0, 0, 0, 0, time.UTC)
+	results, err := c.FetchDayResults(context.Background(), monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != moextest.DayResults || len(srv.Requests()) != 2 {
+		t.Fatalf("expected %d results on 2 pages, got %d on %d", moextest.DayResults, len(results), len(srv.Requests()))
+	}
+	if r := results[0]; r.Ticker != "SBER" || r.Close != 318.45 || r.Value <= 0 || r.NumTrades <= 0 || !r.Date.Equal(monday) {
+		t.Errorf("unexpected first result %+v", r)
+	}
+
+	weekend, err := c.FetchDayResults(context.Background(), monday.AddDate(0, 0, -1))
+	if err != nil || len(weekend) != 0 {
+		t.Errorf("expected no results on Sunday, got %d, %v", len(weekend), err)
+	}
+}
+
+func TestFetchQuotes_JoinsLotSizesAndQuotes(t *testing.T) {
+	c, _ := stubClient(t)
+
+	quotes, err := c.FetchQuotes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byTicker := make(map[string]Quote, len(quotes))
+	for _, q := range quotes {
+		byTicker[q.Ticker] = q
+	}
+	sber := byTicker["SBER"]
+	if sber.LotSize != 10 || sber.Price() != 318.45 || sber.SpreadPct() <= 0 || sber.SpreadPct() > 0.05 {
+		t.Errorf("unexpected SBER quote %+v, spread %.3f%%", sber, sber.SpreadPct())
+	}
+	if q := byTicker["KLVZ"]; q.Last != 0 || q.Price() != q.PrevPrice {
+		t.Errorf("expected the previous price before the first trade, got %+v", q)
+	}
+}
+
+func TestQuote_SpreadPct(t *testing.T) {
+	if got := (Quote{Bid: 99, Offer: 101}).SpreadPct(); math.Abs(got-2) > 1e-9 {
+		t.Errorf("got %.4f, want 2", got)
+	}
+	if got := (Quote{Bid: 0, Offer: 101}).SpreadPct(); got != 0 {
+		t.Errorf("one-sided book: got %.4f, want 0", got)
+	}
+}
+
+func TestFetchIndexMembers(t *testing.T) {
+	c, srv := stubClient(t)
+
+	members, err := c.FetchIndexMembers(context.Background(), "IMOEX")
+	if err != nil || len(members) != moextest.IMOEXMembers || members[0] != "SBER" {
+		t.Fatalf("got %d members %v, %v", len(members), members, err)
+	}
+	if _, err := c.FetchIndexMembers(context.Background(), "NOPE"); err == nil {
+		t.Error("expected an error for an unknown index")
+	}
+
+	srv.Respond("/iss/statistics/engines/stock/markets/index/analytics/IMOEX.json", http.StatusOK, `{"analytics":{"columns":["indexid","weight"],"data":[["IMOEX",1]]}}`)
+	if _, err := c.FetchIndexMembers(context.Background(), "IMOEX"); err == nil {
+		t.Error("expected an error without the secids column")
+	}
+}
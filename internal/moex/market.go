@@ -8,7 +8,7 @@ import (
 	"net/http"
 )
 
-const topTickersURL = "https://iss.moex.com/iss/engines/stock/markets/shares/boards/TQBR/securities.json?iss.meta=off&iss.only=marketdata&marketdata.columns=SECID,VALTODAY,LAST&sort_column=VALTODAY&sort_order=desc"
+const topTickersPath = "/engines/stock/markets/shares/boards/TQBR/securities.json?iss.meta=off&iss.only=marketdata&marketdata.columns=SECID,VALTODAY,LAST&sort_column=VALTODAY&sort_order=desc"
 
 type issResponse struct {
 	Marketdata struct {
@@ -18,7 +18,7 @@ type issResponse struct {
 }
 
 func (c *Client) FetchTopTickers(ctx context.Context, limit int) ([]MarketTicker, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, topTickersURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.issURL+topTickersPath, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -0,0 +1,244 @@
+// Package moextest serves recorded MOEX ISS responses and an RSS feed so that
+// the moex client, and the whole bot with moex.iss_url pointed at it, can run
+// without iss.moex.com.
+package moextest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// RecordedAt is the time the fixtures were recorded at. ISS times are Moscow
+// wall clock without a zone and are read by the client as UTC, so RecordedAt
+// is expressed the same way.
+var RecordedAt = time.Date(2024, 7, 9, 12, 0, 0, 0, time.UTC)
+
+// Fixture contents relied on by tests.
+const (
+	TopTickers     = 13 // marketdata rows with a price; POLY and FIVE are suspended
+	NewsWithin24h  = 51 // sitenews items within 24h of RecordedAt: page 0 and one of page 50
+	FeedWithin24h  = 5  // rss_interfax.xml items within 24h of RecordedAt
+	NewsArticleID  = 72400
+	DividendTicker = "SBER"
+	HistoryDays    = 128 // SBER trading days on pages 0 and 100 of history, two halts skipped
+	DayResults     = 119 // TQBR shares in the results of a trading day, KLVZ without trades
+	IMOEXMembers   = 38
+)
+
+var (
+	issTimeRegex     = regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`)
+	issDateRegex     = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}"`)
+	rssTimeRegex     = regexp.MustCompile(`<pubDate>([^<]+)</pubDate>`)
+	newsContentRegex = regexp.MustCompile(`^/iss/sitenews/(\d+)\.json$`)
+	dividendsRegex   = regexp.MustCompile(`^/iss/securities/([^/]+)/dividends\.json$`)
+	indexRegex       = regexp.MustCompile(`^/iss/statistics/engines/stock/markets/index/analytics/([^/]+)\.json$`)
+	historyRegex     = regexp.MustCompile(`^/iss/history/engines/stock/markets/shares/boards/[^/]+/securities/([^/]+)\.json$`)
+	candlesRegex     = regexp.MustCompile(`^/iss/engines/[^/]+/markets/[^/]+/securities/([^/]+)/candles\.json$`)
+	feedRegex        = regexp.MustCompile(`^/rss/([a-z0-9_]+)\.xml$`)
+)
+
+// recordedDay is the trading day of the dayresults fixtures. They are served
+// for any weekday with the date replaced.
+const recordedDay = "2024-07-08"
+
+// empty are the ISS answers for a security without a fixture: ISS replies
+// 200 with no rows rather than 404.
+var empty = map[string]string{
+	"dividends": `{"dividends":{"columns":["secid","isin","registryclosedate","value","currencyid"],"data":[]}}`,
+	"candles":   `{"candles":{"columns":["open","close","high","low","value","volume","begin","end"],"data":[]}}`,
+	"sitenews":  `{"sitenews":{"columns":["id","tag","title","published_at","modified_at"],"data":[]}}`,
+	"content":   `{"content":{"columns":["id","title","body","published_at"],"data":[]}}`,
+	"history":   `{"history":{"columns":["TRADEDATE","OPEN","HIGH","LOW","CLOSE","VOLUME","VALUE"],"data":[]},"history.cursor":{"columns":["INDEX","TOTAL","PAGESIZE"],"data":[[0,0,100]]}}`,
+}
+
+type response struct {
+	status int
+	body   string
+}
+
+// Handler answers ISS and feed requests from the fixtures. Set Now to move
+// news and feed times and futures expiries so that RecordedAt maps to Now();
+// fixtures are served verbatim otherwise.
+type Handler struct {
+	Now func() time.Time
+
+	mu        sync.Mutex
+	overrides map[string]response
+	requests  []string
+}
+
+func NewHandler() *Handler {
+	return &Handler{overrides: make(map[string]response)}
+}
+
+// Respond replaces the answer for a URL path, e.g. with a malformed payload.
+func (h *Handler) Respond(path string, status int, body string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.overrides[path] = response{status: status, body: body}
+}
+
+// Requests returns the request URIs served so far.
+func (h *Handler) Requests() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.requests...)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests = append(h.requests, r.URL.RequestURI())
+	override, ok := h.overrides[r.URL.Path]
+	h.mu.Unlock()
+	if ok {
+		w.WriteHeader(override.status)
+		fmt.Fprint(w, override.body)
+		return
+	}
+
+	name, fallback := route(r)
+	body, err := fixtures.ReadFile("fixtures/" + name)
+	switch {
+	case err == nil:
+	case fallback != "":
+		body = []byte(fallback)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if strings.HasSuffix(name, ".xml") {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	if strings.HasPrefix(name, "dayresults_") {
+		body = bytes.ReplaceAll(body, []byte(`"`+recordedDay+`"`), []byte(`"`+r.URL.Query().Get("date")+`"`))
+	}
+	if h.Now != nil {
+		d := h.Now().Sub(RecordedAt)
+		switch {
+		case strings.HasPrefix(name, "sitenews_"), strings.HasPrefix(name, "news_"), strings.HasPrefix(name, "rss_"):
+			body = shift(body, d)
+		case name == "futures.json":
+			body = shiftDates(body, d)
+		}
+	}
+	w.Write(body)
+}
+
+// route maps a request to its fixture and to the answer used when the
+// fixture does not exist ("" for 404).
+func route(r *http.Request) (name, fallback string) {
+	path, query := r.URL.Path, r.URL.Query()
+	start := query.Get("start")
+	if start == "" {
+		start = "0"
+	}
+	switch path {
+	case "/iss/engines/stock/markets/shares/boards/TQBR/securities.json":
+		switch query.Get("iss.only") {
+		case "marketdata":
+			return "marketdata.json", ""
+		case "securities,marketdata":
+			return "quotes.json", ""
+		}
+		return "securities.json", ""
+	case "/iss/history/engines/stock/markets/shares/boards/TQBR/securities.json":
+		day, err := time.Parse(time.DateOnly, query.Get("date"))
+		if err != nil || day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			return "", empty["history"]
+		}
+		return "dayresults_" + start + ".json", empty["history"]
+	case "/iss/engines/futures/markets/forts/securities.json":
+		return "futures.json", ""
+	case "/iss/sitenews.json":
+		return "sitenews_" + start + ".json", empty["sitenews"]
+	}
+	if m := newsContentRegex.FindStringSubmatch(path); m != nil {
+		return "news_" + m[1] + ".json", empty["content"]
+	}
+	if m := dividendsRegex.FindStringSubmatch(path); m != nil {
+		return "dividends_" + m[1] + ".json", empty["dividends"]
+	}
+	if m := indexRegex.FindStringSubmatch(path); m != nil {
+		return "index_" + m[1] + ".json", ""
+	}
+	if m := historyRegex.FindStringSubmatch(path); m != nil {
+		return "history_" + m[1] + "_" + start + ".json", empty["history"]
+	}
+	if m := candlesRegex.FindStringSubmatch(path); m != nil {
+		// a fixture is one page: later pages are empty, which ends paging
+		if start != "0" {
+			return "", empty["candles"]
+		}
+		return "candles_" + m[1] + ".json", empty["candles"]
+	}
+	if m := feedRegex.FindStringSubmatch(path); m != nil {
+		return "rss_" + m[1] + ".xml", ""
+	}
+	return "", ""
+}
+
+// shift moves the ISS and RSS times of a fixture by d.
+func shift(body []byte, d time.Duration) []byte {
+	body = issTimeRegex.ReplaceAllFunc(body, func(b []byte) []byte {
+		t, err := time.Parse(time.DateTime, string(b))
+		if err != nil {
+			return b
+		}
+		return []byte(t.Add(d).Format(time.DateTime))
+	})
+	return rssTimeRegex.ReplaceAllFunc(body, func(b []byte) []byte {
+		value := rssTimeRegex.FindSubmatch(b)[1]
+		t, err := time.Parse(time.RFC1123Z, string(value))
+		if err != nil {
+			return b
+		}
+		return []byte("<pubDate>" + t.Add(d).Format(time.RFC1123Z) + "</pubDate>")
+	})
+}
+
+// shiftDates moves the quoted ISS dates of a fixture by whole days of d.
+func shiftDates(body []byte, d time.Duration) []byte {
+	days := int(d / (24 * time.Hour))
+	return issDateRegex.ReplaceAllFunc(body, func(b []byte) []byte {
+		t, err := time.Parse(`"`+time.DateOnly+`"`, string(b))
+		if err != nil {
+			return b
+		}
+		return []byte(t.AddDate(0, 0, days).Format(`"` + time.DateOnly + `"`))
+	})
+}
+
+// Server is a started Handler. Point the client at ISSURL and feeds at FeedURL.
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a stub server; Close it when done.
+func NewServer() *Server {
+	h := NewHandler()
+	return &Server{Server: httptest.NewServer(h), Handler: h}
+}
+
+// ISSURL is the value for moex.iss_url.
+func (s *Server) ISSURL() string {
+	return s.URL + "/iss"
+}
+
+// FeedURL is the address of a recorded feed, e.g. "interfax".
+func (s *Server) FeedURL(name string) string {
+	return s.URL + "/rss/" + name + ".xml"
+}
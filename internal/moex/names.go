@@ -0,0 +1,436 @@
+package moex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+)
+
+const securityNamesPath = "/engines/stock/markets/shares/boards/TQBR/securities.json?iss.meta=off&iss.only=securities&securities.columns=SECID,SHORTNAME,SECNAME,LATNAME"
+
+// builtinNames are colloquial names that exchange listings do not contain.
+// They are used together with the directory and alone until it is loaded.
+var builtinNames = map[string][]string{
+	"SBER": {"Сбербанк", "Сбер"},
+	"GAZP": {"Газпром"},
+	"LKOH": {"Лукойл"},
+	"GMKN": {"Норникель", "Норильский никель"},
+	"NVTK": {"Новатэк"},
+	"ROSN": {"Роснефть"},
+	"YDEX": {"Яндекс"},
+	"T":    {"Т-Банк", "Т-Технологии", "Тинькофф", "ТКС Холдинг"},
+	"MTSS": {"МТС"},
+	"MGNT": {"Магнит"},
+	"PLZL": {"Полюс"},
+	"CHMF": {"Северсталь"},
+	"ALRS": {"Алроса"},
+	"SNGS": {"Сургутнефтегаз"},
+	"VTBR": {"ВТБ"},
+	"MOEX": {"Мосбиржа", "Московская биржа"},
+	"TATN": {"Татнефть"},
+	"NLMK": {"НЛМК"},
+	"PHOR": {"ФосАгро"},
+	"IRAO": {"Интер РАО"},
+}
+
+// SecurityName is one listing with the names it is known by.
+type SecurityName struct {
+	Ticker     string `json:"ticker"`
+	ShortName  string `json:"short_name"`  // ISS SHORTNAME, e.g. "Сбербанк"
+	SecName    string `json:"sec_name"`    // ISS SECNAME, e.g. "Сбербанк России ПАО ао"
+	LatName    string `json:"lat_name"`    // ISS LATNAME, e.g. "Sberbank"
+	BrokerName string `json:"broker_name"` // T-Invest instrument name, e.g. "Сбер Банк"
+}
+
+type issSecuritiesResponse struct {
+	Securities struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"securities"`
+}
+
+// FetchSecurityNames loads the names of all TQBR shares from ISS.
+func (c *Client) FetchSecurityNames(ctx context.Context) ([]SecurityName, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.issURL+securityNamesPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch security names: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("MOEX ISS returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	var iss issSecuritiesResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return nil, fmt.Errorf("parse ISS response: %w", err)
+	}
+	col := make(map[string]int, len(iss.Securities.Columns))
+	for i, name := range iss.Securities.Columns {
+		col[name] = i
+	}
+	field := func(row []interface{}, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		s, _ := row[i].(string)
+		return s
+	}
+
+	result := make([]SecurityName, 0, len(iss.Securities.Data))
+	for _, row := range iss.Securities.Data {
+		sec := SecurityName{
+			Ticker:    field(row, "SECID"),
+			ShortName: field(row, "SHORTNAME"),
+			SecName:   field(row, "SECNAME"),
+			LatName:   field(row, "LATNAME"),
+		}
+		if sec.Ticker != "" {
+			result = append(result, sec)
+		}
+	}
+	return result, nil
+}
+
+// NameDirectory maps tickers to the company names used to match news. Names
+// come from ISS listings, T-Invest instruments, built-in colloquial names and
+// config aliases; every name is expanded into its Russian case forms.
+type NameDirectory struct {
+	config config.NewsConfig
+	logger *logger.Logger
+
+	mu        sync.RWMutex
+	terms     map[string][]string // ticker -> normalised phrases
+	listings  []SecurityName
+	updated   time.Time // last successful refresh, zero when only built-in names are known
+	attempted time.Time // last refresh attempt
+}
+
+// namesRetryDelay spaces refresh attempts after a failure.
+const namesRetryDelay = time.Hour
+
+// nameCache is the on-disk copy of the listings, so restarts do not depend on ISS.
+type nameCache struct {
+	Updated    time.Time      `json:"updated"`
+	Securities []SecurityName `json:"securities"`
+}
+
+// NewNameDirectory builds a directory from the local cache when there is one,
+// otherwise from built-in names only until Refresh succeeds.
+func NewNameDirectory(cfg config.NewsConfig, log *logger.Logger) *NameDirectory {
+	d := &NameDirectory{config: cfg, logger: log}
+	data, err := os.ReadFile(cfg.NamesCache)
+	if err == nil {
+		var cache nameCache
+		if err := json.Unmarshal(data, &cache); err != nil {
+			log.Warn("ignoring corrupt ticker name cache", "path", cfg.NamesCache, "error", err)
+		} else {
+			d.listings, d.updated = cache.Securities, cache.Updated
+		}
+	} else if !os.IsNotExist(err) {
+		log.Warn("read ticker name cache", "path", cfg.NamesCache, "error", err)
+	}
+	d.terms = buildTerms(d.listings, cfg.Aliases, cfg.IgnoreNames)
+	return d
+}
+
+// Stale reports whether the listings are older than the refresh interval and
+// no attempt was made within the retry delay.
+func (d *NameDirectory) Stale() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return time.Since(d.updated) >= time.Duration(d.config.NamesRefreshHours)*time.Hour &&
+		time.Since(d.attempted) >= namesRetryDelay
+}
+
+// Refresh reloads listings from ISS, adds broker instrument names (ticker ->
+// name, may be nil) and saves the cache. On error the previous names stay in use.
+func (d *NameDirectory) Refresh(ctx context.Context, c *Client, brokerNames map[string]string) error {
+	d.mu.Lock()
+	d.attempted = time.Now()
+	d.mu.Unlock()
+
+	listings, err := c.FetchSecurityNames(ctx)
+	if err != nil {
+		return err
+	}
+	if len(listings) == 0 {
+		return fmt.Errorf("ISS returned no securities")
+	}
+	for i := range listings {
+		listings[i].BrokerName = brokerNames[listings[i].Ticker]
+	}
+	terms := buildTerms(listings, d.config.Aliases, d.config.IgnoreNames)
+	now := time.Now()
+
+	d.mu.Lock()
+	d.listings, d.terms, d.updated = listings, terms, now
+	d.mu.Unlock()
+
+	if err := writeNameCache(d.config.NamesCache, nameCache{Updated: now, Securities: listings}); err != nil {
+		d.logger.Warn("save ticker name cache", "path", d.config.NamesCache, "error", err)
+	}
+	d.logger.Info("ticker names refreshed", "securities", len(listings), "broker_names", len(brokerNames))
+	return nil
+}
+
+func writeNameCache(path string, cache nameCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Terms returns the normalised phrases matched for a ticker.
+func (d *NameDirectory) Terms(ticker string) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.terms[ticker]
+}
+
+// FilterNews returns news items grouped by ticker, matching whole words of
+// the title against the ticker symbol and company names in any case form.
+func (d *NameDirectory) FilterNews(news []NewsItem, tickers []string) map[string][]NewsItem {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	titles := make([]string, len(news))
+	for i, item := range news {
+		titles[i] = normalizeText(item.Title)
+	}
+	result := make(map[string][]NewsItem)
+	for _, ticker := range tickers {
+		terms := d.terms[ticker]
+		if utf8.RuneCountInString(ticker) >= 2 {
+			terms = append([]string{normalizeText(ticker)}, terms...)
+		}
+		for i, item := range news {
+			for _, term := range terms {
+				if strings.Contains(titles[i], term) {
+					result[ticker] = append(result[ticker], item)
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+// buildTerms expands the names of every ticker into padded normalised phrases.
+func buildTerms(listings []SecurityName, aliases map[string][]string, ignore []string) map[string][]string {
+	names := make(map[string][]string)
+	for ticker, list := range builtinNames {
+		names[ticker] = append(names[ticker], list...)
+	}
+	for _, sec := range listings {
+		for _, raw := range []string{sec.ShortName, sec.SecName, sec.LatName, sec.BrokerName} {
+			if name := cleanCompanyName(raw); name != "" {
+				names[sec.Ticker] = append(names[sec.Ticker], name)
+			}
+		}
+	}
+	for ticker, list := range aliases {
+		names[ticker] = append(names[ticker], list...)
+	}
+
+	ignored := make(map[string]bool, len(ignore))
+	for _, name := range ignore {
+		ignored[normalizeText(name)] = true
+	}
+
+	terms := make(map[string][]string, len(names))
+	for ticker, list := range names {
+		seen := make(map[string]bool)
+		for _, name := range list {
+			if ignored[normalizeText(name)] {
+				continue
+			}
+			for _, v := range nameVariants(name) {
+				if !seen[v] {
+					seen[v] = true
+					terms[ticker] = append(terms[ticker], v)
+				}
+			}
+		}
+		sort.Strings(terms[ticker])
+	}
+	return terms
+}
+
+var (
+	parenthesized = regexp.MustCompile(`\([^)]*\)`)
+	quoteReplacer = strings.NewReplacer(`"`, " ", "«", " ", "»", " ", "“", " ", "”", " ", "'", " ")
+	// Legal forms and share classes in listing names, upper case.
+	legalWords = map[string]bool{
+		"ПАО": true, "ОАО": true, "АО": true, "ЗАО": true, "МКПАО": true, "МКАО": true, "НК": true, "ГК": true,
+		"АК": true, "ПАО-АО": true, "АО-АО": true, "АП": true, "АО1": true, "АП1": true, "АО2": true, "АП2": true,
+		"PJSC": true, "PAO": true, "OJSC": true, "JSC": true, "MKPAO": true, "IPJSC": true, "PLC": true,
+		"LTD": true, "INC": true, "ORD": true, "SHS": true, "ДР": true, "ГДР": true,
+	}
+)
+
+// maxNameWords drops long descriptive names that would never appear verbatim in a title.
+const maxNameWords = 3
+
+// cleanCompanyName strips quotes, legal forms and share class markers from a
+// listing name: `"Газпром" (ПАО) ао` -> "Газпром", "Сбербанк-п" -> "Сбербанк".
+func cleanCompanyName(s string) string {
+	s = parenthesized.ReplaceAllString(quoteReplacer.Replace(s), " ")
+	words := strings.Fields(s)
+	out := words[:0]
+	for _, w := range words {
+		up := strings.ToUpper(w)
+		if legalWords[up] || strings.HasPrefix(up, "АО-") || strings.HasPrefix(up, "АП-") {
+			continue
+		}
+		w = strings.TrimSuffix(strings.TrimSuffix(w, "-п"), "-ао")
+		out = append(out, w)
+	}
+	if len(out) == 0 || len(out) > maxNameWords {
+		return ""
+	}
+	name := strings.Join(out, " ")
+	if utf8.RuneCountInString(name) < 3 {
+		return ""
+	}
+	return name
+}
+
+// maxVariants caps the case forms of one multi-word name.
+const maxVariants = 64
+
+// nameVariants returns the padded normalised phrases of a name in its Russian
+// case forms: "Норильский никель" also yields "НОРИЛЬСКОГО НИКЕЛЯ".
+func nameVariants(name string) []string {
+	words := strings.Fields(normalizeText(name))
+	if len(words) == 0 {
+		return nil
+	}
+	originals := strings.Fields(strings.NewReplacer("-", " ").Replace(name))
+
+	variants := []string{""}
+	for i, w := range words {
+		forms := []string{w}
+		// Short all-caps words are acronyms (ВТБ, НЛМК) and do not decline.
+		acronym := i < len(originals) && originals[i] == strings.ToUpper(originals[i]) && utf8.RuneCountInString(w) <= 4
+		if !acronym {
+			forms = wordForms(w)
+		}
+		next := make([]string, 0, len(variants)*len(forms))
+		for _, v := range variants {
+			for _, f := range forms {
+				if len(next) < maxVariants {
+					next = append(next, strings.TrimSpace(v+" "+f))
+				}
+			}
+		}
+		variants = next
+	}
+	for i, v := range variants {
+		variants[i] = " " + v + " "
+	}
+	return variants
+}
+
+// wordForms declines an upper-case Russian word by its ending. Latin words,
+// short words and indeclinable endings are returned as is.
+func wordForms(w string) []string {
+	r := []rune(w)
+	n := len(r)
+	if n < 4 || !isCyrillic(r[n-1]) {
+		return []string{w}
+	}
+	stem := func(cut int) string { return string(r[:n-cut]) }
+	with := func(base string, endings ...string) []string {
+		out := make([]string, len(endings))
+		for i, e := range endings {
+			out[i] = base + e
+		}
+		return out
+	}
+
+	last2 := string(r[n-2:])
+	switch last2 {
+	case "ИЙ", "ЫЙ":
+		return with(stem(2), last2, "ОГО", "ОМУ", string(r[n-2])+"М", "ОМ")
+	case "ОЙ":
+		return with(stem(2), "ОЙ", "ОГО", "ОМУ", "ЫМ", "ОМ")
+	case "АЯ":
+		return with(stem(2), "АЯ", "ОЙ", "УЮ")
+	}
+	switch r[n-1] {
+	case 'А':
+		return with(stem(1), "А", "Ы", "И", "Е", "У", "ОЙ")
+	case 'Я':
+		return with(stem(1), "Я", "И", "Е", "Ю", "ЕЙ")
+	case 'Ь':
+		return with(stem(1), "Ь", "Я", "Ю", "ЕМ", "Е", "И")
+	case 'О', 'Е', 'И', 'У', 'Ю', 'Ы', 'Э', 'Й':
+		return []string{w}
+	case 'Ж', 'Ш', 'Ч', 'Щ', 'Ц':
+		return with(w, "", "А", "У", "ЕМ", "ОМ", "Е")
+	}
+	return with(w, "", "А", "У", "ОМ", "Е")
+}
+
+func isCyrillic(r rune) bool {
+	return unicode.Is(unicode.Cyrillic, r)
+}
+
+// normalizeText upper-cases s, folds Ё to Е and turns everything except
+// letters and digits into single spaces, padded at both ends for whole-word
+// matching.
+func normalizeText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte(' ')
+	space := true
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r == 'Ё':
+			r = 'Е'
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if !space {
+				b.WriteByte(' ')
+				space = true
+			}
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	if !space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
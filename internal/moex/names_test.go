@@ -0,0 +1,126 @@
+package moex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+)
+
+func TestCleanCompanyName(t *testing.T) {
+	cases := map[string]string{
+		"Сбербанк России ПАО ао": "Сбербанк России",
+		`"Газпром" (ПАО) ао`:     "Газпром",
+		"Сбербанк-п":             "Сбербанк",
+		`МКПАО "Яндекс"`:         "Яндекс",
+		"НК ЛУКОЙЛ ПАО":          "ЛУКОЙЛ",
+		"PJSC Gazprom":           "Gazprom",
+		"ПАО":                    "",
+		"Публичное акционерное общество Северсталь": "",
+	}
+	for in, want := range cases {
+		if got := cleanCompanyName(in); got != want {
+			t.Errorf("cleanCompanyName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameVariants_DeclinesRussianWords(t *testing.T) {
+	has := func(name, form string) bool {
+		for _, v := range nameVariants(name) {
+			if v == " "+form+" " {
+				return true
+			}
+		}
+		return false
+	}
+	for name, form := range map[string]string{
+		"Газпром":           "ГАЗПРОМОМ",
+		"Алроса":            "АЛРОСЫ",
+		"Норникель":         "НОРНИКЕЛЯ",
+		"Норильский никель": "НОРИЛЬСКОГО НИКЕЛЯ",
+		"Московская биржа":  "МОСКОВСКОЙ БИРЖИ",
+		"Новатэк":           "НОВАТЭКА",
+		"Т-Банк":            "Т БАНКА",
+	} {
+		if !has(name, form) {
+			t.Errorf("%q: missing form %q in %v", name, form, nameVariants(name))
+		}
+	}
+	if v := nameVariants("ВТБ"); len(v) != 1 || v[0] != " ВТБ " {
+		t.Errorf("acronym must not decline, got %v", v)
+	}
+}
+
+func TestNameDirectory_FilterNews(t *testing.T) {
+	d := &NameDirectory{terms: buildTerms([]SecurityName{
+		{Ticker: "AFKS", ShortName: "Система ао", SecName: "АФК Система ПАО ао"},
+		{Ticker: "AFLT", ShortName: "Аэрофлот", SecName: "Аэрофлот-росс.авиалин(ПАО)ао", BrokerName: "Аэрофлот"},
+	}, map[string][]string{"SBER": {"Греф"}}, []string{"Система"})}
+
+	news := []NewsItem{
+		{Title: "Дивиденды Алросы превысили прогноз"},
+		{Title: "Совет директоров Аэрофлота одобрил сделку"},
+		{Title: "Акции Яндекса выросли"},
+		{Title: "Греф рассказал о планах"},
+		{Title: "Платёжная система обновлена"},
+		{Title: "АФК Система разместит облигации"},
+		{Title: "Сбербанковский сектор"},
+	}
+	got := d.FilterNews(news, []string{"ALRS", "AFLT", "YDEX", "SBER", "AFKS"})
+
+	check := func(ticker string, want ...string) {
+		t.Helper()
+		if len(got[ticker]) != len(want) {
+			t.Fatalf("%s: expected %v, got %+v", ticker, want, got[ticker])
+		}
+		for i, title := range want {
+			if got[ticker][i].Title != title {
+				t.Errorf("%s: expected %q, got %q", ticker, title, got[ticker][i].Title)
+			}
+		}
+	}
+	check("ALRS", "Дивиденды Алросы превысили прогноз")
+	check("AFLT", "Совет директоров Аэрофлота одобрил сделку")
+	check("YDEX", "Акции Яндекса выросли")
+	check("SBER", "Греф рассказал о планах")         // alias; no partial-word match
+	check("AFKS", "АФК Система разместит облигации") // bare "Система" ignored
+}
+
+func TestNameDirectory_LoadsCacheAndStaleness(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.json")
+	cfg := config.NewsConfig{NamesRefreshHours: 24, NamesCache: path}
+
+	d := NewNameDirectory(cfg, logger.New("error"))
+	if !d.Stale() {
+		t.Fatal("directory without cache must be stale")
+	}
+	if len(d.Terms("SBER")) == 0 {
+		t.Fatal("built-in names must be available without cache")
+	}
+
+	err := writeNameCache(path, nameCache{
+		Updated:    time.Now(),
+		Securities: []SecurityName{{Ticker: "AFLT", ShortName: "Аэрофлот"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = NewNameDirectory(cfg, logger.New("error"))
+	if d.Stale() {
+		t.Error("fresh cache must not be stale")
+	}
+	if len(d.Terms("AFLT")) == 0 {
+		t.Error("names from cache not loaded")
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if d = NewNameDirectory(cfg, logger.New("error")); !d.Stale() {
+		t.Error("corrupt cache must be ignored")
+	}
+}
@@ -4,37 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/camuig/rus-trader/internal/sentiment"
 )
 
-const newsBaseURL = "https://iss.moex.com/iss/sitenews.json?lang=ru"
-
-// tickerToNames maps tickers to Russian company names for news matching.
-var tickerToNames = map[string][]string{
-	"SBER": {"Сбербанк", "Сбер"},
-	"GAZP": {"Газпром"},
-	"LKOH": {"Лукойл", "ЛУКОЙЛ"},
-	"GMKN": {"Норникель", "Норильский никель"},
-	"NVTK": {"Новатэк", "НОВАТЭК"},
-	"ROSN": {"Роснефть"},
-	"YNDX": {"Яндекс"},
-	"TCSG": {"Тинькофф", "Т-Банк", "TCS"},
-	"MTSS": {"МТС"},
-	"MGNT": {"Магнит"},
-	"PLZL": {"Полюс"},
-	"CHMF": {"Северсталь"},
-	"ALRS": {"Алроса", "АЛРОСА"},
-	"SNGS": {"Сургутнефтегаз"},
-	"VTBR": {"ВТБ"},
-	"MOEX": {"Мосбиржа", "Московская биржа"},
-	"TATN": {"Татнефть"},
-	"NLMK": {"НЛМК"},
-	"PHOR": {"ФосАгро"},
-	"IRAO": {"Интер РАО"},
-}
+const newsContentPath = "/sitenews/%d.json?iss.meta=off"
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
 
 type issNewsResponse struct {
 	SiteNews struct {
@@ -43,34 +25,22 @@ type issNewsResponse struct {
 	} `json:"sitenews"`
 }
 
-func (c *Client) FetchRecentNews(ctx context.Context) ([]NewsItem, error) {
+// fetchISSNews pages through an ISS sitenews list until cutoff.
+func (c *Client) fetchISSNews(ctx context.Context, baseURL string, cutoff time.Time) ([]NewsItem, error) {
 	var allNews []NewsItem
-	cutoff := time.Now().Add(-24 * time.Hour)
+	sep := "?"
+	if strings.Contains(baseURL, "?") {
+		sep = "&"
+	}
 
 	for page := 0; page < 4; page++ {
-		url := fmt.Sprintf("%s&start=%d", newsBaseURL, page*50)
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("create news request: %w", err)
-		}
+		url := fmt.Sprintf("%s%sstart=%d", baseURL, sep, page*50)
 
-		resp, err := c.httpClient.Do(req)
+		body, err := c.getConditional(ctx, url)
 		if err != nil {
 			return nil, fmt.Errorf("fetch news page %d: %w", page, err)
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("MOEX news returned status %d", resp.StatusCode)
-		}
-
-		if err != nil {
-			return nil, fmt.Errorf("read news response: %w", err)
-		}
-
 		var iss issNewsResponse
 		if err := json.Unmarshal(body, &iss); err != nil {
 			return nil, fmt.Errorf("parse news response: %w", err)
@@ -114,9 +84,9 @@ func (c *Client) FetchRecentNews(ctx context.Context) ([]NewsItem, error) {
 
 			allNews = append(allNews, NewsItem{
 				ID:        id,
-				Source:    "MOEX",
 				Title:     title,
 				Published: published,
+				HasText:   true,
 			})
 		}
 
@@ -128,26 +98,89 @@ func (c *Client) FetchRecentNews(ctx context.Context) ([]NewsItem, error) {
 	return allNews, nil
 }
 
-// FilterNewsForTickers returns news items grouped by ticker, matching by ticker symbol or Russian company name in the title.
-func FilterNewsForTickers(news []NewsItem, tickers []string) map[string][]NewsItem {
-	result := make(map[string][]NewsItem)
+// NewsSentiment aggregates the sentiment of a ticker's news, favouring fresh
+// headlines. Returns 0 when there is no news or all of it is neutral.
+func NewsSentiment(items []NewsItem, now time.Time) float64 {
+	scored := make([]sentiment.Item, len(items))
+	for i, n := range items {
+		scored[i] = sentiment.Item{Score: n.Sentiment, Published: n.Published}
+	}
+	return sentiment.Aggregate(scored, now)
+}
 
-	for _, ticker := range tickers {
-		searchTerms := []string{strings.ToUpper(ticker)}
-		if names, ok := tickerToNames[ticker]; ok {
-			searchTerms = append(searchTerms, names...)
-		}
+type issNewsContentResponse struct {
+	Content struct {
+		Columns []string        `json:"columns"`
+		Data    [][]interface{} `json:"data"`
+	} `json:"content"`
+}
 
-		for _, item := range news {
-			titleUpper := strings.ToUpper(item.Title)
-			for _, term := range searchTerms {
-				if strings.Contains(titleUpper, strings.ToUpper(term)) {
-					result[ticker] = append(result[ticker], item)
-					break
-				}
-			}
+// NewsArticle is the full text of a MOEX site news item.
+type NewsArticle struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	Published time.Time `json:"published"`
+}
+
+// FetchNewsText loads the full body of a MOEX news item, with HTML markup stripped.
+func (c *Client) FetchNewsText(ctx context.Context, id int64) (*NewsArticle, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.issURL+fmt.Sprintf(newsContentPath, id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create news content request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch news %d: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("MOEX news content returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read news content: %w", err)
+	}
+
+	var iss issNewsContentResponse
+	if err := json.Unmarshal(body, &iss); err != nil {
+		return nil, fmt.Errorf("parse news content: %w", err)
+	}
+	if len(iss.Content.Data) == 0 {
+		return nil, fmt.Errorf("news %d not found", id)
+	}
+
+	row := iss.Content.Data[0]
+	article := &NewsArticle{ID: id}
+	for i, col := range iss.Content.Columns {
+		if i >= len(row) {
+			break
+		}
+		value, _ := row[i].(string)
+		switch col {
+		case "title":
+			article.Title = value
+		case "body":
+			article.Text = htmlToText(value)
+		case "published_at":
+			article.Published, _ = time.Parse("2006-01-02 15:04:05", value)
 		}
 	}
+	return article, nil
+}
 
-	return result
+func htmlToText(s string) string {
+	s = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n").Replace(s)
+	s = html.UnescapeString(htmlTagRegex.ReplaceAllString(s, ""))
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if line = compactSpaces(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
 }
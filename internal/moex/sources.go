@@ -0,0 +1,222 @@
+package moex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/metrics"
+	"github.com/camuig/rus-trader/internal/sentiment"
+)
+
+const (
+	newsWindow     = 24 * time.Hour
+	rankHalfLife   = 6 * time.Hour  // age at which a headline's rank halves
+	undatedNewsAge = 12 * time.Hour // assumed age of headlines without a date
+)
+
+// Headlines are the fetched news split by use, each best-ranked first.
+type Headlines struct {
+	Tickers []NewsItem // to be matched against ticker names
+	Market  []NewsItem // general market background
+}
+
+// FeedStatus is the health of a news source after its last fetch.
+type FeedStatus struct {
+	Name      string
+	Type      string
+	Language  string
+	Scope     string
+	Enabled   bool
+	LastFetch time.Time
+	LastOK    time.Time
+	Items     int           // headlines within the news window at the last success
+	Latency   time.Duration // of the last fetch
+	Failures  int           // consecutive failed fetches
+	Error     string        // of the last failed fetch
+}
+
+// Healthy reports whether the last fetch succeeded.
+func (s FeedStatus) Healthy() bool {
+	return !s.LastFetch.IsZero() && s.Failures == 0
+}
+
+// NewsFeeds fetches headlines from the configured sources and keeps a health
+// status per source.
+type NewsFeeds struct {
+	client  *Client
+	sources []config.NewsSource
+	logger  *logger.Logger
+
+	mu     sync.Mutex
+	status map[string]*FeedStatus
+}
+
+func NewNewsFeeds(c *Client, cfg config.NewsConfig, log *logger.Logger) *NewsFeeds {
+	f := &NewsFeeds{
+		client:  c,
+		sources: cfg.ResolvedSources(),
+		logger:  log,
+		status:  make(map[string]*FeedStatus),
+	}
+	for _, src := range f.sources {
+		f.status[src.Name] = &FeedStatus{
+			Name:     src.Name,
+			Type:     src.Type,
+			Language: src.Language,
+			Scope:    src.Scope,
+			Enabled:  src.IsEnabled(),
+		}
+	}
+	return f
+}
+
+// Fetch downloads all enabled sources concurrently. A failed source is logged
+// and skipped; the error is returned only when every source failed.
+func (f *NewsFeeds) Fetch(ctx context.Context) (Headlines, error) {
+	now := time.Now()
+	cutoff := now.Add(-newsWindow)
+
+	type result struct {
+		src   config.NewsSource
+		items []NewsItem
+		err   error
+	}
+	var enabled []config.NewsSource
+	for _, src := range f.sources {
+		if src.IsEnabled() {
+			enabled = append(enabled, src)
+		}
+	}
+	results := make([]result, len(enabled))
+	var wg sync.WaitGroup
+	for i, src := range enabled {
+		wg.Add(1)
+		go func(i int, src config.NewsSource) {
+			defer wg.Done()
+			start := time.Now()
+			items, err := f.fetchSource(ctx, src, cutoff)
+			f.record(src.Name, start, len(items), err)
+			results[i] = result{src: src, items: items, err: err}
+		}(i, src)
+	}
+	wg.Wait()
+
+	var headlines Headlines
+	var errs []string
+	weights := make(map[string]float64, len(enabled))
+	for _, r := range results {
+		if r.err != nil {
+			f.logger.Warn("news source failed", "source", r.src.Name, "error", r.err)
+			errs = append(errs, fmt.Sprintf("%s: %v", r.src.Name, r.err))
+			continue
+		}
+		weights[r.src.Name] = r.src.Weight
+		if r.src.Scope != "market" {
+			headlines.Tickers = append(headlines.Tickers, r.items...)
+		}
+		if r.src.Scope != "tickers" {
+			headlines.Market = append(headlines.Market, r.items...)
+		}
+	}
+	headlines.Tickers = rankNews(headlines.Tickers, weights, now)
+	headlines.Market = rankNews(headlines.Market, weights, now)
+
+	if len(enabled) > 0 && len(errs) == len(enabled) {
+		return headlines, fmt.Errorf("all news sources failed: %s", strings.Join(errs, "; "))
+	}
+	return headlines, nil
+}
+
+func (f *NewsFeeds) fetchSource(ctx context.Context, src config.NewsSource, cutoff time.Time) ([]NewsItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(src.Timeout())*time.Second)
+	defer cancel()
+
+	var raw []NewsItem
+	var err error
+	switch src.Type {
+	case "iss":
+		raw, err = f.client.fetchISSNews(ctx, f.client.rebaseISS(src.URL), cutoff)
+	case "json":
+		raw, err = f.client.fetchFeed(ctx, src.Name, src.URL, parseJSONFeed)
+	default:
+		raw, err = f.client.fetchFeed(ctx, src.Name, src.URL, parseNewsFeed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]NewsItem, 0, len(raw))
+	for _, item := range raw {
+		if !item.Published.IsZero() && item.Published.Before(cutoff) {
+			continue
+		}
+		item.Title = compactSpaces(item.Title)
+		if item.Title == "" {
+			continue
+		}
+		item.Source = src.Name
+		item.Language = src.Language
+		if item.ID == 0 {
+			item.ID = hashNewsID(item.Source, item.Title, item.Published)
+		}
+		item.Sentiment = sentiment.Score(item.Title)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (f *NewsFeeds) record(name string, start time.Time, items int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st := f.status[name]
+	st.LastFetch = start
+	st.Latency = time.Since(start)
+	if err != nil {
+		st.Failures++
+		st.Error = err.Error()
+		metrics.NewsFetches.Inc(name, "failed")
+		return
+	}
+	st.LastOK = start
+	st.Items = items
+	st.Failures = 0
+	st.Error = ""
+	metrics.NewsFetches.Inc(name, "ok")
+}
+
+// Status returns the health of every configured source in config order.
+func (f *NewsFeeds) Status() []FeedStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]FeedStatus, 0, len(f.sources))
+	for _, src := range f.sources {
+		out = append(out, *f.status[src.Name])
+	}
+	return out
+}
+
+// rankNews orders headlines by source weight and freshness and drops
+// duplicate titles, keeping the best-ranked copy.
+func rankNews(items []NewsItem, weights map[string]float64, now time.Time) []NewsItem {
+	rank := func(n NewsItem) float64 {
+		age := undatedNewsAge
+		if !n.Published.IsZero() {
+			age = now.Sub(n.Published)
+		}
+		if age < 0 {
+			age = 0
+		}
+		return weights[n.Source] * math.Exp2(-age.Hours()/rankHalfLife.Hours())
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return rank(items[i]) > rank(items[j])
+	})
+	return dedupeNewsByTitle(items)
+}
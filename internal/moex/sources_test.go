@@ -0,0 +1,106 @@
+package moex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+)
+
+func TestNewsFeeds_FetchSplitsRanksAndTracksStatus(t *testing.T) {
+	now := time.Now().UTC()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rss", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<rss><channel>
+<item><title>Сбербанк выплатит дивиденды</title><pubDate>%s</pubDate></item>
+<item><title>Old news</title><pubDate>%s</pubDate></item>
+</channel></rss>`, now.Add(-time.Hour).Format(time.RFC1123Z), now.Add(-48*time.Hour).Format(time.RFC1123Z))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"items":[{"title":"Oil  prices\n rise","date_published":%q}]}`, now.Add(-2*time.Hour).Format(time.RFC3339))
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	off := false
+	cfg := config.NewsConfig{Presets: []string{}, Sources: []config.NewsSource{
+		{Name: "RU", Type: "rss", URL: srv.URL + "/rss", Scope: "all"},
+		{Name: "World", Type: "json", URL: srv.URL + "/json", Language: "en", Scope: "market", Weight: 3},
+		{Name: "Down", Type: "rss", URL: srv.URL + "/down", Scope: "tickers"},
+		{Name: "Off", Type: "rss", URL: srv.URL + "/rss", Enabled: &off},
+	}}
+	f := NewNewsFeeds(NewClient(config.MOEXConfig{}, logger.New("error")), cfg, logger.New("error"))
+
+	h, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("one healthy source is enough, got %v", err)
+	}
+	if len(h.Tickers) != 1 || h.Tickers[0].Source != "RU" || h.Tickers[0].Sentiment <= 0 {
+		t.Fatalf("unexpected ticker news: %+v", h.Tickers)
+	}
+	if len(h.Market) != 2 || h.Market[0].Title != "Oil prices rise" || h.Market[0].Language != "en" {
+		t.Fatalf("expected weighted JSON item first in market news, got %+v", h.Market)
+	}
+
+	status := f.Status()
+	if len(status) != 4 {
+		t.Fatalf("expected 4 statuses, got %d", len(status))
+	}
+	if !status[0].Healthy() || status[0].Items != 1 {
+		t.Errorf("RU: %+v", status[0])
+	}
+	if status[2].Healthy() || status[2].Failures != 1 || status[2].Error == "" {
+		t.Errorf("Down: %+v", status[2])
+	}
+	if status[3].Enabled || !status[3].LastFetch.IsZero() {
+		t.Errorf("Off must not be fetched: %+v", status[3])
+	}
+}
+
+func TestNewsFeeds_FetchFailsWhenAllSourcesFail(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	cfg := config.NewsConfig{Sources: []config.NewsSource{{Name: "A", Type: "atom", URL: srv.URL}}}
+	f := NewNewsFeeds(NewClient(config.MOEXConfig{}, logger.New("error")), cfg, logger.New("error"))
+	if _, err := f.Fetch(context.Background()); err == nil {
+		t.Fatal("expected error when every source fails")
+	}
+}
+
+func TestClient_FetchFeedSendsConditionalRequests(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `<rss><channel><item><title>Headline</title></item></channel></rss>`)
+	}))
+	defer srv.Close()
+
+	c := NewClient(config.MOEXConfig{}, logger.New("error"))
+	for i := 0; i < 2; i++ {
+		items, err := c.fetchFeed(context.Background(), "RU", srv.URL, parseNewsFeed)
+		if err != nil {
+			t.Fatalf("fetch %d: %v", i, err)
+		}
+		if len(items) != 1 || items[0].Title != "Headline" {
+			t.Fatalf("fetch %d: unexpected items %+v", i, items)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("expected the second request to be conditional, got %d requests, %d not modified", requests, notModified)
+	}
+}
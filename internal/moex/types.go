@@ -13,4 +13,9 @@ type NewsItem struct {
 	Source    string
 	Title     string
 	Published time.Time
+	Language  string
+	Sentiment float64 // tone of the title in [-1, 1], see sentiment.Score
+	HasText   bool    // full text is available from FetchNewsText
+	FirstSeen time.Time
+	New       bool // first seen in this cycle
 }
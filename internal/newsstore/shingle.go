@@ -0,0 +1,65 @@
+package newsstore
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the length in runes of the character shingles. Four-rune
+// shingles tolerate Russian inflection and reordered clauses while still
+// separating headlines that only share a company name.
+const shingleSize = 4
+
+type shingleSet map[uint32]struct{}
+
+// normalizeTitle lower-cases a title, folds "ё" to "е" and reduces it to
+// letters and digits separated by single spaces.
+func normalizeTitle(title string) string {
+	title = strings.ReplaceAll(strings.ToLower(title), "ё", "е")
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// shingles returns the hashed character shingles of the normalised title.
+// Titles shorter than a shingle yield the whole title as the only shingle.
+func shingles(title string) shingleSet {
+	runes := []rune(normalizeTitle(title))
+	set := make(shingleSet)
+	if len(runes) == 0 {
+		return set
+	}
+	if len(runes) < shingleSize {
+		set[hash(string(runes))] = struct{}{}
+		return set
+	}
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		set[hash(string(runes[i:i+shingleSize]))] = struct{}{}
+	}
+	return set
+}
+
+func hash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
+
+// similarity is the Jaccard index of two shingle sets.
+func similarity(a, b shingleSet) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	common := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,241 @@
+// Package newsstore remembers fetched headlines in the database to mark news
+// that is new since the previous cycle and to collapse near-duplicates
+// published by several sources.
+package newsstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// compareWindow is how far back headlines are compared for duplicates; it
+// covers the 24h fetch window with room for late-dated items.
+const compareWindow = 48 * time.Hour
+
+type key struct {
+	source string
+	id     int64
+}
+
+type entry struct {
+	id        uint // database id, 0 until saved
+	key       key
+	firstSeen time.Time
+	shingles  shingleSet // nil for duplicates
+	original  *entry     // nil for originals
+}
+
+func (e *entry) canonical() *entry {
+	if e.original != nil {
+		return e.original
+	}
+	return e
+}
+
+// Store marks fresh headlines and drops near-duplicates. The recent
+// headlines are loaded from the database on first use and kept in memory.
+type Store struct {
+	repo      *storage.Repository
+	threshold float64
+	keep      time.Duration
+	logger    *logger.Logger
+
+	mu     sync.Mutex
+	loaded bool
+	recent []*entry
+	byKey  map[key]*entry
+	pruned time.Time
+}
+
+func NewStore(repo *storage.Repository, cfg config.NewsConfig, log *logger.Logger) *Store {
+	return &Store{
+		repo:      repo,
+		threshold: cfg.DuplicateSimilarity,
+		keep:      time.Duration(cfg.KeepDays) * 24 * time.Hour,
+		logger:    log,
+		byKey:     make(map[key]*entry),
+	}
+}
+
+// Mark records the headlines and returns them with FirstSeen and New set.
+// Within each list only the first headline of a near-duplicate group is kept.
+// Headlines seen in earlier cycles, or similar to them, are not New. When the
+// database is unavailable the headlines are returned unchanged.
+func (s *Store) Mark(h moex.Headlines, now time.Time) moex.Headlines {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now = now.UTC() // SQLite compares stored times as strings
+
+	if !s.loaded {
+		if err := s.load(now); err != nil {
+			s.logger.Error("load seen news", "error", err)
+			return h
+		}
+	}
+	s.prune(now)
+
+	// An item of a source with scope "all" appears in both lists; it is
+	// resolved once.
+	resolved := make(map[key]*entry)
+	var added []*entry
+	for _, list := range [][]moex.NewsItem{h.Tickers, h.Market} {
+		for _, item := range list {
+			k := key{item.Source, item.ID}
+			if _, ok := resolved[k]; ok {
+				continue
+			}
+			e, ok := s.byKey[k]
+			if !ok {
+				e = s.add(k, item.Title, now)
+				added = append(added, e)
+			}
+			resolved[k] = e
+		}
+	}
+
+	if err := s.save(added, h, now); err != nil {
+		// keep them in memory so the cycle still sees the right novelty
+		s.logger.Error("save seen news", "error", err)
+	}
+
+	mark := func(list []moex.NewsItem) []moex.NewsItem {
+		used := make(map[*entry]bool)
+		out := make([]moex.NewsItem, 0, len(list))
+		for _, item := range list {
+			e := resolved[key{item.Source, item.ID}]
+			c := e.canonical()
+			if used[c] {
+				continue
+			}
+			used[c] = true
+			item.FirstSeen = c.firstSeen
+			item.New = !c.firstSeen.Before(now)
+			out = append(out, item)
+		}
+		return out
+	}
+	return moex.Headlines{Tickers: mark(h.Tickers), Market: mark(h.Market)}
+}
+
+// add registers an unseen headline, as a duplicate of the most similar
+// recent original at or above the threshold, or as a new original.
+func (s *Store) add(k key, title string, now time.Time) *entry {
+	sh := shingles(title)
+	var best *entry
+	bestSim := 0.0
+	for _, e := range s.recent {
+		if e.original != nil {
+			continue
+		}
+		if sim := similarity(sh, e.shingles); sim >= s.threshold && sim > bestSim {
+			best, bestSim = e, sim
+		}
+	}
+	e := &entry{key: k, firstSeen: now}
+	if best != nil {
+		e.original = best
+	} else {
+		e.shingles = sh
+	}
+	s.recent = append(s.recent, e)
+	s.byKey[k] = e
+	return e
+}
+
+// save stores new originals first so that duplicates can refer to their ids.
+func (s *Store) save(added []*entry, h moex.Headlines, now time.Time) error {
+	if len(added) == 0 {
+		return nil
+	}
+	items := make(map[key]moex.NewsItem)
+	for _, list := range [][]moex.NewsItem{h.Tickers, h.Market} {
+		for _, item := range list {
+			items[key{item.Source, item.ID}] = item
+		}
+	}
+	record := func(e *entry) storage.NewsHeadline {
+		item := items[e.key]
+		rec := storage.NewsHeadline{
+			CreatedAt:  now,
+			Source:     e.key.source,
+			ExternalID: e.key.id,
+			Title:      item.Title,
+			Published:  item.Published,
+			Sentiment:  item.Sentiment,
+		}
+		if e.original != nil {
+			rec.DuplicateOf = e.original.id
+		}
+		return rec
+	}
+
+	for _, originals := range []bool{true, false} {
+		var batch []storage.NewsHeadline
+		var entries []*entry
+		for _, e := range added {
+			if (e.original == nil) == originals {
+				batch = append(batch, record(e))
+				entries = append(entries, e)
+			}
+		}
+		if err := s.repo.SaveNewsHeadlines(batch); err != nil {
+			return err
+		}
+		for i, e := range entries {
+			e.id = batch[i].ID
+		}
+	}
+	return nil
+}
+
+func (s *Store) load(now time.Time) error {
+	rows, err := s.repo.GetNewsHeadlinesSince(now.Add(-compareWindow))
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]*entry, len(rows))
+	for _, r := range rows {
+		e := &entry{id: r.ID, key: key{r.Source, r.ExternalID}, firstSeen: r.CreatedAt}
+		if orig, ok := byID[r.DuplicateOf]; ok && r.DuplicateOf != 0 {
+			e.original = orig
+		} else {
+			e.shingles = shingles(r.Title)
+		}
+		byID[r.ID] = e
+		s.recent = append(s.recent, e)
+		s.byKey[e.key] = e
+	}
+	s.loaded = true
+	s.logger.Info("seen news loaded", "count", len(rows))
+	return nil
+}
+
+// prune drops entries older than the compare window from memory and, once a
+// day, headlines older than keep_days from the database.
+func (s *Store) prune(now time.Time) {
+	cutoff := now.Add(-compareWindow)
+	kept := s.recent[:0]
+	for _, e := range s.recent {
+		if e.firstSeen.Before(cutoff) {
+			delete(s.byKey, e.key)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.recent = kept
+
+	if now.Sub(s.pruned) < 24*time.Hour {
+		return
+	}
+	s.pruned = now
+	if n, err := s.repo.DeleteNewsHeadlinesBefore(now.Add(-s.keep)); err != nil {
+		s.logger.Error("prune seen news", "error", err)
+	} else if n > 0 {
+		s.logger.Info("seen news pruned", "count", n)
+	}
+}
@@ -0,0 +1,109 @@
+package newsstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+func TestSimilarity_NearDuplicates(t *testing.T) {
+	a := shingles("Сбербанк выплатит дивиденды за 2023 год в размере 33,3 руб.")
+	b := shingles("«Сбербанк» выплатит дивиденды за 2023 год в размере 33,3 рубля")
+	c := shingles("Газпром нефть отчиталась о росте добычи")
+	if sim := similarity(a, b); sim < 0.6 {
+		t.Errorf("reworded headline: similarity %.2f, want >= 0.6", sim)
+	}
+	if sim := similarity(a, c); sim > 0.2 {
+		t.Errorf("unrelated headlines: similarity %.2f, want <= 0.2", sim)
+	}
+	if similarity(shingles("ВТБ"), shingles("втб")) != 1 {
+		t.Error("short titles must match after normalisation")
+	}
+}
+
+func TestStore_MarksNewAndDropsDuplicates(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := config.NewsConfig{DuplicateSimilarity: 0.6, KeepDays: 7}
+	store := NewStore(repo, cfg, logger.New("error"))
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	first := moex.Headlines{
+		Tickers: []moex.NewsItem{
+			{Source: "MOEX", ID: 1, Title: "Сбербанк выплатит дивиденды за 2023 год в размере 33,3 руб."},
+			{Source: "РБК", ID: 2, Title: "«Сбербанк» выплатит дивиденды за 2023 год в размере 33,3 рубля"},
+		},
+		Market: []moex.NewsItem{
+			{Source: "РБК", ID: 2, Title: "«Сбербанк» выплатит дивиденды за 2023 год в размере 33,3 рубля"},
+			{Source: "BBC", ID: 3, Title: "Oil prices rise"},
+		},
+	}
+	got := store.Mark(first, now)
+	if len(got.Tickers) != 1 || got.Tickers[0].ID != 1 || !got.Tickers[0].New {
+		t.Fatalf("expected the first of the duplicates, new: %+v", got.Tickers)
+	}
+	if len(got.Market) != 2 || !got.Market[0].New || !got.Market[1].New {
+		t.Fatalf("market keeps its own copy of the duplicate: %+v", got.Market)
+	}
+
+	// next cycle: repeats and a rewording of an old headline are not new
+	later := now.Add(15 * time.Minute)
+	second := moex.Headlines{Tickers: []moex.NewsItem{
+		{Source: "Интерфакс", ID: 4, Title: "Сбербанк выплатит дивиденды за 2023 год в размере 33,3 руб"},
+		{Source: "MOEX", ID: 5, Title: "Мосбиржа изменила расписание торгов"},
+		{Source: "MOEX", ID: 1, Title: "Сбербанк выплатит дивиденды за 2023 год в размере 33,3 руб."},
+	}}
+	got = store.Mark(second, later)
+	if len(got.Tickers) != 2 {
+		t.Fatalf("expected duplicate of ID 1 to be dropped, got %+v", got.Tickers)
+	}
+	if got.Tickers[0].ID != 4 || got.Tickers[0].New || !got.Tickers[0].FirstSeen.Equal(now) {
+		t.Errorf("rewording of an old headline: %+v", got.Tickers[0])
+	}
+	if got.Tickers[1].ID != 5 || !got.Tickers[1].New {
+		t.Errorf("fresh headline: %+v", got.Tickers[1])
+	}
+
+	// a restart loads the seen headlines from the database
+	store = NewStore(repo, cfg, logger.New("error"))
+	got = store.Mark(moex.Headlines{Tickers: []moex.NewsItem{
+		{Source: "MOEX", ID: 5, Title: "Мосбиржа изменила расписание торгов"},
+		{Source: "ТАСС", ID: 6, Title: "«Сбербанк» выплатит дивиденды за 2023 год в размере 33,3 рубля"},
+	}}, later.Add(15*time.Minute))
+	if len(got.Tickers) != 2 || got.Tickers[0].New || got.Tickers[1].New {
+		t.Fatalf("headlines seen before the restart must not be new: %+v", got.Tickers)
+	}
+}
+
+func TestStore_PrunesOldHeadlines(t *testing.T) {
+	repo := newTestRepo(t)
+	store := NewStore(repo, config.NewsConfig{DuplicateSimilarity: 0.6, KeepDays: 2}, logger.New("error"))
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	store.Mark(moex.Headlines{Tickers: []moex.NewsItem{{Source: "MOEX", ID: 1, Title: "Старая новость"}}}, now)
+
+	got := store.Mark(moex.Headlines{Tickers: []moex.NewsItem{{Source: "MOEX", ID: 1, Title: "Старая новость"}}}, now.Add(72*time.Hour))
+	if !got.Tickers[0].New {
+		t.Error("headline older than the compare window is new again")
+	}
+	rows, err := repo.GetNewsHeadlinesSince(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || !rows[0].CreatedAt.Equal(now.Add(72*time.Hour)) {
+		t.Errorf("expected only the re-seen headline after pruning, got %+v", rows)
+	}
+}
+
+func newTestRepo(t *testing.T) *storage.Repository {
+	t.Helper()
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "news-test.db"))
+	if err != nil {
+		t.Fatalf("create test database: %v", err)
+	}
+	return storage.NewRepository(db)
+}
@@ -0,0 +1,271 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/metrics"
+)
+
+const (
+	sendTimeout    = 15 * time.Second
+	initialBackoff = 2 * time.Second
+	maxBackoff     = time.Minute
+)
+
+// Dispatcher routes messages to channels. Every channel has its own queue and
+// worker, so a slow channel delays only its own messages.
+type Dispatcher struct {
+	routes      []*route
+	maxAttempts int
+	backoff     time.Duration // first retry delay, doubled up to maxBackoff
+	logger      *logger.Logger
+
+	mu     sync.RWMutex // guards closed against sends on closed queues
+	closed bool
+	stop   chan struct{} // aborts retry waits on shutdown
+	wg     sync.WaitGroup
+}
+
+type route struct {
+	channel     Notifier
+	kind        string // channel type from config
+	events      map[Kind]bool
+	minSeverity Severity
+	queue       chan Message
+
+	mu          sync.Mutex
+	lastSent    time.Time
+	lastFailure time.Time
+	lastErr     error
+	dropped     int
+}
+
+// ChannelStatus is the delivery state of one channel.
+type ChannelStatus struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Queued      int       `json:"queued"`
+	Dropped     int       `json:"dropped"`
+	LastSent    time.Time `json:"last_sent"`
+	LastFailure time.Time `json:"last_failure"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// NewDispatcher creates the channels from notify config and starts their workers.
+func NewDispatcher(cfg *config.Config, log *logger.Logger) (*Dispatcher, error) {
+	d := newDispatcher(cfg.Notify.MaxAttempts, initialBackoff, log)
+	for _, ch := range cfg.Notify.Channels {
+		n, err := newChannel(ch, cfg, log)
+		if err != nil {
+			return nil, fmt.Errorf("notify channel %s: %w", ch.Name, err)
+		}
+		d.add(n, ch.Type, ch.Events, Severity(ch.MinSeverity), cfg.Notify.QueueSize)
+		log.Info("notification channel", "name", ch.Name, "type", ch.Type,
+			"events", ch.Events, "min_severity", ch.MinSeverity)
+	}
+	if len(d.routes) == 0 {
+		log.Warn("no notification channels configured, alerts are only logged")
+	}
+	return d, nil
+}
+
+func newChannel(ch config.NotifyChannel, cfg *config.Config, log *logger.Logger) (Notifier, error) {
+	switch ch.Type {
+	case "telegram":
+		return NewTelegram(ch.Name, cfg.Telegram, log), nil
+	case "webhook":
+		return NewWebhook(ch.Name, ch.URL, ch.Headers), nil
+	case "email":
+		return NewEmail(ch.Name, ch.SMTP), nil
+	case "file":
+		return NewFile(ch.Name, ch.Path), nil
+	}
+	return nil, fmt.Errorf("unknown type %q", ch.Type)
+}
+
+func newDispatcher(maxAttempts int, backoff time.Duration, log *logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		logger:      log,
+		stop:        make(chan struct{}),
+	}
+}
+
+// add registers a channel and starts its worker. An empty events list routes every kind.
+func (d *Dispatcher) add(n Notifier, kind string, events []string, minSeverity Severity, queueSize int) {
+	r := &route{
+		channel:     n,
+		kind:        kind,
+		events:      make(map[Kind]bool, len(events)),
+		minSeverity: minSeverity,
+		queue:       make(chan Message, queueSize),
+	}
+	for _, e := range events {
+		r.events[Kind(e)] = true
+	}
+	d.routes = append(d.routes, r)
+	d.wg.Add(1)
+	go d.worker(r)
+}
+
+func (r *route) accepts(m Message) bool {
+	if len(r.events) > 0 && !r.events[m.Kind] {
+		return false
+	}
+	return m.Severity.AtLeast(r.minSeverity)
+}
+
+// Notify queues a message on every matching channel without blocking. A full
+// queue drops the message for that channel.
+func (d *Dispatcher) Notify(m Message) {
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	if m.Severity == "" {
+		m.Severity = SeverityInfo
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.logger.Warn("notification after shutdown", "kind", m.Kind, "title", m.Title)
+		return
+	}
+	for _, r := range d.routes {
+		if !r.accepts(m) {
+			continue
+		}
+		select {
+		case r.queue <- m:
+		default:
+			r.mu.Lock()
+			r.dropped++
+			r.mu.Unlock()
+			metrics.Notifications.Inc(r.channel.Name(), "dropped")
+			d.logger.Error("notification queue full, message dropped", "channel", r.channel.Name(), "kind", m.Kind)
+		}
+	}
+}
+
+func (d *Dispatcher) worker(r *route) {
+	defer d.wg.Done()
+	for m := range r.queue {
+		err := redactError(d.deliver(r.channel, m))
+		r.mu.Lock()
+		if err != nil {
+			r.lastFailure, r.lastErr = time.Now(), err
+		} else {
+			r.lastSent, r.lastErr = time.Now(), nil
+		}
+		r.mu.Unlock()
+
+		if err != nil {
+			metrics.Notifications.Inc(r.channel.Name(), "failed")
+			d.logger.Error("notification not delivered", "channel", r.channel.Name(), "kind", m.Kind, "error", err)
+		} else {
+			metrics.Notifications.Inc(r.channel.Name(), "sent")
+		}
+	}
+}
+
+// deliver sends with retries and exponential backoff. After Close only one
+// attempt is made, so shutdown is not held up by a dead channel.
+func (d *Dispatcher) deliver(n Notifier, m Message) error {
+	backoff := d.backoff
+	var err error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = n.Send(ctx, m)
+		cancel()
+		if err == nil || attempt == d.maxAttempts {
+			break
+		}
+		d.logger.Warn("notification failed, retrying", "channel", n.Name(), "attempt", attempt, "delay", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-d.stop:
+			return err
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+	return err
+}
+
+// Close stops accepting messages and waits until queued ones are delivered
+// or ctx expires.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.stop)
+	for _, r := range d.routes {
+		close(r.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Status returns the delivery state of every channel.
+func (d *Dispatcher) Status() []ChannelStatus {
+	out := make([]ChannelStatus, len(d.routes))
+	for i, r := range d.routes {
+		r.mu.Lock()
+		out[i] = ChannelStatus{
+			Name:        r.channel.Name(),
+			Type:        r.kind,
+			Queued:      len(r.queue),
+			Dropped:     r.dropped,
+			LastSent:    r.lastSent,
+			LastFailure: r.lastFailure,
+		}
+		if r.lastErr != nil {
+			out[i].LastError = r.lastErr.Error()
+		}
+		r.mu.Unlock()
+	}
+	return out
+}
+
+// Check runs the configuration checks of channels that support them, keyed by channel name.
+func (d *Dispatcher) Check(ctx context.Context) map[string]error {
+	out := make(map[string]error)
+	for _, r := range d.routes {
+		if c, ok := r.channel.(Checker); ok {
+			out[r.channel.Name()] = redactError(c.Check(ctx))
+		}
+	}
+	return out
+}
+
+// redactError drops the request URL from transport errors: webhook URLs and
+// the Telegram bot API path carry secrets, and channel errors are shown by
+// the health check.
+func redactError(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("%s request: %w", uerr.Op, uerr.Err)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File appends every message as one JSON line. The file is reopened for each
+// message, so it can be rotated externally.
+type File struct {
+	name string
+	path string
+	mu   sync.Mutex
+}
+
+func NewFile(name, path string) *File {
+	return &File{name: name, path: path}
+}
+
+func (f *File) Name() string { return f.name }
+
+func (f *File) Send(ctx context.Context, m Message) error {
+	line, err := json.Marshal(webhookPayload{
+		Kind:     m.Kind,
+		Severity: m.Severity,
+		Time:     m.Time,
+		Title:    m.Title,
+		Text:     m.Text(),
+		Data:     m.Data,
+	})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
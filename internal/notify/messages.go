@@ -0,0 +1,194 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/camuig/rus-trader/internal/analytics"
+)
+
+// Trading alerts. Each builds a message and queues it on the matching channels.
+
+func (d *Dispatcher) NotifyBuy(ticker string, price float64, lots int64, sl, tp float64, reasoning string) {
+	d.Notify(Message{
+		Kind:     KindBuy,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf("BUY %s по %.2f ₽", ticker, price),
+		HTML: fmt.Sprintf("🟢 <b>BUY</b> %s\nЦена: %.2f ₽\nЛоты: %d\nSL: %.2f\nTP: %.2f\n\n<i>%s</i>",
+			escapeHTML(ticker), price, lots, sl, tp, escapeHTML(reasoning)),
+		Data: map[string]any{"ticker": ticker, "price": price, "lots": lots, "stop_loss": sl, "take_profit": tp, "reasoning": reasoning},
+	})
+}
+
+func (d *Dispatcher) NotifySell(ticker string, price float64, lots int64, pnl float64, reasoning string) {
+	emoji := "🔴"
+	if pnl > 0 {
+		emoji = "💰"
+	}
+	d.Notify(Message{
+		Kind:     KindSell,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf("SELL %s по %.2f ₽, P&L %+.2f ₽", ticker, price, pnl),
+		HTML: fmt.Sprintf("%s <b>SELL</b> %s\nЦена: %.2f ₽\nЛоты: %d\nP&amp;L: %.2f ₽\n\n<i>%s</i>",
+			emoji, escapeHTML(ticker), price, lots, pnl, escapeHTML(reasoning)),
+		Data: map[string]any{"ticker": ticker, "price": price, "lots": lots, "pnl": pnl, "reasoning": reasoning},
+	})
+}
+
+func (d *Dispatcher) NotifyError(context string, err error) {
+	d.Notify(Message{
+		Kind:     KindError,
+		Severity: SeverityError,
+		Title:    fmt.Sprintf("Ошибка [%s]", context),
+		HTML:     fmt.Sprintf("⚠️ <b>Ошибка</b> [%s]\n%s", escapeHTML(context), escapeHTML(err.Error())),
+		Data:     map[string]any{"context": context, "error": err.Error()},
+	})
+}
+
+func (d *Dispatcher) NotifyBlocked(ticker, action, reason string) {
+	d.Notify(Message{
+		Kind:     KindBlocked,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf("BLOCKED %s %s", action, ticker),
+		HTML: fmt.Sprintf("🚫 <b>BLOCKED</b> %s %s\n<i>%s</i>",
+			escapeHTML(action), escapeHTML(ticker), escapeHTML(reason)),
+		Data: map[string]any{"ticker": ticker, "action": action, "reason": reason},
+	})
+}
+
+// NotifyStatus reports a lifecycle event: start, stop, pause.
+func (d *Dispatcher) NotifyStatus(message string) {
+	d.Notify(Message{Kind: KindStatus, Severity: SeverityInfo, Title: message, HTML: escapeHTML(message)})
+}
+
+// NotifyWarning reports a condition that needs attention but does not stop trading.
+func (d *Dispatcher) NotifyWarning(message string) {
+	d.Notify(Message{Kind: KindStatus, Severity: SeverityWarning, Title: message, HTML: escapeHTML(message)})
+}
+
+// digestListLimit caps trade and position lists so a digest fits one Telegram message.
+const digestListLimit = 10
+
+// NotifyDigest sends the end-of-session digest of a day or week, with an
+// optional PNG equity chart.
+func (d *Dispatcher) NotifyDigest(dg analytics.Digest, chart []byte) {
+	title := "Итоги дня " + dg.From.Format("02.01.2006")
+	if dg.Weekly {
+		title = fmt.Sprintf("Итоги недели %s–%s", dg.From.Format("02.01"), dg.To.Format("02.01.2006"))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 <b>%s</b>\n", escapeHTML(title))
+	p := dg.Period
+	if p.EndEquity > 0 {
+		fmt.Fprintf(&b, "Капитал: %.2f ₽ (%+.2f ₽, %+.2f%%)\n", p.EndEquity, p.EndEquity-p.StartEquity, p.ReturnPct)
+	}
+	fmt.Fprintf(&b, "P&amp;L: реализованный %+.2f ₽ · нереализованный %+.2f ₽\n", dg.RealizedPnL, dg.UnrealizedPnL)
+	fmt.Fprintf(&b, "Комиссии: ~%.2f ₽\n", dg.FeesRub)
+
+	fmt.Fprintf(&b, "\n<b>Сделки</b>: открыто %d, закрыто %d", len(dg.Opened), len(dg.Closed))
+	if p.Trades > 0 {
+		fmt.Fprintf(&b, ", win rate %.0f%%", p.WinRate)
+	}
+	b.WriteString("\n")
+	for i, t := range dg.Opened {
+		if i == digestListLimit {
+			fmt.Fprintf(&b, "… ещё %d\n", len(dg.Opened)-i)
+			break
+		}
+		fmt.Fprintf(&b, "🟢 %s %d × %.2f\n", escapeHTML(t.Ticker), t.Quantity, t.Price)
+	}
+	for i, t := range dg.Closed {
+		if i == digestListLimit {
+			fmt.Fprintf(&b, "… ещё %d\n", len(dg.Closed)-i)
+			break
+		}
+		emoji := "🔴"
+		if t.PnL > 0 {
+			emoji = "💰"
+		}
+		fmt.Fprintf(&b, "%s %s %+.2f ₽ (%+.2f%%)\n", emoji, escapeHTML(t.Ticker), t.PnL, t.ReturnPct)
+	}
+
+	blocked := 0
+	for _, r := range dg.Blocks {
+		blocked += r.Count
+	}
+	fmt.Fprintf(&b, "\n<b>Guard</b>: заблокировано решений %d\n", blocked)
+	for _, r := range dg.Blocks {
+		fmt.Fprintf(&b, "• %s — %d\n", escapeHTML(r.Reason), r.Count)
+	}
+	fmt.Fprintf(&b, "\n<b>AI</b>: циклов %d, с ошибкой %d, расходы %.2f ₽\n", dg.Cycles, dg.FailedCycles, dg.AICostRub)
+
+	if len(dg.Book) > 0 {
+		b.WriteString("\n<b>Позиции</b>\n")
+		for i, pos := range dg.Book {
+			if i == digestListLimit {
+				fmt.Fprintf(&b, "… ещё %d\n", len(dg.Book)-i)
+				break
+			}
+			fmt.Fprintf(&b, "%s %d × %.2f", escapeHTML(pos.Ticker), pos.Quantity, pos.EntryPrice)
+			if pos.CurrentPrice > 0 {
+				fmt.Fprintf(&b, " → %.2f (%+.2f ₽)", pos.CurrentPrice, pos.PnL)
+			}
+			if pos.StopDistPct != 0 {
+				fmt.Fprintf(&b, " · SL %+.1f%%", pos.StopDistPct)
+			}
+			if pos.TakeDistPct != 0 {
+				fmt.Fprintf(&b, " · TP %+.1f%%", pos.TakeDistPct)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	t := dg.Trailing
+	fmt.Fprintf(&b, "\n<b>%d дней</b>\n", dg.TrailingDays)
+	fmt.Fprintf(&b, "Доходность: %+.2f%%\n", t.ReturnPct)
+	fmt.Fprintf(&b, "Sharpe %.2f · Sortino %.2f\n", t.Sharpe, t.Sortino)
+	fmt.Fprintf(&b, "Макс. просадка: %.2f%%\n", t.MaxDrawdown.DepthPct)
+	if t.Trades > 0 {
+		pf := "∞"
+		if t.GrossLoss < 0 {
+			pf = fmt.Sprintf("%.2f", t.ProfitFactor)
+		}
+		fmt.Fprintf(&b, "Сделок: %d, win rate %.0f%%, PF %s\n", t.Trades, t.WinRate, pf)
+		fmt.Fprintf(&b, "Ожидание: %+.2f ₽/сделка, удержание %.1f ч\n", t.Expectancy, t.AvgHoldingHours)
+	}
+	if len(t.Tickers) > 0 {
+		best, worst := t.Tickers[0], t.Tickers[len(t.Tickers)-1]
+		fmt.Fprintf(&b, "Лучший: %s %+.2f ₽", escapeHTML(best.Ticker), best.PnL)
+		if len(t.Tickers) > 1 {
+			fmt.Fprintf(&b, " · худший: %s %+.2f ₽", escapeHTML(worst.Ticker), worst.PnL)
+		}
+	}
+
+	d.Notify(Message{
+		Kind:     KindReport,
+		Severity: SeverityInfo,
+		Title:    title,
+		HTML:     strings.TrimRight(b.String(), "\n"),
+		Data: map[string]any{
+			"weekly":           dg.Weekly,
+			"from":             dg.From,
+			"to":               dg.To,
+			"equity":           p.EndEquity,
+			"return_pct":       p.ReturnPct,
+			"realized_pnl":     dg.RealizedPnL,
+			"unrealized_pnl":   dg.UnrealizedPnL,
+			"fees_rub":         dg.FeesRub,
+			"opened":           dg.Opened,
+			"closed":           dg.Closed,
+			"win_rate":         p.WinRate,
+			"blocks":           dg.Blocks,
+			"ai_cost_rub":      dg.AICostRub,
+			"cycles":           dg.Cycles,
+			"failed_cycles":    dg.FailedCycles,
+			"book":             dg.Book,
+			"trailing_days":    dg.TrailingDays,
+			"sharpe":           t.Sharpe,
+			"sortino":          t.Sortino,
+			"max_drawdown_pct": t.MaxDrawdown.DepthPct,
+		},
+		Image: chart,
+	})
+}
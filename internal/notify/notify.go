@@ -0,0 +1,85 @@
+// Package notify delivers alerts to several channels: Telegram, a JSON webhook,
+// SMTP email and an append-only file. Routing by event kind and severity is
+// configured per channel; delivery is asynchronous with retries, so a slow or
+// failing channel never blocks trading.
+package notify
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Kind is the event type of a message, used for routing.
+type Kind string
+
+const (
+	KindBuy     Kind = "buy"
+	KindSell    Kind = "sell"
+	KindBlocked Kind = "blocked"
+	KindError   Kind = "error"
+	KindStatus  Kind = "status" // start, stop, pause, budget alerts
+	KindReport  Kind = "report" // daily performance report
+)
+
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityWarning:
+		return 1
+	case SeverityError:
+		return 2
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as severe as min or more.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank()
+}
+
+// Message is one alert. The body is written in the HTML subset Telegram
+// accepts (<b>, <i> and escaped entities); other channels use Text.
+type Message struct {
+	Kind     Kind
+	Severity Severity
+	Time     time.Time
+	Title    string // one-line plain-text summary, e.g. an email subject
+	HTML     string
+	Data     any    // structured payload for webhooks, optional
+	Image    []byte // PNG attachment, optional; sent by Telegram and email
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Text returns the body as plain text.
+func (m Message) Text() string {
+	return html.UnescapeString(tagPattern.ReplaceAllString(m.HTML, ""))
+}
+
+// Notifier is a delivery channel.
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, m Message) error
+}
+
+// Checker is implemented by channels that can verify their configuration
+// without sending a message.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
@@ -0,0 +1,301 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/analytics"
+	"github.com/camuig/rus-trader/internal/logger"
+)
+
+// fakeChannel records delivered messages; the first `failures` sends fail.
+type fakeChannel struct {
+	name     string
+	mu       sync.Mutex
+	failures int
+	attempts int
+	got      []Message
+	block    chan struct{}
+}
+
+func (f *fakeChannel) Name() string { return f.name }
+
+func (f *fakeChannel) Send(ctx context.Context, m Message) error {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("unavailable")
+	}
+	f.got = append(f.got, m)
+	return nil
+}
+
+func (f *fakeChannel) received() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.got...)
+}
+
+func testDispatcher(maxAttempts int) *Dispatcher {
+	return newDispatcher(maxAttempts, time.Millisecond, logger.New("error"))
+}
+
+func closeDispatcher(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds; Close would cut retries short.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (f *fakeChannel) attempted() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts
+}
+
+func TestDispatcher_RoutesByKindAndSeverity(t *testing.T) {
+	d := testDispatcher(1)
+	all := &fakeChannel{name: "all"}
+	trades := &fakeChannel{name: "trades"}
+	errs := &fakeChannel{name: "errors"}
+	d.add(all, "file", nil, SeverityInfo, 10)
+	d.add(trades, "webhook", []string{"buy", "sell"}, SeverityInfo, 10)
+	d.add(errs, "email", nil, SeverityWarning, 10)
+
+	d.NotifyBuy("SBER", 300, 1, 290, 320, "breakout")
+	d.NotifyStatus("started")
+	d.NotifyWarning("budget")
+	d.NotifyError("SELL GAZP", errors.New("rejected"))
+	closeDispatcher(t, d)
+
+	if n := len(all.received()); n != 4 {
+		t.Errorf("all: expected 4 messages, got %d", n)
+	}
+	if got := trades.received(); len(got) != 1 || got[0].Kind != KindBuy {
+		t.Errorf("trades: expected only the buy, got %+v", got)
+	}
+	got := errs.received()
+	if len(got) != 2 || got[0].Severity != SeverityWarning || got[1].Kind != KindError {
+		t.Errorf("errors: expected warning and error, got %+v", got)
+	}
+}
+
+func TestDispatcher_RetriesUntilDelivered(t *testing.T) {
+	d := testDispatcher(3)
+	ch := &fakeChannel{name: "flaky", failures: 2}
+	d.add(ch, "webhook", nil, SeverityInfo, 10)
+
+	d.NotifyStatus("hello")
+	waitFor(t, func() bool { return len(ch.received()) == 1 })
+	closeDispatcher(t, d)
+
+	if len(ch.received()) != 1 || ch.attempts != 3 {
+		t.Fatalf("expected delivery on 3rd attempt, got %d messages after %d attempts", len(ch.received()), ch.attempts)
+	}
+	if st := d.Status()[0]; st.LastError != "" || st.LastSent.IsZero() {
+		t.Errorf("unexpected status %+v", st)
+	}
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	d := testDispatcher(2)
+	ch := &fakeChannel{name: "dead", failures: 100}
+	d.add(ch, "webhook", nil, SeverityInfo, 10)
+
+	d.NotifyStatus("hello")
+	waitFor(t, func() bool { return ch.attempted() == 2 })
+	closeDispatcher(t, d)
+
+	if ch.attempts != 2 || len(ch.received()) != 0 {
+		t.Fatalf("expected 2 failed attempts, got %d", ch.attempts)
+	}
+	if st := d.Status()[0]; st.LastError != "unavailable" || st.LastFailure.IsZero() {
+		t.Errorf("unexpected status %+v", st)
+	}
+}
+
+func TestDispatcher_HidesChannelURLsInErrors(t *testing.T) {
+	d := testDispatcher(1)
+	d.add(NewWebhook("hook", "http://127.0.0.1:1/hooks/s3cr3t-token", nil), "webhook", nil, SeverityInfo, 10)
+
+	d.NotifyStatus("hello")
+	waitFor(t, func() bool { return !d.Status()[0].LastFailure.IsZero() })
+	closeDispatcher(t, d)
+
+	if st := d.Status()[0]; st.LastError == "" || strings.Contains(st.LastError, "s3cr3t") {
+		t.Errorf("expected an error without the URL, got %q", st.LastError)
+	}
+}
+
+func TestDispatcher_DropsWhenQueueFull(t *testing.T) {
+	d := testDispatcher(1)
+	ch := &fakeChannel{name: "slow", block: make(chan struct{})}
+	d.add(ch, "webhook", nil, SeverityInfo, 2)
+
+	// One message is held by the blocked worker at most; the rest fill the queue.
+	for i := 0; i < 10; i++ {
+		d.NotifyStatus("msg")
+	}
+	close(ch.block)
+	closeDispatcher(t, d)
+
+	st := d.Status()[0]
+	if st.Dropped < 7 || st.Dropped+len(ch.received()) != 10 {
+		t.Fatalf("expected at least 7 dropped of 10, got dropped=%d delivered=%d", st.Dropped, len(ch.received()))
+	}
+}
+
+func TestDispatcher_IgnoresMessagesAfterClose(t *testing.T) {
+	d := testDispatcher(1)
+	ch := &fakeChannel{name: "c"}
+	d.add(ch, "file", nil, SeverityInfo, 10)
+	closeDispatcher(t, d)
+
+	d.NotifyStatus("late")
+	if len(ch.received()) != 0 {
+		t.Fatal("message delivered after close")
+	}
+}
+
+func TestMessage_Text(t *testing.T) {
+	m := Message{HTML: "🟢 <b>BUY</b> SBER\nP&amp;L: 1 &lt; 2\n<i>why</i>"}
+	if got, want := m.Text(), "🟢 BUY SBER\nP&L: 1 < 2\nwhy"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWebhook_PostsJSON(t *testing.T) {
+	var body webhookPayload
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	w := NewWebhook("hook", srv.URL, map[string]string{"Authorization": "Bearer x"})
+	err := w.Send(context.Background(), Message{
+		Kind: KindSell, Severity: SeverityInfo, Title: "SELL", HTML: "<b>SELL</b> GAZP",
+		Data: map[string]any{"ticker": "GAZP"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer x" {
+		t.Errorf("header not sent: %q", auth)
+	}
+	if body.Kind != KindSell || body.Text != "SELL GAZP" || body.Data.(map[string]any)["ticker"] != "GAZP" {
+		t.Errorf("unexpected payload %+v", body)
+	}
+}
+
+func TestWebhook_FailsOnErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook("hook", srv.URL, nil).Send(context.Background(), Message{}); err == nil {
+		t.Fatal("expected error for 502")
+	}
+}
+
+func TestFile_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "alerts.jsonl")
+	f := NewFile("file", path)
+	for _, title := range []string{"one", "two"} {
+		if err := f.Send(context.Background(), Message{Kind: KindStatus, Title: title, HTML: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var titles []string
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		var p webhookPayload
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		titles = append(titles, p.Title)
+	}
+	if len(titles) != 2 || titles[0] != "one" || titles[1] != "two" {
+		t.Errorf("unexpected lines %v", titles)
+	}
+}
+
+func TestNotifyDigest_RendersSectionsAndChart(t *testing.T) {
+	d := testDispatcher(1)
+	ch := &fakeChannel{name: "c"}
+	d.add(ch, "telegram", []string{"report"}, SeverityInfo, 10)
+
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	d.NotifyDigest(analytics.Digest{
+		Weekly:       true,
+		From:         from,
+		To:           from.AddDate(0, 0, 4),
+		RealizedPnL:  99,
+		Closed:       []analytics.DigestTrade{{Ticker: "GAZP", PnL: 99, ReturnPct: 9.9}},
+		Blocks:       []analytics.ReasonCount{{Reason: "лимит сделок <за день>", Count: 2}},
+		Cycles:       5,
+		FailedCycles: 1,
+		Book:         []analytics.BookPosition{{Ticker: "SBER", Quantity: 10, EntryPrice: 300, CurrentPrice: 300, StopDistPct: -5, TakeDistPct: 10}},
+		TrailingDays: 30,
+	}, []byte("png"))
+	closeDispatcher(t, d)
+
+	got := ch.received()
+	if len(got) != 1 {
+		t.Fatalf("expected one report, got %d", len(got))
+	}
+	m := got[0]
+	if m.Title != "Итоги недели 02.03–06.03.2026" || string(m.Image) != "png" {
+		t.Errorf("unexpected title %q or image", m.Title)
+	}
+	for _, want := range []string{
+		"реализованный +99.00 ₽",
+		"💰 GAZP +99.00 ₽ (+9.90%)",
+		"лимит сделок &lt;за день&gt; — 2",
+		"циклов 5, с ошибкой 1",
+		"SBER 10 × 300.00 → 300.00 (+0.00 ₽) · SL -5.0% · TP +10.0%",
+		"<b>30 дней</b>",
+	} {
+		if !strings.Contains(m.HTML, want) {
+			t.Errorf("digest misses %q:\n%s", want, m.HTML)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+)
+
+// Telegram sends messages to a chat through the Bot API. The bot is created
+// lazily, so a Telegram outage at startup only delays alerts. The Bot API
+// calls take no context, so each request is bounded by the client timeout
+// instead.
+type Telegram struct {
+	name   string
+	token  string
+	chatID int64
+	logger *logger.Logger
+
+	mu  sync.Mutex
+	bot *tgbotapi.BotAPI
+}
+
+func NewTelegram(name string, cfg config.TelegramConfig, log *logger.Logger) *Telegram {
+	t := &Telegram{name: name, token: cfg.BotToken, chatID: cfg.ChatID, logger: log}
+	if _, err := t.client(); err != nil {
+		log.Error("failed to create telegram bot, will retry on send", "error", err)
+	}
+	return t
+}
+
+func (t *Telegram) Name() string { return t.name }
+
+func (t *Telegram) client() (*tgbotapi.BotAPI, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.bot != nil {
+		return t.bot, nil
+	}
+	bot, err := tgbotapi.NewBotAPIWithClient(t.token, tgbotapi.APIEndpoint, &http.Client{Timeout: sendTimeout})
+	if err != nil {
+		return nil, err
+	}
+	t.logger.Info("telegram bot connected", "username", bot.Self.UserName)
+	t.bot = bot
+	return bot, nil
+}
+
+func (t *Telegram) Send(ctx context.Context, m Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	bot, err := t.client()
+	if err != nil {
+		return err
+	}
+	msg := tgbotapi.NewMessage(t.chatID, m.HTML)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if _, err := bot.Send(msg); err != nil {
+		return err
+	}
+
+	// The image follows the text. Its failure is only logged: a retry would
+	// repeat the text that was already delivered.
+	if len(m.Image) > 0 && ctx.Err() == nil {
+		photo := tgbotapi.NewPhoto(t.chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: m.Image})
+		photo.Caption = m.Title
+		if _, err := bot.Send(photo); err != nil {
+			t.logger.Warn("telegram image not sent", "channel", t.name, "error", redactError(err))
+		}
+	}
+	return nil
+}
+
+// Check verifies the bot token with getMe.
+func (t *Telegram) Check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	bot, err := t.client()
+	if err != nil {
+		return err
+	}
+	me, err := bot.GetMe()
+	if err != nil {
+		return err
+	}
+	if me.UserName == "" {
+		return errors.New("getMe returned no bot")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook POSTs every message as JSON. Any 2xx response is a success.
+type Webhook struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// webhookPayload is the JSON body sent to webhooks.
+type webhookPayload struct {
+	Kind     Kind      `json:"kind"`
+	Severity Severity  `json:"severity"`
+	Time     time.Time `json:"time"`
+	Title    string    `json:"title"`
+	Text     string    `json:"text"`
+	Data     any       `json:"data,omitempty"`
+}
+
+func NewWebhook(name, url string, headers map[string]string) *Webhook {
+	return &Webhook{name: name, url: url, headers: headers, client: &http.Client{}}
+}
+
+func (w *Webhook) Name() string { return w.name }
+
+func (w *Webhook) Send(ctx context.Context, m Message) error {
+	body, err := json.Marshal(webhookPayload{
+		Kind:     m.Kind,
+		Severity: m.Severity,
+		Time:     m.Time,
+		Title:    m.Title,
+		Text:     m.Text(),
+		Data:     m.Data,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rus-trader")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
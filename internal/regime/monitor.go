@@ -0,0 +1,123 @@
+package regime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+)
+
+// historyDays of daily candles are loaded, about 40 trading days.
+const historyDays = 60
+
+// Snapshot is the market context at the last refresh.
+type Snapshot struct {
+	Regime     Regime
+	Score      int
+	Reasons    []string
+	Series     []Series
+	Missing    []string // instruments that failed in the last refresh
+	KeyRatePct float64
+	Updated    time.Time
+}
+
+// Monitor loads the market instruments from ISS and keeps the last snapshot
+// for refresh_minutes. Update is called by the scheduler only; Current may be
+// called concurrently.
+type Monitor struct {
+	client      *moex.Client
+	instruments []config.MarketInstrument
+	keyRate     float64
+	refresh     time.Duration
+	logger      *logger.Logger
+
+	mu   sync.Mutex
+	last Snapshot
+}
+
+func NewMonitor(c *moex.Client, cfg config.MarketConfig, log *logger.Logger) *Monitor {
+	return &Monitor{
+		client:      c,
+		instruments: cfg.Instruments,
+		keyRate:     cfg.KeyRatePct,
+		refresh:     time.Duration(cfg.RefreshMinutes) * time.Minute,
+		logger:      log,
+	}
+}
+
+// Update returns the market context, reloading it when it is older than
+// refresh_minutes. An instrument that fails keeps its previous series; when
+// nothing has ever been loaded ok is false.
+func (m *Monitor) Update(ctx context.Context, now time.Time) (snap Snapshot, ok bool) {
+	if len(m.instruments) == 0 {
+		return Snapshot{}, false
+	}
+	last := m.Current()
+	if !last.Updated.IsZero() && now.Sub(last.Updated) < m.refresh {
+		return last, true
+	}
+
+	previous := make(map[string]Series, len(last.Series))
+	for _, s := range last.Series {
+		previous[s.Name] = s
+	}
+	next := Snapshot{KeyRatePct: m.keyRate}
+	loaded := 0
+	for _, inst := range m.instruments {
+		s, err := m.load(ctx, inst, now)
+		if err == nil {
+			loaded++
+		} else {
+			m.logger.Warn("load market instrument", "name", inst.Name, "error", err)
+			next.Missing = append(next.Missing, inst.Name)
+			var found bool
+			if s, found = previous[inst.Name]; !found {
+				continue
+			}
+		}
+		next.Series = append(next.Series, s)
+	}
+	if loaded == 0 {
+		return last, !last.Updated.IsZero()
+	}
+
+	next.Regime, next.Score, next.Reasons = Classify(next.Series)
+	next.Updated = now
+	if next.Regime != last.Regime {
+		m.logger.Info("market regime", "regime", next.Regime, "score", next.Score, "reasons", next.Reasons)
+	}
+	m.mu.Lock()
+	m.last = next
+	m.mu.Unlock()
+	return next, true
+}
+
+// Current returns the last snapshot without refreshing it.
+func (m *Monitor) Current() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+func (m *Monitor) load(ctx context.Context, inst config.MarketInstrument, now time.Time) (Series, error) {
+	security := inst.Security
+	if inst.Asset != "" {
+		var err error
+		if security, err = m.client.FrontFuture(ctx, inst.Asset, now); err != nil {
+			return Series{}, err
+		}
+	}
+	candles, err := m.client.FetchDailyCandles(ctx, inst.Engine, inst.Market, security, now.AddDate(0, 0, -historyDays))
+	if err != nil {
+		return Series{}, err
+	}
+	s, ok := NewSeries(inst.Name, inst.Role, candles)
+	if !ok {
+		return Series{}, fmt.Errorf("%s: %d daily candles, need %d", security, len(candles), minCandles)
+	}
+	return s, nil
+}
@@ -0,0 +1,144 @@
+// Package regime classifies the market regime (risk-on, neutral, risk-off)
+// from the daily trends of the index, government bonds, the ruble and oil.
+package regime
+
+import (
+	"fmt"
+
+	"github.com/camuig/rus-trader/internal/indicators"
+	"github.com/camuig/rus-trader/internal/moex"
+)
+
+type Regime string
+
+const (
+	RiskOn  Regime = "risk-on"
+	Neutral Regime = "neutral"
+	RiskOff Regime = "risk-off"
+)
+
+// minCandles is the history needed for EMA(21) and the 20-day change.
+const minCandles = 22
+
+// riskScore is the score at which the regime turns risk-on (or, negated, risk-off).
+const riskScore = 2
+
+// Series is the daily trend of one market instrument.
+type Series struct {
+	Name      string
+	Role      string // equity, bonds, fx or oil
+	Last      float64
+	Change1d  float64 // %
+	Change5d  float64 // %
+	Change20d float64 // %
+	RSI14     float64
+	Trend     int // +1 up, -1 down, 0 flat: close and EMA(9) against EMA(21)
+}
+
+// NewSeries computes the trend of an instrument from its daily candles,
+// oldest first. ok is false when the history is too short.
+func NewSeries(name, role string, candles []moex.Candle) (s Series, ok bool) {
+	if len(candles) < minCandles {
+		return Series{}, false
+	}
+	ic := make([]indicators.Candle, len(candles))
+	for i, c := range candles {
+		ic[i] = indicators.Candle{Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume}
+	}
+	ind := indicators.Compute(ic)
+
+	last := candles[len(candles)-1].Close
+	// ISS may return a candle without trades and a zero close; it gives no
+	// change rather than ±Inf
+	change := func(days int) float64 {
+		prev := candles[len(candles)-1-days].Close
+		if prev <= 0 || last <= 0 {
+			return 0
+		}
+		return (last - prev) / prev * 100
+	}
+	s = Series{
+		Name:      name,
+		Role:      role,
+		Last:      last,
+		Change1d:  change(1),
+		Change5d:  change(5),
+		Change20d: change(20),
+		RSI14:     ind.RSI14,
+	}
+	switch {
+	case last > ind.EMA21 && ind.EMA9 > ind.EMA21:
+		s.Trend = 1
+	case last < ind.EMA21 && ind.EMA9 < ind.EMA21:
+		s.Trend = -1
+	}
+	return s, true
+}
+
+// Describe renders the series for the prompt, e.g.
+// "IMOEX 2750.12: 1д -0.8%, 5д -3.1%, 20д -6.2%, тренд ↓, RSI 34".
+func (s Series) Describe() string {
+	trend := "→"
+	switch s.Trend {
+	case 1:
+		trend = "↑"
+	case -1:
+		trend = "↓"
+	}
+	return fmt.Sprintf("%s %.2f: 1д %+.1f%%, 5д %+.1f%%, 20д %+.1f%%, тренд %s, RSI %.0f",
+		s.Name, s.Last, s.Change1d, s.Change5d, s.Change20d, trend, s.RSI14)
+}
+
+// Classify scores the series and returns the regime, the score and the
+// reasons that contributed to it. The index trend weighs most; bonds, the
+// ruble and oil confirm or offset it.
+func Classify(series []Series) (Regime, int, []string) {
+	score := 0
+	var reasons []string
+	add := func(points int, format string, args ...interface{}) {
+		score += points
+		reasons = append(reasons, fmt.Sprintf(format, args...))
+	}
+
+	for _, s := range series {
+		switch s.Role {
+		case "equity":
+			switch s.Trend {
+			case 1:
+				add(2, "%s в восходящем тренде", s.Name)
+			case -1:
+				add(-2, "%s в нисходящем тренде", s.Name)
+			}
+			if s.Change5d <= -4 {
+				add(-1, "%s %+.1f%% за 5 дней", s.Name, s.Change5d)
+			} else if s.Change5d >= 4 {
+				add(1, "%s %+.1f%% за 5 дней", s.Name, s.Change5d)
+			}
+		case "bonds":
+			switch s.Trend {
+			case 1:
+				add(1, "%s растёт: доходности ОФЗ снижаются", s.Name)
+			case -1:
+				add(-1, "%s падает: доходности ОФЗ растут", s.Name)
+			}
+		case "fx":
+			if s.Change5d >= 3 || s.Change20d >= 7 {
+				add(-1, "рубль слабеет: %s %+.1f%% за 5 дней, %+.1f%% за 20 дней", s.Name, s.Change5d, s.Change20d)
+			}
+		case "oil":
+			if s.Change20d >= 5 {
+				add(1, "%s %+.1f%% за 20 дней", s.Name, s.Change20d)
+			} else if s.Change20d <= -10 {
+				add(-1, "%s %+.1f%% за 20 дней", s.Name, s.Change20d)
+			}
+		}
+	}
+
+	switch {
+	case score >= riskScore:
+		return RiskOn, score, reasons
+	case score <= -riskScore:
+		return RiskOff, score, reasons
+	}
+	return Neutral, score, reasons
+}
@@ -0,0 +1,80 @@
+package regime
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/moex"
+)
+
+// trendCandles returns n daily candles whose close moves by step % a day.
+func trendCandles(n int, start, step float64) []moex.Candle {
+	candles := make([]moex.Candle, n)
+	price := start
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := range candles {
+		candles[i] = moex.Candle{Begin: day.AddDate(0, 0, i), Open: price, High: price * 1.005, Low: price * 0.995, Close: price}
+		price *= 1 + step/100
+	}
+	return candles
+}
+
+func TestNewSeries_TrendAndChanges(t *testing.T) {
+	if _, ok := NewSeries("IMOEX", "equity", trendCandles(10, 3000, 1)); ok {
+		t.Fatal("short history must be rejected")
+	}
+
+	up, ok := NewSeries("IMOEX", "equity", trendCandles(40, 3000, 0.5))
+	if !ok || up.Trend != 1 {
+		t.Fatalf("expected an uptrend, got %+v", up)
+	}
+	if up.Change1d < 0.49 || up.Change1d > 0.51 || up.Change5d < 2.5 || up.Change5d > 2.6 {
+		t.Errorf("unexpected changes: %+v", up)
+	}
+
+	down, _ := NewSeries("IMOEX", "equity", trendCandles(40, 3000, -0.5))
+	if down.Trend != -1 {
+		t.Fatalf("expected a downtrend, got %+v", down)
+	}
+	if got := down.Describe(); !strings.HasPrefix(got, "IMOEX ") || !strings.Contains(got, "тренд ↓") {
+		t.Errorf("unexpected description %q", got)
+	}
+
+	gap := trendCandles(40, 3000, 0.5)
+	gap[len(gap)-6].Close = 0
+	s, _ := NewSeries("IMOEX", "equity", gap)
+	if s.Change5d != 0 || s.Change1d < 0.49 || s.Change1d > 0.51 {
+		t.Errorf("expected no 5-day change across a zero close, got %+v", s)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []Series
+		want   Regime
+	}{
+		{"index uptrend", []Series{{Name: "IMOEX", Role: "equity", Trend: 1}}, RiskOn},
+		{"index downtrend with falling bonds and weak ruble", []Series{
+			{Name: "IMOEX", Role: "equity", Trend: -1, Change5d: -5},
+			{Name: "RGBI", Role: "bonds", Trend: -1},
+			{Name: "USD/RUB", Role: "fx", Change5d: 4},
+		}, RiskOff},
+		{"index uptrend offset by bonds and the ruble", []Series{
+			{Name: "IMOEX", Role: "equity", Trend: 1},
+			{Name: "RGBI", Role: "bonds", Trend: -1},
+			{Name: "USD/RUB", Role: "fx", Change20d: 8},
+		}, Neutral},
+		{"flat index, oil rally", []Series{
+			{Name: "IMOEX", Role: "equity"},
+			{Name: "Brent", Role: "oil", Change20d: 6},
+		}, Neutral},
+	}
+	for _, tt := range tests {
+		got, score, reasons := Classify(tt.series)
+		if got != tt.want {
+			t.Errorf("%s: got %s (score %d, %v), want %s", tt.name, got, score, reasons, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/camuig/rus-trader/internal/ai"
+	"github.com/camuig/rus-trader/internal/executor"
+	"github.com/camuig/rus-trader/internal/metrics"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// Operator control errors, mapped to HTTP statuses by the web server.
+var (
+	ErrNoPosition  = errors.New("нет открытой позиции")
+	ErrInvalidStop = errors.New("некорректные уровни SL/TP")
+	ErrBadOrder    = errors.New("некорректная заявка")
+)
+
+// Paused reports whether scheduled cycles are suspended.
+func (s *Scheduler) Paused() bool {
+	return s.paused.Load()
+}
+
+// Pause suspends scheduled cycles; a forced cycle still runs.
+func (s *Scheduler) Pause(user string) {
+	if s.paused.Swap(true) {
+		return
+	}
+	s.logger.Info("scheduler paused", "user", user)
+	metrics.Paused.Set(1)
+	s.notifier.NotifyStatus(fmt.Sprintf("⏸ Торговля приостановлена (%s)", user))
+}
+
+// Resume re-enables scheduled cycles.
+func (s *Scheduler) Resume(user string) {
+	if !s.paused.Swap(false) {
+		return
+	}
+	s.logger.Info("scheduler resumed", "user", user)
+	metrics.Paused.Set(0)
+	s.notifier.NotifyStatus(fmt.Sprintf("▶️ Торговля возобновлена (%s)", user))
+}
+
+// TriggerCycle queues an immediate analysis cycle. It returns false if one is already queued.
+func (s *Scheduler) TriggerCycle(user string) bool {
+	select {
+	case s.trigger <- struct{}{}:
+		s.logger.Info("analysis cycle requested", "user", user)
+		return true
+	default:
+		return false
+	}
+}
+
+// ClosePosition sells the open position in ticker. The trade guard is bypassed:
+// the operator may close before the minimum holding time.
+func (s *Scheduler) ClosePosition(ticker, user string) (executor.Outcome, error) {
+	ticker = strings.ToUpper(ticker)
+	if trade, err := s.repo.GetOpenTradeByTicker(ticker); err != nil || trade == nil {
+		return executor.Outcome{}, ErrNoPosition
+	}
+
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+	s.logger.Info("manual close", "ticker", ticker, "user", user)
+	return s.executor.Execute([]ai.AIDecision{{
+		Action:    "SELL",
+		Ticker:    ticker,
+		Reasoning: fmt.Sprintf("закрыто вручную (%s)", user),
+		Manual:    true,
+	}})[0], nil
+}
+
+// UpdateStops re-places the stop orders of an open trade. A zero level keeps the current one.
+func (s *Scheduler) UpdateStops(tradeID uint, stopLoss, takeProfit float64, user string) (*storage.Trade, error) {
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	trade, err := s.repo.GetTradeByID(tradeID)
+	if err != nil || trade.Action != "BUY" || trade.Status != "open" {
+		return nil, ErrNoPosition
+	}
+	sl, tp := stopLoss, takeProfit
+	if sl <= 0 {
+		sl = trade.StopLossPrice
+	}
+	if tp <= 0 {
+		tp = trade.TakeProfitPrice
+	}
+	if stopLoss < 0 || takeProfit < 0 || sl >= tp {
+		return nil, ErrInvalidStop
+	}
+
+	s.logger.Info("manual SL/TP update", "ticker", trade.Ticker, "sl", sl, "tp", tp, "user", user)
+	if err := s.executor.ReplaceStops(trade, sl, tp); err != nil {
+		if errors.Is(err, executor.ErrStopsCrossPrice) {
+			return trade, fmt.Errorf("%w: %v", ErrInvalidStop, err)
+		}
+		return trade, err
+	}
+	return trade, nil
+}
+
+// ManualOrder places an operator BUY or SELL. It goes through the trade guard
+// like a model decision; a blocked order returns the guard's reason.
+func (s *Scheduler) ManualOrder(action, ticker string, stopLoss, takeProfit float64, user string) (outcome executor.Outcome, blockedReason string, err error) {
+	action, ticker = strings.ToUpper(action), strings.ToUpper(strings.TrimSpace(ticker))
+	if (action != "BUY" && action != "SELL") || ticker == "" || stopLoss < 0 || takeProfit < 0 ||
+		(stopLoss > 0 && takeProfit > 0 && stopLoss >= takeProfit) {
+		return executor.Outcome{}, "", ErrBadOrder
+	}
+	d := ai.AIDecision{
+		Action:     action,
+		Ticker:     ticker,
+		StopLoss:   stopLoss,
+		TakeProfit: takeProfit,
+		Reasoning:  fmt.Sprintf("ручная заявка (%s)", user),
+		Manual:     true,
+	}
+
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+	_, blocked := s.guard.Filter([]ai.AIDecision{d})
+	if len(blocked) > 0 {
+		return executor.Outcome{}, blocked[0].Reason, nil
+	}
+	s.logger.Info("manual order", "action", action, "ticker", ticker, "user", user)
+	return s.executor.Execute([]ai.AIDecision{d})[0], "", nil
+}
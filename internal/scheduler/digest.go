@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/analytics"
+	"github.com/camuig/rus-trader/internal/guard"
+)
+
+// reportPeriodDays is the trailing window of the risk metrics in reports.
+const reportPeriodDays = 30
+
+// sessionClosedMinute is 18:50 MSK: reports go out after the main session.
+const sessionClosedMinute = 18*60 + 50
+
+// chart size in pixels
+const (
+	chartWidth  = 800
+	chartHeight = 360
+)
+
+// sendReports sends the daily digest once per trading day after the close and
+// the weekly digest on Friday.
+func (s *Scheduler) sendReports() {
+	tg := s.config.Telegram
+	if !tg.DailyReport && !tg.WeeklyReport {
+		return
+	}
+	now := time.Now().In(s.loc)
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday || now.Hour()*60+now.Minute() <= sessionClosedMinute {
+		return
+	}
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+
+	if today := now.Format("2006-01-02"); tg.DailyReport && s.reportSentDay != today {
+		if s.sendDigest(dayStart, now, false) {
+			s.reportSentDay = today
+		}
+	}
+	year, week := now.ISOWeek()
+	if thisWeek := fmt.Sprintf("%d-W%02d", year, week); tg.WeeklyReport && now.Weekday() == time.Friday && s.weeklySentWeek != thisWeek {
+		weekStart := dayStart.AddDate(0, 0, -int(now.Weekday()-time.Monday))
+		if s.sendDigest(weekStart, now, true) {
+			s.weeklySentWeek = thisWeek
+		}
+	}
+}
+
+func (s *Scheduler) sendDigest(from, to time.Time, weekly bool) bool {
+	digest, err := s.buildDigest(from, to, weekly)
+	if err != nil {
+		s.logger.Error("build report", "weekly", weekly, "error", err)
+		return false
+	}
+
+	var chart []byte
+	if s.config.Telegram.ReportChart && len(digest.Period.Equity) >= 2 {
+		chart, err = analytics.EquityChartPNG(digest.Period.Equity, chartWidth, chartHeight)
+		if err != nil {
+			s.logger.Error("draw equity chart", "error", err)
+		}
+	}
+	s.notifier.NotifyDigest(digest, chart)
+	return true
+}
+
+// buildDigest collects the digest data of [from, to) from the repository.
+func (s *Scheduler) buildDigest(from, to time.Time, weekly bool) (analytics.Digest, error) {
+	in := analytics.DigestInput{
+		Weekly:        weekly,
+		From:          from,
+		To:            to,
+		TrailingDays:  reportPeriodDays,
+		CommissionPct: s.config.Trading.CommissionPct,
+	}
+	var err error
+	if in.Period, err = s.buildReport(from, to); err != nil {
+		return analytics.Digest{}, err
+	}
+	if in.Trailing, err = s.buildReport(to.AddDate(0, 0, -reportPeriodDays), to); err != nil {
+		return analytics.Digest{}, err
+	}
+	if in.Trades, err = s.repo.GetTradesBetween(from, to); err != nil {
+		return analytics.Digest{}, err
+	}
+	if in.RoundTrips, err = s.repo.GetRoundTripsClosedBetween(from, to); err != nil {
+		return analytics.Digest{}, err
+	}
+	if in.OpenTrades, err = s.repo.GetOpenTrades(); err != nil {
+		return analytics.Digest{}, err
+	}
+	if in.Cycles, err = s.repo.GetCycleSummariesBetween(from, to); err != nil {
+		return analytics.Digest{}, err
+	}
+	for _, c := range in.Cycles {
+		blocked, err := guard.BlockedFromJSON(c.BlockedJSON)
+		if err != nil {
+			s.logger.Warn("parse blocked decisions", "analysis_log", c.ID, "error", err)
+			continue
+		}
+		for _, b := range blocked {
+			in.BlockReasons = append(in.BlockReasons, guard.ReasonCategory(b.Reason))
+		}
+	}
+
+	// Unrealised P&L and current prices come from the latest snapshot, saved every cycle.
+	if snapshot, err := s.repo.GetLatestSnapshot(); err == nil && snapshot.PositionsJSON != "" {
+		if err := json.Unmarshal([]byte(snapshot.PositionsJSON), &in.Positions); err != nil {
+			s.logger.Warn("parse snapshot positions", "error", err)
+		}
+	}
+	return analytics.NewDigest(in), nil
+}
+
+func (s *Scheduler) buildReport(from, to time.Time) (analytics.Report, error) {
+	snapshots, err := s.repo.GetSnapshotsBetween(from, to)
+	if err != nil {
+		return analytics.Report{}, err
+	}
+	trades, err := s.repo.GetRoundTripsClosedBetween(from, to)
+	if err != nil {
+		return analytics.Report{}, err
+	}
+	return analytics.Build(snapshots, trades, from, to, s.loc), nil
+}
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/moex"
+)
+
+// CycleHealth is the state of the scheduler loop for readiness checks.
+type CycleHealth struct {
+	Started     time.Time // scheduler start
+	LastOK      time.Time // last cycle that completed or was skipped on schedule
+	LastFailure time.Time
+	LastError   string
+	Paused      bool
+}
+
+type cycleHealth struct {
+	mu    sync.Mutex
+	state CycleHealth
+}
+
+func (h *cycleHealth) start() {
+	h.mu.Lock()
+	h.state.Started = time.Now()
+	h.mu.Unlock()
+}
+
+func (h *cycleHealth) record(ok bool, errText string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ok {
+		h.state.LastOK = time.Now()
+		return
+	}
+	h.state.LastFailure = time.Now()
+	h.state.LastError = errText
+}
+
+// CycleHealth reports when the last cycle ran and how the last failure ended.
+func (s *Scheduler) CycleHealth() CycleHealth {
+	s.health.mu.Lock()
+	h := s.health.state
+	s.health.mu.Unlock()
+	h.Paused = s.paused.Load()
+	return h
+}
+
+// CycleDeadline is how long a healthy scheduler may go without a completed
+// cycle: two intervals plus one full AI timeout and the retry delay.
+func (s *Scheduler) CycleDeadline() time.Duration {
+	return 2*s.config.TradingInterval() + s.config.DeepSeekTimeout() + retryDelay
+}
+
+// NewsSources reports the health of the configured news sources.
+func (s *Scheduler) NewsSources() []moex.FeedStatus {
+	return s.news.Status()
+}
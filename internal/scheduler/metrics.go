@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/camuig/rus-trader/internal/ai"
+	"github.com/camuig/rus-trader/internal/metrics"
+)
+
+// stageTimer records the duration of consecutive analysis cycle stages.
+type stageTimer struct {
+	start time.Time
+}
+
+func newStageTimer() *stageTimer {
+	return &stageTimer{start: time.Now()}
+}
+
+// done records the stage that just finished and starts timing the next one.
+func (t *stageTimer) done(stage string) {
+	now := time.Now()
+	metrics.CycleStageSeconds.Observe(now.Sub(t.start).Seconds(), stage)
+	t.start = now
+}
+
+// recordCycle counts a finished cycle: "success", "failure" or "skipped".
+func recordCycle(result string, duration time.Duration) {
+	metrics.Cycles.Inc(result)
+	if result == "skipped" {
+		return
+	}
+	metrics.CycleSeconds.Observe(duration.Seconds())
+	if result == "success" {
+		metrics.LastSuccess.Set(float64(time.Now().Unix()))
+	}
+}
+
+// recordAI records latency, token usage and cost of an AI request. The result
+// may be partial or nil when the request failed.
+func recordAI(result *ai.AnalysisResult, err error) {
+	if err != nil {
+		metrics.AIErrors.Inc()
+	}
+	if result == nil {
+		return
+	}
+	u := result.Usage
+	if u.Latency > 0 {
+		metrics.AISeconds.Observe(u.Latency.Seconds())
+	}
+	metrics.AITokens.Add(float64(u.PromptTokens), "prompt")
+	metrics.AITokens.Add(float64(u.CachedTokens), "cached")
+	metrics.AITokens.Add(float64(u.CompletionTokens), "completion")
+	metrics.AITokens.Add(float64(u.ReasoningTokens), "reasoning")
+	metrics.AICostRub.Add(result.CostRub)
+	for _, d := range result.Decisions {
+		metrics.Decisions.Inc(d.Action)
+	}
+}
@@ -4,42 +4,75 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/camuig/rus-trader/internal/ai"
 	"github.com/camuig/rus-trader/internal/broker"
+	"github.com/camuig/rus-trader/internal/calibration"
 	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/corpevents"
+	"github.com/camuig/rus-trader/internal/events"
 	"github.com/camuig/rus-trader/internal/executor"
 	"github.com/camuig/rus-trader/internal/guard"
 	"github.com/camuig/rus-trader/internal/indicators"
 	"github.com/camuig/rus-trader/internal/logger"
 	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/newsstore"
+	"github.com/camuig/rus-trader/internal/notify"
+	"github.com/camuig/rus-trader/internal/regime"
 	"github.com/camuig/rus-trader/internal/screener"
 	"github.com/camuig/rus-trader/internal/storage"
-	"github.com/camuig/rus-trader/internal/telegram"
+	"github.com/camuig/rus-trader/internal/universe"
 )
 
 type Scheduler struct {
 	broker   *broker.BrokerClient
 	moex     *moex.Client
+	names    *moex.NameDirectory
+	news     *moex.NewsFeeds
+	seen     *newsstore.Store
+	calendar *corpevents.Calendar
+	market   *regime.Monitor
+	universe *universe.Universe
 	ai       *ai.DeepSeekClient
 	executor *executor.Executor
 	repo     *storage.Repository
-	notifier *telegram.Notifier
+	notifier *notify.Dispatcher
+	events   *events.Bus
 	guard    *guard.TradeGuard
 	config   *config.Config
 	logger   *logger.Logger
 	loc      *time.Location
+
+	budgetNotifiedDay string // date (MSK) the AI budget alert was last sent
+	reportSentDay     string // date (MSK) the daily report was last sent
+	weeklySentWeek    string // ISO week (MSK) the weekly report was last sent
+
+	paused  atomic.Bool   // scheduled cycles suspended by an operator
+	trigger chan struct{} // forced cycle requests
+	tradeMu sync.Mutex    // serialises guard filtering and execution with operator orders
+
+	health cycleHealth
 }
 
 func NewScheduler(
 	bc *broker.BrokerClient,
 	moexClient *moex.Client,
+	names *moex.NameDirectory,
+	news *moex.NewsFeeds,
+	seen *newsstore.Store,
+	calendar *corpevents.Calendar,
+	market *regime.Monitor,
+	u *universe.Universe,
 	aiClient *ai.DeepSeekClient,
 	exec *executor.Executor,
 	repo *storage.Repository,
-	notifier *telegram.Notifier,
+	notifier *notify.Dispatcher,
+	bus *events.Bus,
 	g *guard.TradeGuard,
 	cfg *config.Config,
 	log *logger.Logger,
@@ -47,14 +80,22 @@ func NewScheduler(
 	return &Scheduler{
 		broker:   bc,
 		moex:     moexClient,
+		names:    names,
+		news:     news,
+		seen:     seen,
+		calendar: calendar,
+		market:   market,
+		universe: u,
 		ai:       aiClient,
 		executor: exec,
 		repo:     repo,
 		notifier: notifier,
+		events:   bus,
 		guard:    g,
 		config:   cfg,
 		logger:   log,
 		loc:      cfg.MOEXLocation(),
+		trigger:  make(chan struct{}, 1),
 	}
 }
 
@@ -66,6 +107,7 @@ func (s *Scheduler) Run(ctx context.Context) {
 	defer ticker.Stop()
 
 	s.logger.Info("scheduler started", "interval", interval.String())
+	s.health.start()
 
 	// Run immediately on start
 	s.runWithRetry(ctx)
@@ -76,13 +118,23 @@ func (s *Scheduler) Run(ctx context.Context) {
 			s.logger.Info("scheduler stopped")
 			return
 		case <-ticker.C:
+			if s.paused.Load() {
+				s.logger.Info("scheduler paused, skipping cycle")
+				s.sendReports() // reports are due after the close, paused or not
+				recordCycle("skipped", 0)
+				s.health.record(true, "")
+				continue
+			}
 			s.runWithRetry(ctx)
+		case <-s.trigger:
+			s.logger.Info("running forced cycle")
+			s.runCycle(ctx)
 		}
 	}
 }
 
 func (s *Scheduler) runWithRetry(ctx context.Context) {
-	if s.runCycle(ctx) {
+	if s.runCycle(ctx) || s.paused.Load() {
 		return
 	}
 	s.logger.Info("cycle failed, retrying", "delay", retryDelay)
@@ -105,26 +157,53 @@ func (s *Scheduler) runCycle(ctx context.Context) (ok bool) {
 
 	if !s.isWithinTradingHours() {
 		s.logger.Info("outside trading hours, skipping cycle")
+		s.sendReports()
+		recordCycle("skipped", 0)
+		s.health.record(true, "")
 		return true // not an error, no retry needed
 	}
 
 	s.logger.Info("starting analysis cycle")
+	started := time.Now()
+	summary := events.CycleFinishedData{}
+	s.events.Publish(events.CycleStarted, nil)
+	defer func() {
+		summary.OK = ok
+		summary.DurationMs = time.Since(started).Milliseconds()
+		s.events.Publish(events.CycleFinished, summary)
+		if ok {
+			recordCycle("success", time.Since(started))
+		} else {
+			recordCycle("failure", time.Since(started))
+		}
+		s.health.record(ok, summary.Error)
+	}()
+	stages := newStageTimer()
 
-	// 1. Fetch top tickers from MOEX (fetch more, filter later)
-	topTickers, err := s.moex.FetchTopTickers(ctx, 50)
+	// 1. Today's universe (fetch more, filter later); the plain top by
+	// turnover stands in while it cannot be built
+	tickerNames, err := s.universe.Tickers(ctx, time.Now())
 	if err != nil {
-		s.logger.Error("fetch top tickers", "error", err)
-		s.saveAnalysisLog(0, "", "", err)
-		return false
+		s.logger.Warn("build universe, falling back to top tickers", "error", err)
+		topTickers, err := s.moex.FetchTopTickers(ctx, s.config.Universe.Size)
+		if err != nil {
+			s.logger.Error("fetch top tickers", "error", err)
+			summary.Error = err.Error()
+			s.saveAnalysisLog(0, nil, "", "", err)
+			return false
+		}
+		tickerNames = make([]string, len(topTickers))
+		for i, t := range topTickers {
+			tickerNames[i] = t.Ticker
+		}
 	}
-	s.logger.Info("top tickers fetched", "count", len(topTickers))
-
-	// 2. Resolve tickers to UIDs and filter tradable
-	tickerNames := make([]string, len(topTickers))
-	for i, t := range topTickers {
-		tickerNames[i] = t.Ticker
+	s.logger.Info("universe tickers", "count", len(tickerNames))
+	if err := s.universe.SampleSpreads(ctx, time.Now()); err != nil {
+		s.logger.Warn("sample spreads", "error", err)
 	}
+	stages.done("tickers")
 
+	// 2. Resolve tickers to UIDs and filter tradable
 	uids := make([]string, 0, len(tickerNames))
 	uidToTicker := make(map[string]string, len(tickerNames))
 	for _, t := range tickerNames {
@@ -140,7 +219,8 @@ func (s *Scheduler) runCycle(ctx context.Context) (ok bool) {
 	tradable, err := s.broker.FilterTradable(uids)
 	if err != nil {
 		s.logger.Error("filter tradable", "error", err)
-		s.saveAnalysisLog(len(topTickers), "", "", err)
+		summary.Error = err.Error()
+		s.saveAnalysisLog(len(tickerNames), nil, "", "", err)
 		return false
 	}
 
@@ -158,14 +238,18 @@ func (s *Scheduler) runCycle(ctx context.Context) (ok bool) {
 		}
 	}
 	s.logger.Info("tradable tickers", "total", len(tradableSet), "selected", len(tradableTickers))
+	stages.done("tradable")
 
 	// 3. Get portfolio early — we need position tickers before fetching candles
 	portfolio, err := s.broker.GetPortfolio()
 	if err != nil {
 		s.logger.Error("get portfolio", "error", err)
-		s.saveAnalysisLog(len(topTickers), "", "", err)
+		summary.Error = err.Error()
+		s.saveAnalysisLog(len(tickerNames), nil, "", "", err)
 		return false
 	}
+	s.events.Publish(events.Portfolio, portfolio)
+	stages.done("portfolio")
 
 	// 4. Ensure tickers with open positions are always included (even beyond limit)
 	for _, pos := range portfolio.Positions {
@@ -185,62 +269,110 @@ func (s *Scheduler) runCycle(ctx context.Context) (ok bool) {
 	concurrency := s.config.Trading.CandleConcurrency
 	allSnapshots := s.broker.FetchCandleSnapshots(tradableTickers, concurrency)
 	s.logger.Info("candle snapshots fetched", "count", len(allSnapshots))
+	stages.done("candles")
 
-	// 5a. Screen tickers by technical signals (keep positions, filter rest by score)
+	// 5a. Fetch news, mark fresh ones, filter by tickers and aggregate their sentiment
+	headlines, err := s.news.Fetch(ctx)
+	if err != nil {
+		// non-fatal, continue without news
+		s.logger.Error("fetch news", "error", err)
+	}
+	headlines = s.seen.Mark(headlines, time.Now())
+	s.refreshNames(ctx)
+	tickerNews := s.names.FilterNews(headlines.Tickers, tradableTickers)
+	newsSentiment := make(map[string]float64, len(tickerNews))
+	for ticker, items := range tickerNews {
+		newsSentiment[ticker] = moex.NewsSentiment(items, time.Now())
+		// fresh headlines first so they survive the prompt item limit
+		sort.SliceStable(items, func(i, j int) bool { return items[i].New && !items[j].New })
+	}
+
+	// 5b. Screen tickers by technical signals and news (keep positions, filter rest by score)
 	positionTickers := make(map[string]bool, len(portfolio.Positions))
 	for _, pos := range portfolio.Positions {
 		positionTickers[pos.Ticker] = true
 	}
-	snapshots := screener.Screen(allSnapshots, positionTickers, s.config.Trading.MaxAnalysisTickers)
+	snapshots := screener.Screen(allSnapshots, positionTickers, newsSentiment, s.config.Trading.MaxAnalysisTickers)
 	s.logger.Info("screened tickers", "before", len(allSnapshots), "after", len(snapshots))
 
+	// 5c. Exits-only mode once the daily AI budget is spent: analyse open positions only
+	exitsOnly := s.aiBudgetExceeded()
+	if exitsOnly {
+		positionSnapshots := make([]broker.CandleSnapshot, 0, len(positionTickers))
+		for _, snap := range snapshots {
+			if positionTickers[snap.Ticker] {
+				positionSnapshots = append(positionSnapshots, snap)
+			}
+		}
+		snapshots = positionSnapshots
+		if len(snapshots) == 0 {
+			s.logger.Info("AI budget exceeded and no open positions, skipping cycle")
+			return true
+		}
+		s.logger.Info("AI budget exceeded, exits-only mode", "positions", len(snapshots))
+	}
+
 	// 6. Fetch ticker briefs (cached, non-fatal)
 	tickerBriefs := s.fetchTickerBriefs(tradableTickers)
 
-	// 6. Fetch news and filter by tickers
-	allNews, err := s.moex.FetchRecentNews(ctx)
-	if err != nil {
-		s.logger.Error("fetch news", "error", err)
-		// non-fatal, continue without news
-		allNews = nil
+	// 6a. Upcoming dividends and reports of analysed tickers and open positions (cached, non-fatal)
+	eventTickers := make([]string, 0, len(snapshots)+len(positionTickers))
+	for _, snap := range snapshots {
+		if !positionTickers[snap.Ticker] {
+			eventTickers = append(eventTickers, snap.Ticker)
+		}
+	}
+	for ticker := range positionTickers {
+		eventTickers = append(eventTickers, ticker)
 	}
-	tickerNews := moex.FilterNewsForTickers(allNews, tradableTickers)
+	eventsCtx, cancelEvents := context.WithTimeout(ctx, 20*time.Second)
+	corpEvents := s.calendar.Upcoming(eventsCtx, eventTickers, time.Now())
+	cancelEvents()
 
-	// 7. Fetch world news (non-fatal)
-	worldCtx, cancelWorld := context.WithTimeout(ctx, 8*time.Second)
-	worldNewsItems, err := s.moex.FetchWorldNews(worldCtx, s.config.DeepSeek.MaxWorldNewsItems*2)
-	cancelWorld()
-	if err != nil {
-		s.logger.Error("fetch world news", "error", err)
-		worldNewsItems = nil
+	// 7. Market background news, best-ranked first
+	marketNews := headlines.Market
+	if limit := s.config.DeepSeek.MaxWorldNewsItems * 2; len(marketNews) > limit {
+		marketNews = marketNews[:limit]
 	}
-	globalNews := make([]string, 0, len(worldNewsItems))
-	for _, n := range worldNewsItems {
-		if n.Source != "" {
-			globalNews = append(globalNews, fmt.Sprintf("%s: %s", n.Source, n.Title))
-		} else {
-			globalNews = append(globalNews, n.Title)
-		}
+	globalNews := make([]string, 0, len(marketNews))
+	for _, n := range marketNews {
+		globalNews = append(globalNews, freshPrefix(n)+fmt.Sprintf("%s: %s", n.Source, n.Title))
 	}
-	s.logger.Info("world news fetched", "count", len(globalNews))
+	s.logger.Info("news fetched", "tickers", len(headlines.Tickers), "market", len(headlines.Market),
+		"fresh", countFreshNews(headlines.Tickers)+countFreshNews(headlines.Market))
+	stages.done("news")
+
+	// 7a. Market context: index, bond, ruble and oil trends (cached, non-fatal)
+	marketCtx, cancelMarket := context.WithTimeout(ctx, 20*time.Second)
+	market, marketOK := s.market.Update(marketCtx, time.Now())
+	cancelMarket()
 
 	// 8. Build TickerAnalysis with OHLCV data, indicators, and news
 	tickerAnalyses := make([]ai.TickerAnalysis, 0, len(snapshots))
 	for _, snap := range snapshots {
 		ta := ai.TickerAnalysis{
-			Ticker:     snap.Ticker,
-			Brief:      tickerBriefs[snap.Ticker],
-			LastPrice:  snap.LastPrice,
-			Period3h:   toPeriodData(snap.Period3h),
-			Period1d:   toPeriodData(snap.Period1d),
-			Period3d:   toPeriodData(snap.Period3d),
-			Period1w:   toPeriodData(snap.Period1w),
-			Indicators: snap.Indicators,
+			Ticker:        snap.Ticker,
+			Brief:         tickerBriefs[snap.Ticker],
+			LastPrice:     snap.LastPrice,
+			Period3h:      toPeriodData(snap.Period3h),
+			Period1d:      toPeriodData(snap.Period1d),
+			Period3d:      toPeriodData(snap.Period3d),
+			Period1w:      toPeriodData(snap.Period1w),
+			NewsSentiment: newsSentiment[snap.Ticker],
+			Indicators:    snap.Indicators,
+		}
+		for _, e := range corpEvents[snap.Ticker] {
+			ta.Events = append(ta.Events, e.Describe(time.Now(), s.loc))
 		}
 
 		if items, ok := tickerNews[snap.Ticker]; ok {
 			for _, n := range items {
-				ta.News = append(ta.News, n.Title)
+				if s.config.DeepSeek.Tools.Enabled && n.HasText {
+					// expose the id so the model can request the full text
+					ta.News = append(ta.News, freshPrefix(n)+fmt.Sprintf("[#%d] %s", n.ID, n.Title))
+				} else {
+					ta.News = append(ta.News, freshPrefix(n)+n.Title)
+				}
 			}
 		}
 
@@ -285,6 +417,7 @@ func (s *Scheduler) runCycle(ctx context.Context) (ok bool) {
 
 	// 11a. Fetch performance stats for AI context
 	stats := s.fetchPerformanceStats()
+	stages.done("context")
 
 	// 12. AI analysis
 	analysisReq := &ai.AnalysisRequest{
@@ -298,27 +431,54 @@ func (s *Scheduler) runCycle(ctx context.Context) (ok bool) {
 		Stats:        stats,
 		CurrentTime:  time.Now().In(s.loc),
 	}
+	if marketOK {
+		analysisReq.Market = toMarketContext(market)
+	}
+	if s.config.DeepSeek.Tools.Enabled {
+		analysisReq.Tools = &agentTools{broker: s.broker, moex: s.moex, repo: s.repo}
+	}
 
-	decisions, rawResponse, err := s.ai.Analyze(ctx, analysisReq, todayTraded)
+	result, err := s.ai.Analyze(ctx, analysisReq, todayTraded)
+	stages.done("ai")
+	recordAI(result, err)
 	if err != nil {
 		s.logger.Error("AI analysis", "error", err)
-		s.saveAnalysisLog(len(tradableTickers), rawResponse, "", err)
+		summary.Error = err.Error()
+		s.saveAnalysisLog(len(tradableTickers), result, "", "", err)
 		return false
 	}
+	decisions := result.Decisions
 
 	s.logger.Info("AI decisions received", "count", len(decisions))
+	summary.Decisions = len(decisions)
 	for _, d := range decisions {
 		s.logger.Info("AI decision",
 			"action", d.Action, "ticker", d.Ticker,
 			"confidence", d.Confidence, "reasoning", d.Reasoning)
+		s.events.Publish(events.Decision, events.DecisionData{
+			Ticker: d.Ticker, Action: d.Action, Confidence: d.Confidence, Reasoning: d.Reasoning,
+		})
 	}
 
-	// 13. Set indicators in guard for pre-validation and apply filter
+	// 13. Set indicators in guard for pre-validation and apply filter.
+	// Operator orders wait until execution below is finished.
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+	s.guard.SetExitsOnly(exitsOnly)
 	indicatorsMap := make(map[string]indicators.Indicators, len(snapshots))
 	for _, snap := range snapshots {
 		indicatorsMap[snap.Ticker] = snap.Indicators
 	}
 	s.guard.SetIndicators(indicatorsMap)
+	s.guard.SetNewsSentiment(newsSentiment)
+	s.guard.SetCorporateEvents(corpEvents)
+	s.guard.SetRegime(market.Regime)
+	for _, exit := range s.guard.ReportExits() {
+		if !hasDecision(decisions, exit.Ticker, "SELL") {
+			s.logger.Info("report exit", "ticker", exit.Ticker, "reasoning", exit.Reasoning)
+			decisions = append(decisions, exit)
+		}
+	}
 	allowed, blocked := s.guard.Filter(decisions)
 	for _, b := range blocked {
 		s.notifier.NotifyBlocked(b.Decision.Ticker, b.Decision.Action, b.Reason)
@@ -330,23 +490,79 @@ func (s *Scheduler) runCycle(ctx context.Context) (ok bool) {
 	}
 	s.logger.Info("guard filter applied",
 		"allowed", len(allowedDecisions), "blocked", len(blocked))
+	summary.Allowed, summary.Blocked = len(allowedDecisions), len(blocked)
+	stages.done("guard")
 
 	// 13a. Update trailing stops for open positions
 	if s.config.Trading.TrailingStopEnabled {
 		s.updateTrailingStops(portfolio)
 	}
 
-	// 14. Execute decisions
-	s.executor.Execute(allowedDecisions)
+	// 14. Save the analysis log before execution so trades can reference the cycle
+	analysisLog := s.saveAnalysisLog(len(tradableTickers), result, executor.DecisionsToJSON(decisions), guard.BlockedToJSON(blocked), nil)
+	for i := range allowedDecisions {
+		allowedDecisions[i].AnalysisLogID = analysisLog.ID
+	}
 
-	// 12. Save analysis log and portfolio snapshot
-	s.saveAnalysisLog(len(tradableTickers), rawResponse, executor.DecisionsToJSON(decisions), nil)
+	// 15. Execute decisions and record the outcomes
+	if s.config.Trading.Calibration.Enabled {
+		s.executor.SetCalibration(s.fitCalibration())
+	}
+	outcomes := s.executor.Execute(allowedDecisions)
+	if analysisLog.ID != 0 {
+		if err := s.repo.SetAnalysisExecution(analysisLog.ID, executor.OutcomesToJSON(outcomes)); err != nil {
+			s.logger.Error("save execution outcomes", "error", err)
+		}
+	}
+	stages.done("execute")
 	s.savePortfolioSnapshot(portfolio)
 
 	s.logger.Info("analysis cycle completed")
 	return true
 }
 
+func toMarketContext(snap regime.Snapshot) *ai.MarketContext {
+	mc := &ai.MarketContext{
+		Regime:     string(snap.Regime),
+		Reasons:    snap.Reasons,
+		KeyRatePct: snap.KeyRatePct,
+	}
+	for _, s := range snap.Series {
+		mc.Lines = append(mc.Lines, s.Describe())
+	}
+	if len(snap.Missing) > 0 {
+		mc.Lines = append(mc.Lines, "Не обновились: "+strings.Join(snap.Missing, ", "))
+	}
+	return mc
+}
+
+func hasDecision(decisions []ai.AIDecision, ticker, action string) bool {
+	for _, d := range decisions {
+		if d.Ticker == ticker && d.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// freshPrefix tags headlines first seen in this cycle for the prompt.
+func freshPrefix(n moex.NewsItem) string {
+	if n.New {
+		return ai.FreshNewsTag + " "
+	}
+	return ""
+}
+
+func countFreshNews(items []moex.NewsItem) int {
+	n := 0
+	for _, item := range items {
+		if item.New {
+			n++
+		}
+	}
+	return n
+}
+
 func toPeriodData(p broker.PeriodOHLCV) ai.PeriodData {
 	var changePct float64
 	if p.Open > 0 {
@@ -379,11 +595,36 @@ func (s *Scheduler) isWithinTradingHours() bool {
 	return totalMinutes >= 600 && totalMinutes <= 1130
 }
 
-func (s *Scheduler) saveAnalysisLog(tickersCount int, rawResponse, decisionsJSON string, err error) {
+// saveAnalysisLog stores the cycle; the returned log has ID 0 if saving failed.
+func (s *Scheduler) saveAnalysisLog(tickersCount int, result *ai.AnalysisResult, decisionsJSON, blockedJSON string, err error) *storage.AnalysisLog {
 	log := &storage.AnalysisLog{
 		SignalsCount:  tickersCount,
-		AIResponse:    rawResponse,
 		DecisionsJSON: decisionsJSON,
+		BlockedJSON:   blockedJSON,
+	}
+	if result != nil {
+		log.AIResponse = result.RawResponse
+		log.Reasoning = result.Reasoning
+		log.ReasoningMs = result.ReasoningDuration.Milliseconds()
+		log.AnswerMs = result.AnswerDuration.Milliseconds()
+		log.Model = result.Model
+		log.PromptVersion = result.PromptVersion
+		log.SystemPrompt = result.SystemPrompt
+		log.UserPrompt = result.UserPrompt
+		log.PromptTokens = result.Usage.PromptTokens
+		log.CompletionTokens = result.Usage.CompletionTokens
+		log.ReasoningTokens = result.Usage.ReasoningTokens
+		log.TokensEstimated = result.Usage.Estimated
+		log.LatencyMs = result.Usage.Latency.Milliseconds()
+		log.CostUSD = result.CostUSD
+		log.CostRub = result.CostRub
+		log.ToolCallsCount = len(result.ToolCalls)
+		log.ToolMs = result.ToolDuration.Milliseconds()
+		if len(result.ToolCalls) > 0 {
+			if data, err := json.Marshal(result.ToolCalls); err == nil {
+				log.ToolCallsJSON = string(data)
+			}
+		}
 	}
 	if err != nil {
 		log.Error = err.Error()
@@ -391,6 +632,50 @@ func (s *Scheduler) saveAnalysisLog(tickersCount int, rawResponse, decisionsJSON
 	if dbErr := s.repo.SaveAnalysisLog(log); dbErr != nil {
 		s.logger.Error("save analysis log", "error", dbErr)
 	}
+	return log
+}
+
+// aiBudgetExceeded reports whether today's AI spend has reached deepseek.daily_budget_rub.
+// Notifies once per day when the budget is first exceeded.
+func (s *Scheduler) aiBudgetExceeded() bool {
+	budget := s.config.DeepSeek.DailyBudgetRub
+	if budget <= 0 {
+		return false
+	}
+
+	spent, err := s.repo.GetTodayAICostRub()
+	if err != nil {
+		s.logger.Error("get today AI cost", "error", err)
+		return false
+	}
+	if spent < budget {
+		return false
+	}
+
+	today := time.Now().In(s.loc).Format("2006-01-02")
+	if s.budgetNotifiedDay != today {
+		s.budgetNotifiedDay = today
+		s.notifier.NotifyWarning(fmt.Sprintf("💸 Дневной бюджет AI исчерпан (%.2f / %.2f ₽) — только выходы из позиций", spent, budget))
+	}
+	return true
+}
+
+// fitCalibration fits the confidence calibration curve on recent closed trades.
+// Returns nil (raw confidence) until enough outcomes are available.
+func (s *Scheduler) fitCalibration() *calibration.Curve {
+	trades, err := s.repo.GetClosedBuyTradesSince(s.config.CalibrationSince())
+	if err != nil {
+		s.logger.Error("get trades for calibration", "error", err)
+		return nil
+	}
+	cc := s.config.Trading.Calibration
+	buckets := calibration.BuildBuckets(calibration.OutcomesFromTrades(trades), cc.BucketSize)
+	curve := calibration.Fit(buckets, cc.MinTrades)
+	if curve == nil {
+		s.logger.Info("confidence calibration not applied: not enough closed trades",
+			"trades", len(trades), "min", cc.MinTrades)
+	}
+	return curve
 }
 
 func (s *Scheduler) savePortfolioSnapshot(portfolio *broker.PortfolioInfo) {
@@ -500,6 +785,23 @@ func (s *Scheduler) fetchPerformanceStats() ai.PerformanceStats {
 	}
 }
 
+// refreshNames reloads the ticker name directory when it is due. Failures are
+// logged and matching continues with the names already loaded.
+func (s *Scheduler) refreshNames(ctx context.Context) {
+	if !s.names.Stale() {
+		return
+	}
+	brokerNames, err := s.broker.ShareNames()
+	if err != nil {
+		s.logger.Warn("fetch broker share names", "error", err)
+	}
+	refreshCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if err := s.names.Refresh(refreshCtx, s.moex, brokerNames); err != nil {
+		s.logger.Error("refresh ticker names", "error", err)
+	}
+}
+
 func (s *Scheduler) fetchTickerBriefs(tickers []string) map[string]string {
 	if len(tickers) == 0 {
 		return nil
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/broker"
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+const positionHistoryLimit = 20
+
+// agentTools serves the model's tool calls from the broker, MOEX ISS and the trade history.
+type agentTools struct {
+	broker *broker.BrokerClient
+	moex   *moex.Client
+	repo   *storage.Repository
+}
+
+type positionHistoryEntry struct {
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	Price      float64   `json:"price"`
+	Quantity   int64     `json:"quantity"`
+	StopLoss   float64   `json:"stop_loss,omitempty"`
+	TakeProfit float64   `json:"take_profit,omitempty"`
+	PnL        float64   `json:"pnl,omitempty"`
+	Status     string    `json:"status"`
+	Reasoning  string    `json:"reasoning,omitempty"`
+}
+
+func (t *agentTools) Candles(ctx context.Context, ticker, interval string, count int) (any, error) {
+	return withContext(ctx, func() (any, error) {
+		return t.broker.GetBars(ticker, interval, count)
+	})
+}
+
+func (t *agentTools) NewsText(ctx context.Context, newsID int64) (any, error) {
+	return t.moex.FetchNewsText(ctx, newsID)
+}
+
+func (t *agentTools) OrderBook(ctx context.Context, ticker string, depth int) (any, error) {
+	return withContext(ctx, func() (any, error) {
+		uid, err := t.broker.ResolveTickerToUID(ticker)
+		if err != nil {
+			return nil, err
+		}
+		return t.broker.GetOrderBook(uid, int32(depth))
+	})
+}
+
+func (t *agentTools) PositionHistory(_ context.Context, ticker string) (any, error) {
+	trades, err := t.repo.GetTradesByTicker(ticker, positionHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]positionHistoryEntry, 0, len(trades))
+	for _, tr := range trades {
+		history = append(history, positionHistoryEntry{
+			Time:       tr.CreatedAt,
+			Action:     tr.Action,
+			Price:      tr.Price,
+			Quantity:   tr.Quantity,
+			StopLoss:   tr.StopLossPrice,
+			TakeProfit: tr.TakeProfitPrice,
+			PnL:        tr.PnL,
+			Status:     tr.Status,
+			Reasoning:  tr.Reasoning,
+		})
+	}
+	return history, nil
+}
+
+// withContext runs a broker call that takes no context and returns when it
+// finishes or ctx is done, whichever comes first. An abandoned call finishes
+// in the background.
+func withContext(ctx context.Context, call func() (any, error)) (any, error) {
+	type outcome struct {
+		v   any
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		v, err := call()
+		done <- outcome{v, err}
+	}()
+	select {
+	case o := <-done:
+		return o.v, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -14,9 +14,10 @@ type Score struct {
 	Reason string
 }
 
-// Screen filters and ranks candle snapshots by technical signal strength.
+// Screen filters and ranks candle snapshots by technical signal strength and
+// news sentiment (ticker -> [-1, 1], may be nil).
 // Returns up to maxTickers best candidates. Position tickers are always included.
-func Screen(snapshots []broker.CandleSnapshot, positionTickers map[string]bool, maxTickers int) []broker.CandleSnapshot {
+func Screen(snapshots []broker.CandleSnapshot, positionTickers map[string]bool, newsSentiment map[string]float64, maxTickers int) []broker.CandleSnapshot {
 	if maxTickers <= 0 {
 		maxTickers = 5
 	}
@@ -27,7 +28,7 @@ func Screen(snapshots []broker.CandleSnapshot, positionTickers map[string]bool,
 		if positionTickers[snap.Ticker] {
 			continue
 		}
-		points := scoreSnapshot(snap.Indicators)
+		points := scoreSnapshot(snap.Indicators) + scoreSentiment(newsSentiment[snap.Ticker])
 		if points > 0 {
 			scores = append(scores, Score{
 				Ticker: snap.Ticker,
@@ -120,3 +121,19 @@ func scoreSnapshot(ind indicators.Indicators) float64 {
 
 	return points
 }
+
+// scoreSentiment rewards tickers with good news and demotes those with bad
+// news, which the guard may block from buying anyway.
+func scoreSentiment(s float64) float64 {
+	switch {
+	case s >= 0.6:
+		return 2 // strong positive news (dividends, buyback)
+	case s >= 0.3:
+		return 1
+	case s <= -0.6:
+		return -2 // strong negative news (sanctions, SPO)
+	case s <= -0.3:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,184 @@
+package sentiment
+
+// termKind describes how a lexicon term affects the score.
+type termKind int
+
+const (
+	// polar terms carry their own weight, e.g. "дивиденды", "sanctions".
+	polar termKind = iota
+	// negators flip the sign of the next polar term, or of the one before
+	// when none follows: "не", "без", "not", "сняты".
+	negator
+	// reversers flip the polar term next to them, before or after, and keep
+	// their own weight when there is none: "снижение прибыли" and "убыток
+	// сократился" read as a change of sign, a bare "акции снизились" is negative.
+	reverser
+	// boosters keep the sign of the polar term next to them and keep their own
+	// weight when there is none: "рост убытка" is negative, "акции выросли"
+	// positive.
+	booster
+)
+
+type term struct {
+	stem   string
+	weight float64
+	kind   termKind
+	exact  bool // the token must equal the stem
+}
+
+// modifierWindow is how many tokens a negator, reverser or booster reaches.
+const modifierWindow = 3
+
+// maxSuffixRunes limits the inflection a stem may match, so that "дивиденд"
+// matches "дивидендов" but not "дивидендоориентированный".
+const maxSuffixRunes = 4
+
+// lexicon lists financial terms in Russian and English. Stems are lower-case
+// with "ё" replaced by "е". Event keywords (dividends, buyback, sanctions,
+// SPO, default) carry higher weights than general tone words.
+var lexicon = []term{
+	// corporate events
+	{stem: "дивиденд", weight: 2},
+	{stem: "dividend", weight: 2},
+	{stem: "выкуп", weight: 2},
+	{stem: "байбек", weight: 2},
+	{stem: "buyback", weight: 2},
+	{stem: "repurchas", weight: 2},
+	{stem: "сплит", weight: 0.5},
+	{stem: "split", weight: 0.5, exact: true},
+	{stem: "санкци", weight: -2},
+	{stem: "санкцион", weight: -2},
+	{stem: "sanction", weight: -2},
+	{stem: "spo", weight: -2, exact: true},
+	{stem: "допэмисси", weight: -2},
+	{stem: "dilution", weight: -2},
+	{stem: "дефолт", weight: -3},
+	{stem: "default", weight: -3},
+	{stem: "банкротств", weight: -3},
+	{stem: "bankrupt", weight: -3},
+	{stem: "делистинг", weight: -2},
+	{stem: "delist", weight: -2},
+	{stem: "национализ", weight: -2},
+	{stem: "nationaliz", weight: -2},
+
+	// results and tone
+	{stem: "прибыл", weight: 1},
+	{stem: "profit", weight: 1},
+	{stem: "earnings", weight: 0.5, exact: true},
+	{stem: "выручк", weight: 0.5},
+	{stem: "revenue", weight: 0.5},
+	{stem: "убыт", weight: -1.5},
+	{stem: "loss", weight: -1.5},
+	{stem: "рекорд", weight: 1.5},
+	{stem: "record", weight: 1.5},
+	{stem: "превыс", weight: 1},
+	{stem: "превыш", weight: 1},
+	{stem: "beat", weight: 1, exact: true},
+	{stem: "beats", weight: 1, exact: true},
+	{stem: "miss", weight: -1, exact: true},
+	{stem: "missed", weight: -1, exact: true},
+	{stem: "misses", weight: -1, exact: true},
+	{stem: "повыси", weight: 1},
+	{stem: "upgrad", weight: 1.5},
+	{stem: "downgrad", weight: -1.5},
+	{stem: "штраф", weight: -1},
+	{stem: "fine", weight: -1, exact: true},
+	{stem: "fined", weight: -1, exact: true},
+	{stem: "иск", weight: -1, exact: true},
+	{stem: "иска", weight: -1, exact: true},
+	{stem: "lawsuit", weight: -1},
+	{stem: "обыск", weight: -1.5},
+	{stem: "арест", weight: -1.5},
+	{stem: "авари", weight: -1.5},
+	{stem: "пожар", weight: -1},
+	{stem: "обвал", weight: -2},
+	{stem: "crash", weight: -2},
+	{stem: "plung", weight: -2},
+	{stem: "slump", weight: -1.5},
+	{stem: "surg", weight: 1.5},
+	{stem: "soar", weight: 1.5},
+	{stem: "ралли", weight: 1},
+	{stem: "rally", weight: 1},
+	{stem: "rallies", weight: 1},
+
+	// negators and cancellations
+	{stem: "не", kind: negator, exact: true},
+	{stem: "нет", kind: negator, exact: true},
+	{stem: "без", kind: negator, exact: true},
+	{stem: "ни", kind: negator, exact: true},
+	{stem: "not", kind: negator, exact: true},
+	{stem: "no", kind: negator, exact: true},
+	{stem: "never", kind: negator, exact: true},
+	{stem: "without", kind: negator, exact: true},
+	{stem: "отмен", weight: -1, kind: reverser},
+	{stem: "отказ", weight: -1, kind: reverser},
+	{stem: "приостанов", weight: -1, kind: reverser},
+	{stem: "снят", kind: negator},
+	{stem: "снял", kind: negator},
+	{stem: "сним", kind: negator},
+	{stem: "cancel", weight: -1, kind: reverser},
+	{stem: "suspend", weight: -1, kind: reverser},
+	{stem: "lift", kind: negator},
+	// passive participles, usually after the term: "выкуп аннулирован",
+	// "лицензия отозвана", "dividend scrapped"; "сняты", "отменены" and
+	// "приостановлены" match the stems above
+	{stem: "аннулирован", weight: -1, kind: reverser},
+	{stem: "отозван", weight: -1, kind: reverser},
+	{stem: "scrap", weight: -1, kind: reverser},
+	{stem: "revok", weight: -1, kind: reverser},
+	{stem: "withdrawn", weight: -1, kind: reverser, exact: true},
+
+	// direction words
+	{stem: "сниж", weight: -1, kind: reverser},
+	{stem: "снизил", weight: -1, kind: reverser},
+	{stem: "сократил", weight: -1, kind: reverser},
+	{stem: "сокращ", weight: -1, kind: reverser},
+	{stem: "паден", weight: -1, kind: reverser},
+	{stem: "упал", weight: -1, kind: reverser},
+	{stem: "подешев", weight: -1, kind: reverser},
+	{stem: "fall", weight: -1, kind: reverser},
+	{stem: "fell", weight: -1, kind: reverser, exact: true},
+	{stem: "drop", weight: -1, kind: reverser},
+	{stem: "declin", weight: -1, kind: reverser},
+	{stem: "cut", weight: -1, kind: reverser},
+	{stem: "lower", weight: -1, kind: reverser},
+	{stem: "рост", weight: 1, kind: booster, exact: true}, // not "Ростех", "Ростелеком"
+	{stem: "роста", weight: 1, kind: booster, exact: true},
+	{stem: "ростом", weight: 1, kind: booster, exact: true},
+	{stem: "росте", weight: 1, kind: booster, exact: true},
+	{stem: "вырос", weight: 1, kind: booster},
+	{stem: "увелич", weight: 1, kind: booster},
+	{stem: "подорож", weight: 1, kind: booster},
+	{stem: "rise", weight: 1, kind: booster},
+	{stem: "rose", weight: 1, kind: booster, exact: true},
+	{stem: "gain", weight: 1, kind: booster},
+	{stem: "grow", weight: 1, kind: booster},
+	{stem: "rais", weight: 1, kind: booster},
+	{stem: "jump", weight: 1, kind: booster},
+	{stem: "higher", weight: 1, kind: booster, exact: true},
+}
+
+// lookup returns the lexicon term matching token, preferring the longest stem.
+func lookup(token string) (term, bool) {
+	var best term
+	found := false
+	tokenRunes := len([]rune(token))
+	for _, t := range lexicon {
+		if t.exact {
+			if token != t.stem {
+				continue
+			}
+		} else {
+			if len(token) < len(t.stem) || token[:len(t.stem)] != t.stem {
+				continue
+			}
+			if tokenRunes-len([]rune(t.stem)) > maxSuffixRunes {
+				continue
+			}
+		}
+		if !found || len(t.stem) > len(best.stem) {
+			best, found = t, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,148 @@
+// Package sentiment scores the tone of Russian and English market headlines
+// with an offline financial lexicon.
+package sentiment
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Scores are normalised to [-1, 1]; saturation is the raw lexicon sum that
+// maps to about ±0.7, so a single event keyword already gives a clear signal.
+const saturation = 2.0
+
+// HalfLife is the age at which a headline's weight in Aggregate halves.
+const HalfLife = 6 * time.Hour
+
+// Score returns the tone of a headline in [-1, 1]: negative for bad news,
+// positive for good news and 0 when no lexicon term is found.
+func Score(text string) float64 {
+	raw := rawScore(tokenize(text))
+	if raw == 0 {
+		return 0
+	}
+	return raw / math.Sqrt(raw*raw+saturation*saturation)
+}
+
+type modifier struct {
+	term
+	sign float64 // applied to the next polar term
+	left int     // tokens it still reaches
+	back float64 // the polar term it follows, flipped when no term comes next
+}
+
+func rawScore(tokens []string) float64 {
+	var sum float64
+	var pending *modifier
+
+	// The last polar term stays open to a direction word that follows it,
+	// as in "убыток сократился".
+	var last float64
+	lastDist := modifierWindow
+
+	// expire drops the pending modifier, keeping its own weight; a negator
+	// that only follows a polar term flips it, as in "дивиденды не будут
+	// выплачены".
+	expire := func() {
+		if pending != nil {
+			sum += pending.weight
+			if pending.sign < 0 {
+				sum -= 2 * pending.back
+			}
+			pending = nil
+		}
+	}
+
+	for _, tok := range tokens {
+		t, ok := lookup(tok)
+		if !ok {
+			lastDist++
+			if pending != nil {
+				if pending.left--; pending.left <= 0 {
+					expire()
+				}
+			}
+			continue
+		}
+
+		if t.kind == polar {
+			w := t.weight
+			if pending != nil {
+				// the modifier's own weight is replaced by its effect on the term
+				w *= pending.sign
+				pending = nil
+				lastDist = modifierWindow
+			} else {
+				last, lastDist = w, 0
+			}
+			sum += w
+			continue
+		}
+
+		if pending == nil && lastDist < modifierWindow && (t.kind == reverser || t.kind == booster) {
+			if t.kind == reverser {
+				sum -= 2 * last
+			}
+			lastDist = modifierWindow
+			continue
+		}
+
+		m := &modifier{term: t, sign: 1, left: modifierWindow}
+		if t.kind == negator || t.kind == reverser {
+			m.sign = -1
+		}
+		if pending == nil && lastDist < modifierWindow {
+			m.back = last
+			lastDist = modifierWindow
+		}
+		if pending != nil {
+			// "не снизилась", "no cut": the earlier modifier applies to this one
+			m.weight *= pending.sign
+			m.sign *= pending.sign
+			pending = nil
+		}
+		pending = m
+	}
+	expire()
+	return sum
+}
+
+// tokenize splits text into lower-case words; "ё" is folded to "е".
+func tokenize(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Item is a scored headline for aggregation.
+type Item struct {
+	Score     float64
+	Published time.Time
+}
+
+// Aggregate returns the recency-weighted mean score of the non-neutral items,
+// so that routine headlines do not dilute a strong signal. Items without a
+// publication time count as fresh. Returns 0 when all items are neutral.
+func Aggregate(items []Item, now time.Time) float64 {
+	var sum, weights float64
+	for _, it := range items {
+		if it.Score == 0 {
+			continue
+		}
+		w := 1.0
+		if !it.Published.IsZero() {
+			if age := now.Sub(it.Published); age > 0 {
+				w = math.Exp2(-age.Hours() / HalfLife.Hours())
+			}
+		}
+		sum += it.Score * w
+		weights += w
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
@@ -0,0 +1,80 @@
+package sentiment
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScore_Sign(t *testing.T) {
+	cases := []struct {
+		title string
+		sign  int
+	}{
+		{"Совет директоров Сбербанка рекомендовал дивиденды", 1},
+		{"Совет директоров решил не выплачивать дивиденды", -1},
+		{"Компания отменила дивиденды за 2023 год", -1},
+		{"ЕС ввёл санкции против компании", -1},
+		{"США сняли санкции с компании", 1},
+		{"Компания объявила SPO", -1},
+		{"Компания запустила программу обратного выкупа акций", 1},
+		{"Чистая прибыль выросла на 20%", 1},
+		{"Снижение прибыли по МСФО", -1},
+		{"Убыток сократился вдвое", 1},
+		{"Рост убытка по РСБУ", -1},
+		{"Акции упали на 5%", -1},
+		{"Company announces share buyback", 1},
+		{"Company misses earnings, shares fall", -1},
+		{"US lifts sanctions on steelmaker", 1},
+		{"Dividend will not be cut", 1},
+		{"Дивиденды не будут выплачены", -1},
+		{"Санкции против банка сняты", 1},
+		{"Дивиденды по акциям отменены", -1},
+		{"Выплаты дивидендов приостановлены", -1},
+		{"Решение о выкупе акций аннулировано", -1},
+		{"Sanctions on bank lifted", 1},
+		{"Dividend scrapped", -1},
+		{"Прибыль не снизилась", 1},
+		{"Ростелеком провел собрание акционеров", 0},
+		{"Мосбиржа опубликовала расписание торгов", 0},
+	}
+	for _, c := range cases {
+		got := Score(c.title)
+		if (c.sign > 0 && got <= 0) || (c.sign < 0 && got >= 0) || (c.sign == 0 && got != 0) {
+			t.Errorf("Score(%q) = %.2f, want sign %d", c.title, got, c.sign)
+		}
+	}
+}
+
+func TestScore_Bounded(t *testing.T) {
+	got := Score("дефолт банкротство санкции делистинг обвал убыток")
+	if got >= -0.9 || got < -1 {
+		t.Errorf("expected strong negative within [-1, -0.9), got %.3f", got)
+	}
+	if got := Score("дивиденды"); math.Abs(got-1/math.Sqrt2) > 1e-9 {
+		t.Errorf("single event keyword: got %.3f", got)
+	}
+}
+
+func TestAggregate_RecencyAndNeutral(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	items := []Item{
+		{Score: -0.8, Published: now.Add(-time.Hour)},
+		{Score: 0.6, Published: now.Add(-13 * time.Hour)},
+		{Score: 0, Published: now},
+		{Score: 0, Published: now},
+	}
+	got := Aggregate(items, now)
+	if got >= 0 {
+		t.Errorf("fresh negative news must dominate, got %.3f", got)
+	}
+	if got < -0.8 {
+		t.Errorf("aggregate out of range: %.3f", got)
+	}
+	if Aggregate([]Item{{Score: 0}}, now) != 0 {
+		t.Error("neutral news must aggregate to 0")
+	}
+	if got := Aggregate([]Item{{Score: 0.5}}, now); got != 0.5 {
+		t.Errorf("item without time: got %.3f", got)
+	}
+}
@@ -21,6 +21,11 @@ type Trade struct {
 	PnL       float64 `gorm:"column:pnl" json:"pnl"`
 	Reasoning string  `gorm:"type:text" json:"reasoning"`
 	Status    string  `gorm:"not null;default:'open'" json:"status"` // open, closed
+
+	PromptVersion        string `gorm:"index" json:"prompt_version"` // prompt variant behind the decision
+	Confidence           int    `json:"confidence"`                  // raw model confidence at entry
+	CalibratedConfidence int    `json:"calibrated_confidence"`       // after calibration, 0 if not applied
+	AnalysisLogID        uint   `gorm:"index" json:"analysis_log_id"` // cycle behind the order, 0 if not placed by a decision
 }
 
 type AnalysisLog struct {
@@ -29,8 +34,30 @@ type AnalysisLog struct {
 
 	SignalsCount  int    `json:"signals_count"`
 	AIResponse    string `gorm:"type:text" json:"ai_response"`
+	Reasoning     string `gorm:"type:text" json:"reasoning"` // model reasoning chain, separate from the answer
 	DecisionsJSON string `gorm:"type:text" json:"decisions_json"`
+	BlockedJSON   string `gorm:"type:text" json:"blocked_json"` // decisions rejected by the trade guard, with reasons
+	ExecutionJSON string `gorm:"type:text" json:"execution_json"` // what the executor did with each allowed decision
 	Error         string `json:"error"`
+
+	SystemPrompt string `gorm:"type:text" json:"system_prompt"`
+	UserPrompt   string `gorm:"type:text" json:"user_prompt"`
+
+	Model            string  `json:"model"`
+	PromptVersion    string  `gorm:"index" json:"prompt_version"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`
+	TokensEstimated  bool    `json:"tokens_estimated"` // usage estimated locally, API reported none
+	LatencyMs        int64   `json:"latency_ms"`
+	ReasoningMs      int64   `json:"reasoning_ms"` // request start → first answer token
+	AnswerMs         int64   `json:"answer_ms"`    // first answer token → end of stream
+	CostUSD          float64 `gorm:"column:cost_usd" json:"cost_usd"`
+	CostRub          float64 `json:"cost_rub"`
+
+	ToolCallsCount int    `json:"tool_calls_count"`
+	ToolMs         int64  `json:"tool_ms"`
+	ToolCallsJSON  string `gorm:"type:text" json:"tool_calls_json"` // executed tool calls with arguments and results
 }
 
 type PortfolioSnapshot struct {
@@ -42,3 +69,77 @@ type PortfolioSnapshot struct {
 	PositionsCount int     `json:"positions_count"`
 	PositionsJSON  string  `gorm:"type:text" json:"positions_json"`
 }
+
+// AuditLog records authenticated web actions and login attempts.
+type AuditLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	Username   string `gorm:"index" json:"username"`
+	Role       string `json:"role"`
+	AuthMethod string `json:"auth_method"` // session, basic, token
+	Action     string `json:"action"`      // login, login_failed, login_throttled, logout, request, or an operator action
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	RemoteAddr string `json:"remote_addr"`
+	Detail     string `json:"detail,omitempty"` // operator action parameters and result
+}
+
+// NewsHeadline is a headline seen by the news fetcher, kept to tell fresh
+// news from repeats and near-duplicates across cycles and restarts.
+type NewsHeadline struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"` // first seen
+
+	Source      string    `gorm:"index:idx_news_source_external" json:"source"`
+	ExternalID  int64     `gorm:"index:idx_news_source_external" json:"external_id"` // ISS id or hash of the feed item
+	Title       string    `json:"title"`
+	Published   time.Time `json:"published"`
+	Sentiment   float64   `json:"sentiment"`
+	DuplicateOf uint      `json:"duplicate_of,omitempty"` // earlier headline with a near-identical title
+}
+
+// HistoryBar is a bar downloaded from MOEX ISS for research and backtests
+// (cmd/fetchhistory), one row per ticker, interval and bar start.
+type HistoryBar struct {
+	ID       uint      `gorm:"primarykey" json:"id"`
+	Ticker   string    `gorm:"uniqueIndex:idx_history_bar" json:"ticker"`
+	Interval string    `gorm:"uniqueIndex:idx_history_bar" json:"interval"` // moex.Interval name, e.g. "1d"
+	Time     time.Time `gorm:"uniqueIndex:idx_history_bar" json:"time"`     // bar start, Moscow wall clock as UTC
+	Open     float64   `json:"open"`
+	High     float64   `json:"high"`
+	Low      float64   `json:"low"`
+	Close    float64   `json:"close"`
+	Volume   float64   `json:"volume"`
+	Value    float64   `json:"value"` // turnover, RUB
+}
+
+// UniverseEntry is a candidate of the daily ticker universe with the figures
+// it was judged by and the reason it was selected or left out.
+type UniverseEntry struct {
+	ID           uint    `gorm:"primarykey" json:"id"`
+	Date         string  `gorm:"index:idx_universe_date_ticker" json:"date"` // YYYY-MM-DD, MSK
+	Ticker       string  `gorm:"index:idx_universe_date_ticker;index" json:"ticker"`
+	Included     bool    `json:"included"`
+	Rank         int     `json:"rank,omitempty"`   // by average turnover among included, from 1
+	Reason       string  `json:"reason"`           // why it is in or out
+	Change       string  `json:"change,omitempty"` // entered or left against the previous universe
+	AvgTurnover  float64 `json:"avg_turnover_rub"`
+	TradingDays  int     `json:"trading_days"` // days with trades within the lookback
+	Price        float64 `json:"price"`
+	LotValue     float64 `json:"lot_value_rub"`
+	SpreadPct    float64 `json:"spread_pct"`        // at the rebuild
+	AvgSpreadPct float64 `json:"avg_spread_pct"`    // over the session samples of the lookback
+	Indexes      string  `json:"indexes,omitempty"` // comma-separated
+}
+
+// SpreadSample accumulates the bid/offer spreads of a ticker sampled during a
+// trading day, so the universe averages them over the whole session.
+type SpreadSample struct {
+	ID      uint    `gorm:"primarykey" json:"id"`
+	Date    string  `gorm:"uniqueIndex:idx_spread_date_ticker" json:"date"` // YYYY-MM-DD, MSK
+	Ticker  string  `gorm:"uniqueIndex:idx_spread_date_ticker" json:"ticker"`
+	SumPct  float64 `json:"sum_pct"`
+	Samples int     `json:"samples"`
+}
@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"errors"
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -14,6 +17,16 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// StartOfTodayMSK returns midnight of the current day in Moscow time.
+func StartOfTodayMSK() time.Time {
+	msk, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		msk = time.FixedZone("MSK", 3*60*60)
+	}
+	now := time.Now().In(msk)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, msk)
+}
+
 // Trades
 
 func (r *Repository) SaveTrade(trade *Trade) error {
@@ -46,6 +59,114 @@ func (r *Repository) GetRecentTrades(limit int) ([]Trade, error) {
 	return trades, err
 }
 
+// GetTradesByTicker returns the most recent trades for a ticker, newest first.
+func (r *Repository) GetTradesByTicker(ticker string, limit int) ([]Trade, error) {
+	var trades []Trade
+	err := r.db.Where("ticker = ?", ticker).Order("created_at DESC").Limit(limit).Find(&trades).Error
+	return trades, err
+}
+
+// GetClosedBuyTradesSince returns closed positions opened since the given time
+// that have a recorded model confidence.
+func (r *Repository) GetClosedBuyTradesSince(since time.Time) ([]Trade, error) {
+	var trades []Trade
+	err := r.db.Where("action = ? AND status = ? AND confidence > 0 AND created_at >= ?", "BUY", "closed", since).
+		Order("created_at ASC").Find(&trades).Error
+	return trades, err
+}
+
+// GetRoundTripsClosedBetween returns closed BUY trades whose position was closed
+// (UpdatedAt) in [from, to). Zero bounds are open.
+func (r *Repository) GetRoundTripsClosedBetween(from, to time.Time) ([]Trade, error) {
+	q := r.db.Where("action = ? AND status = ?", "BUY", "closed")
+	if !from.IsZero() {
+		q = q.Where("updated_at >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("updated_at < ?", to)
+	}
+	var trades []Trade
+	err := q.Order("updated_at ASC").Find(&trades).Error
+	return trades, err
+}
+
+// GetTradesBetween returns every trade record created in [from, to), oldest first.
+func (r *Repository) GetTradesBetween(from, to time.Time) ([]Trade, error) {
+	var trades []Trade
+	err := r.db.Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at ASC").Find(&trades).Error
+	return trades, err
+}
+
+// TradeFilter narrows ListTrades. Zero values mean no filter.
+type TradeFilter struct {
+	Ticker string
+	Action string
+	Status string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// ListTrades returns a page of trades matching the filter, newest first, and the total match count.
+func (r *Repository) ListTrades(f TradeFilter) ([]Trade, int64, error) {
+	q := r.db.Model(&Trade{})
+	if f.Ticker != "" {
+		q = q.Where("ticker = ?", f.Ticker)
+	}
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if !f.From.IsZero() {
+		q = q.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("created_at < ?", f.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var trades []Trade
+	err := q.Order("created_at DESC").Limit(f.Limit).Offset(f.Offset).Find(&trades).Error
+	return trades, total, err
+}
+
+// GetTradesByAnalysisLog returns the trades placed on decisions of one analysis cycle.
+func (r *Repository) GetTradesByAnalysisLog(id uint) ([]Trade, error) {
+	var trades []Trade
+	err := r.db.Where("analysis_log_id = ?", id).Order("created_at ASC").Find(&trades).Error
+	return trades, err
+}
+
+func (r *Repository) GetTradeByID(id uint) (*Trade, error) {
+	var trade Trade
+	if err := r.db.First(&trade, id).Error; err != nil {
+		return nil, err
+	}
+	return &trade, nil
+}
+
+// GetClosedSellTrades returns position exits in [from, to), oldest first.
+// Zero bounds are open-ended.
+func (r *Repository) GetClosedSellTrades(from, to time.Time) ([]Trade, error) {
+	q := r.db.Where("status = ? AND action = ?", "closed", "SELL")
+	if !from.IsZero() {
+		q = q.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("created_at < ?", to)
+	}
+	var trades []Trade
+	err := q.Order("created_at ASC").Find(&trades).Error
+	return trades, err
+}
+
 func (r *Repository) GetTodayPnL() (float64, error) {
 	// Use MSK timezone for "today" boundary
 	msk, err := time.LoadLocation("Europe/Moscow")
@@ -210,12 +331,279 @@ func (r *Repository) SaveAnalysisLog(log *AnalysisLog) error {
 	return r.db.Create(log).Error
 }
 
+// GetLatestAnalysisLog returns the most recent cycle that produced a model response.
+func (r *Repository) GetLatestAnalysisLog() (*AnalysisLog, error) {
+	var log AnalysisLog
+	err := r.db.Where("ai_response != ''").Order("created_at DESC").First(&log).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// AnalysisLogFilter narrows ListAnalysisLogs. Zero values mean no filter.
+type AnalysisLogFilter struct {
+	From          time.Time
+	To            time.Time
+	PromptVersion string
+	Ticker        string // cycles with a decision for the ticker
+	ErrorsOnly    bool
+	Summary       bool // skip prompts, model output and tool calls
+	Limit         int
+	Offset        int
+}
+
+// ListAnalysisLogs returns a page of analysis logs matching the filter, newest first, and the total match count.
+func (r *Repository) ListAnalysisLogs(f AnalysisLogFilter) ([]AnalysisLog, int64, error) {
+	q := r.db.Model(&AnalysisLog{})
+	if !f.From.IsZero() {
+		q = q.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("created_at < ?", f.To)
+	}
+	if f.PromptVersion != "" {
+		q = q.Where("prompt_version = ?", f.PromptVersion)
+	}
+	if f.Ticker != "" {
+		q = q.Where("decisions_json LIKE ?", `%"ticker":"`+f.Ticker+`"%`)
+	}
+	if f.ErrorsOnly {
+		q = q.Where("error != ''")
+	}
+	if f.Summary {
+		q = q.Omit("system_prompt", "user_prompt", "ai_response", "reasoning", "tool_calls_json")
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []AnalysisLog
+	err := q.Order("created_at DESC").Limit(f.Limit).Offset(f.Offset).Find(&logs).Error
+	return logs, total, err
+}
+
+// GetCycleSummariesBetween returns analysis cycles in [from, to) without prompts
+// and model output: time, error, guard rejections and cost.
+func (r *Repository) GetCycleSummariesBetween(from, to time.Time) ([]AnalysisLog, error) {
+	var logs []AnalysisLog
+	err := r.db.Select("id", "created_at", "error", "blocked_json", "cost_rub").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at ASC").Find(&logs).Error
+	return logs, err
+}
+
+// SetAnalysisExecution stores the executor outcomes of a saved cycle.
+func (r *Repository) SetAnalysisExecution(id uint, executionJSON string) error {
+	return r.db.Model(&AnalysisLog{}).Where("id = ?", id).Update("execution_json", executionJSON).Error
+}
+
+func (r *Repository) GetAnalysisLogByID(id uint) (*AnalysisLog, error) {
+	var log AnalysisLog
+	if err := r.db.First(&log, id).Error; err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// AI Cost
+
+type AICostStats struct {
+	Cycles           int
+	PromptTokens     int
+	CompletionTokens int
+	ReasoningTokens  int
+	CostUSD          float64
+	CostRub          float64
+	Trades           int     // BUY trades opened in the same period
+	CostPerTradeRub  float64 // CostRub / Trades, 0 when no trades
+}
+
+// GetAICostSince aggregates model usage and cost of analysis cycles since the given time.
+func (r *Repository) GetAICostSince(since time.Time) (AICostStats, error) {
+	var row struct {
+		Cycles           int
+		PromptTokens     int
+		CompletionTokens int
+		ReasoningTokens  int
+		CostUSD          float64 `gorm:"column:cost_usd"`
+		CostRub          float64
+	}
+	err := r.db.Model(&AnalysisLog{}).
+		Where("created_at >= ?", since).
+		Select("COUNT(*) AS cycles, " +
+			"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+			"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+			"COALESCE(SUM(reasoning_tokens), 0) AS reasoning_tokens, " +
+			"COALESCE(SUM(cost_usd), 0) AS cost_usd, " +
+			"COALESCE(SUM(cost_rub), 0) AS cost_rub").
+		Scan(&row).Error
+	if err != nil {
+		return AICostStats{}, err
+	}
+
+	var trades int64
+	if err := r.db.Model(&Trade{}).
+		Where("action = ? AND created_at >= ?", "BUY", since).
+		Count(&trades).Error; err != nil {
+		return AICostStats{}, err
+	}
+
+	stats := AICostStats{
+		Cycles:           row.Cycles,
+		PromptTokens:     row.PromptTokens,
+		CompletionTokens: row.CompletionTokens,
+		ReasoningTokens:  row.ReasoningTokens,
+		CostUSD:          row.CostUSD,
+		CostRub:          row.CostRub,
+		Trades:           int(trades),
+	}
+	if trades > 0 {
+		stats.CostPerTradeRub = row.CostRub / float64(trades)
+	}
+	return stats, nil
+}
+
+// GetTodayAICostRub returns the AI spend since midnight MSK.
+func (r *Repository) GetTodayAICostRub() (float64, error) {
+	var total float64
+	err := r.db.Model(&AnalysisLog{}).
+		Where("created_at >= ?", StartOfTodayMSK()).
+		Select("COALESCE(SUM(cost_rub), 0)").Scan(&total).Error
+	return total, err
+}
+
+// Prompt Variants
+
+// PromptVariantStats compares prompt variants: cycles and AI cost from analysis logs,
+// trading results from positions opened by the variant.
+type PromptVariantStats struct {
+	Version      string
+	Cycles       int
+	CostRub      float64
+	OpenedTrades int
+	ClosedTrades int
+	Wins         int
+	WinRate      float64 // % of closed trades with positive P&L
+	TotalPnL     float64
+	AvgPnL       float64
+}
+
+// GetPromptVariantStats aggregates per prompt version since the given time.
+// P&L is attributed to the variant that opened the position (BUY trade).
+func (r *Repository) GetPromptVariantStats(since time.Time) ([]PromptVariantStats, error) {
+	var cycles []struct {
+		PromptVersion string
+		Cycles        int
+		CostRub       float64
+	}
+	err := r.db.Model(&AnalysisLog{}).
+		Where("created_at >= ? AND prompt_version != ''", since).
+		Select("prompt_version, COUNT(*) AS cycles, COALESCE(SUM(cost_rub), 0) AS cost_rub").
+		Group("prompt_version").
+		Scan(&cycles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []struct {
+		PromptVersion string
+		Opened        int
+		Closed        int
+		Wins          int
+		TotalPnL      float64 `gorm:"column:total_pnl"`
+	}
+	err = r.db.Model(&Trade{}).
+		Where("action = ? AND created_at >= ? AND prompt_version != ''", "BUY", since).
+		Select("prompt_version, COUNT(*) AS opened, " +
+			"SUM(CASE WHEN status = 'closed' THEN 1 ELSE 0 END) AS closed, " +
+			"SUM(CASE WHEN status = 'closed' AND pnl > 0 THEN 1 ELSE 0 END) AS wins, " +
+			"COALESCE(SUM(CASE WHEN status = 'closed' THEN pnl ELSE 0 END), 0) AS total_pnl").
+		Group("prompt_version").
+		Scan(&trades).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[string]*PromptVariantStats)
+	var order []string
+	get := func(version string) *PromptVariantStats {
+		if s, ok := byVersion[version]; ok {
+			return s
+		}
+		s := &PromptVariantStats{Version: version}
+		byVersion[version] = s
+		order = append(order, version)
+		return s
+	}
+	for _, c := range cycles {
+		s := get(c.PromptVersion)
+		s.Cycles = c.Cycles
+		s.CostRub = c.CostRub
+	}
+	for _, t := range trades {
+		s := get(t.PromptVersion)
+		s.OpenedTrades = t.Opened
+		s.ClosedTrades = t.Closed
+		s.Wins = t.Wins
+		s.TotalPnL = t.TotalPnL
+		if t.Closed > 0 {
+			s.WinRate = float64(t.Wins) / float64(t.Closed) * 100
+			s.AvgPnL = t.TotalPnL / float64(t.Closed)
+		}
+	}
+
+	sort.Strings(order)
+	result := make([]PromptVariantStats, 0, len(order))
+	for _, v := range order {
+		result = append(result, *byVersion[v])
+	}
+	return result, nil
+}
+
+// Audit
+
+func (r *Repository) SaveAuditLog(entry *AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// ListAuditLogs returns a page of audit entries, newest first, and the total count.
+func (r *Repository) ListAuditLogs(username string, limit, offset int) ([]AuditLog, int64, error) {
+	q := r.db.Model(&AuditLog{})
+	if username != "" {
+		q = q.Where("username = ?", username)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []AuditLog
+	err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, total, err
+}
+
 // Portfolio Snapshots
 
 func (r *Repository) SavePortfolioSnapshot(snapshot *PortfolioSnapshot) error {
 	return r.db.Create(snapshot).Error
 }
 
+// GetSnapshotsBetween returns portfolio valuations in [from, to), oldest first,
+// without the positions payload. Zero bounds are open.
+func (r *Repository) GetSnapshotsBetween(from, to time.Time) ([]PortfolioSnapshot, error) {
+	q := r.db.Select("id", "created_at", "total_rub", "available_rub", "positions_count")
+	if !from.IsZero() {
+		q = q.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("created_at < ?", to)
+	}
+	var snapshots []PortfolioSnapshot
+	err := q.Order("created_at ASC").Find(&snapshots).Error
+	return snapshots, err
+}
+
 func (r *Repository) GetLatestSnapshot() (*PortfolioSnapshot, error) {
 	var snapshot PortfolioSnapshot
 	err := r.db.Order("created_at DESC").First(&snapshot).Error
@@ -224,3 +612,163 @@ func (r *Repository) GetLatestSnapshot() (*PortfolioSnapshot, error) {
 	}
 	return &snapshot, nil
 }
+
+// Health
+
+var errRollback = errors.New("rollback")
+
+// CheckWritable verifies that the database accepts writes: it writes a row in
+// a transaction and rolls it back, leaving no trace.
+func (r *Repository) CheckWritable() error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("CREATE TABLE IF NOT EXISTS health_check (checked_at DATETIME)").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO health_check (checked_at) VALUES (?)", time.Now()).Error; err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+	return err
+}
+
+// News Headlines
+
+// SaveNewsHeadlines inserts headlines in one batch and fills their IDs.
+func (r *Repository) SaveNewsHeadlines(items []NewsHeadline) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.Create(&items).Error
+}
+
+// GetNewsHeadlinesSince returns headlines first seen at or after since, oldest first.
+func (r *Repository) GetNewsHeadlinesSince(since time.Time) ([]NewsHeadline, error) {
+	var items []NewsHeadline
+	err := r.db.Where("created_at >= ?", since).Order("created_at ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+// DeleteNewsHeadlinesBefore removes headlines first seen before t.
+func (r *Repository) DeleteNewsHeadlinesBefore(t time.Time) (int64, error) {
+	res := r.db.Where("created_at < ?", t).Delete(&NewsHeadline{})
+	return res.RowsAffected, res.Error
+}
+
+// History Bars
+
+// SaveHistoryBars inserts bars, replacing stored bars of the same ticker,
+// interval and time.
+func (r *Repository) SaveHistoryBars(bars []HistoryBar) error {
+	if len(bars) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ticker"}, {Name: "interval"}, {Name: "time"}},
+		DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "value"}),
+	}).CreateInBatches(&bars, 500).Error
+}
+
+// GetLastHistoryBarTime returns the start of the latest stored bar, zero if none.
+func (r *Repository) GetLastHistoryBarTime(ticker, interval string) (time.Time, error) {
+	var bar HistoryBar
+	err := r.db.Where("ticker = ? AND interval = ?", ticker, interval).Order("time DESC").First(&bar).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return bar.Time, err
+}
+
+// GetFirstHistoryBarTime returns the start of the earliest stored bar, zero if none.
+func (r *Repository) GetFirstHistoryBarTime(ticker, interval string) (time.Time, error) {
+	var bar HistoryBar
+	err := r.db.Where("ticker = ? AND interval = ?", ticker, interval).Order("time ASC").First(&bar).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return bar.Time, err
+}
+
+// GetHistoryBars returns the bars within [from, to], oldest first.
+func (r *Repository) GetHistoryBars(ticker, interval string, from, to time.Time) ([]HistoryBar, error) {
+	var bars []HistoryBar
+	err := r.db.Where("ticker = ? AND interval = ? AND time >= ? AND time <= ?", ticker, interval, from, to).
+		Order("time ASC").Find(&bars).Error
+	return bars, err
+}
+
+// Universe
+
+// SaveUniverse replaces the entries of a day.
+func (r *Repository) SaveUniverse(date string, entries []UniverseEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("date = ?", date).Delete(&UniverseEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&entries, 200).Error
+	})
+}
+
+// GetUniverse returns the entries of a day, included first by rank.
+func (r *Repository) GetUniverse(date string) ([]UniverseEntry, error) {
+	var entries []UniverseEntry
+	err := r.db.Where("date = ?", date).Order("included DESC, rank ASC, avg_turnover DESC, ticker ASC").Find(&entries).Error
+	return entries, err
+}
+
+// GetLatestUniverseDate returns the latest day with a universe before the
+// given day, "" if none. An empty before means any day.
+func (r *Repository) GetLatestUniverseDate(before string) (string, error) {
+	q := r.db.Model(&UniverseEntry{})
+	if before != "" {
+		q = q.Where("date < ?", before)
+	}
+	var date *string
+	if err := q.Select("MAX(date)").Scan(&date).Error; err != nil || date == nil {
+		return "", err
+	}
+	return *date, nil
+}
+
+// GetTickerUniverseHistory returns the latest entries of a ticker, newest first.
+func (r *Repository) GetTickerUniverseHistory(ticker string, limit int) ([]UniverseEntry, error) {
+	var entries []UniverseEntry
+	err := r.db.Where("ticker = ?", ticker).Order("date DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// AddSpreadSamples adds a sample per ticker to the day's sums.
+func (r *Repository) AddSpreadSamples(date string, spreads map[string]float64) error {
+	if len(spreads) == 0 {
+		return nil
+	}
+	samples := make([]SpreadSample, 0, len(spreads))
+	for ticker, pct := range spreads {
+		samples = append(samples, SpreadSample{Date: date, Ticker: ticker, SumPct: pct, Samples: 1})
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "date"}, {Name: "ticker"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"sum_pct": gorm.Expr("spread_samples.sum_pct + excluded.sum_pct"),
+			"samples": gorm.Expr("spread_samples.samples + excluded.samples"),
+		}),
+	}).CreateInBatches(&samples, 200).Error
+}
+
+// GetSpreadSamplesSince returns the daily samples from since on.
+func (r *Repository) GetSpreadSamplesSince(since string) ([]SpreadSample, error) {
+	var samples []SpreadSample
+	err := r.db.Where("date >= ?", since).Order("date ASC").Find(&samples).Error
+	return samples, err
+}
+
+// DeleteSpreadSamplesBefore removes the daily samples older than before.
+func (r *Repository) DeleteSpreadSamplesBefore(before string) error {
+	return r.db.Where("date < ?", before).Delete(&SpreadSample{}).Error
+}
@@ -0,0 +1,136 @@
+// Package universe picks the TQBR shares analysed during the day: the most
+// traded ones by average turnover that pass the liquidity filters, plus the
+// tickers forced in by the config.
+package universe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// Change marks a ticker that entered or left the universe since the previous day.
+const (
+	Entered = "entered"
+	Left    = "left"
+)
+
+// Candidate is a share with its liquidity over the lookback window.
+type Candidate struct {
+	Ticker       string
+	AvgTurnover  float64 // RUB per trading day of the window
+	TradingDays  int     // days of the window with trades
+	Price        float64
+	LotSize      int
+	SpreadPct    float64 // current bid/offer spread, 0 without quotes
+	AvgSpreadPct float64 // of the daily session averages, 0 without samples
+	Indexes      []string
+}
+
+// LotValue is the price of one lot in RUB, 0 when the lot size is unknown.
+func (c Candidate) LotValue() float64 {
+	return c.Price * float64(c.LotSize)
+}
+
+// Select judges every candidate against the filters and keeps the top
+// cfg.Size by average turnover; tickers in cfg.Include always stay and take
+// slots of the top. windowDays is the number of trading days the averages
+// cover. The entries are returned included first by rank, then the rest by
+// turnover, each with the reason it is in or out.
+func Select(cands []Candidate, cfg config.UniverseConfig, windowDays int) []storage.UniverseEntry {
+	entries := make([]storage.UniverseEntry, 0, len(cands))
+	var eligible []int
+	forced := 0
+	for _, c := range cands {
+		e := storage.UniverseEntry{
+			Ticker:       c.Ticker,
+			AvgTurnover:  c.AvgTurnover,
+			TradingDays:  c.TradingDays,
+			Price:        c.Price,
+			LotValue:     c.LotValue(),
+			SpreadPct:    c.SpreadPct,
+			AvgSpreadPct: c.AvgSpreadPct,
+			Indexes:      strings.Join(c.Indexes, ","),
+		}
+		if reason := rejectReason(c, cfg, windowDays); reason != "" {
+			e.Reason = reason
+		} else {
+			eligible = append(eligible, len(entries))
+		}
+		if contains(cfg.Include, c.Ticker) {
+			e.Included = true
+			e.Reason = "добавлен в настройках"
+			forced++
+		}
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(eligible, func(a, b int) bool {
+		return entries[eligible[a]].AvgTurnover > entries[eligible[b]].AvgTurnover
+	})
+	slots := cfg.Size - forced
+	for _, i := range eligible {
+		e := &entries[i]
+		if e.Included {
+			continue
+		}
+		if slots > 0 {
+			e.Included = true
+			e.Reason = fmt.Sprintf("средний оборот %s", formatRub(e.AvgTurnover))
+			slots--
+		} else {
+			e.Reason = fmt.Sprintf("вне топ-%d по обороту", cfg.Size)
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].Included != entries[b].Included {
+			return entries[a].Included
+		}
+		return entries[a].AvgTurnover > entries[b].AvgTurnover
+	})
+	for i := range entries {
+		if entries[i].Included {
+			entries[i].Rank = i + 1
+		}
+	}
+	return entries
+}
+
+// rejectReason returns why a candidate fails the filters, "" when it passes.
+func rejectReason(c Candidate, cfg config.UniverseConfig, windowDays int) string {
+	switch {
+	case contains(cfg.Exclude, c.Ticker):
+		return "исключён в настройках"
+	case c.TradingDays*2 < windowDays:
+		return fmt.Sprintf("мало истории: торги %d из %d дней", c.TradingDays, windowDays)
+	case cfg.MinPrice > 0 && c.Price < cfg.MinPrice:
+		return fmt.Sprintf("цена %.2f ₽ ниже %.2f ₽", c.Price, cfg.MinPrice)
+	case cfg.MinLotValueRub > 0 && c.LotSize > 0 && c.LotValue() < cfg.MinLotValueRub:
+		return fmt.Sprintf("лот %.0f ₽ дешевле %.0f ₽", c.LotValue(), cfg.MinLotValueRub)
+	case cfg.MaxAvgSpreadPct > 0 && c.AvgSpreadPct > cfg.MaxAvgSpreadPct:
+		return fmt.Sprintf("средний спред %.2f%% выше %.2f%%", c.AvgSpreadPct, cfg.MaxAvgSpreadPct)
+	case len(cfg.Indexes) > 0 && len(c.Indexes) == 0:
+		return fmt.Sprintf("не входит в %s", strings.Join(cfg.Indexes, ", "))
+	case cfg.MinAvgTurnoverRub > 0 && c.AvgTurnover < cfg.MinAvgTurnoverRub:
+		return fmt.Sprintf("средний оборот %s ниже %s", formatRub(c.AvgTurnover), formatRub(cfg.MinAvgTurnoverRub))
+	}
+	return ""
+}
+
+// formatRub renders a turnover in millions, e.g. "152.3 млн ₽".
+func formatRub(v float64) string {
+	return fmt.Sprintf("%.1f млн ₽", v/1e6)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
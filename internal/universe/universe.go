@@ -0,0 +1,339 @@
+package universe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+const dateLayout = "2006-01-02"
+
+// A failed build is retried after retryMin, doubling up to retryMax, so an
+// unavailable ISS is not queried in full on every cycle.
+const (
+	retryMin = 5 * time.Minute
+	retryMax = time.Hour
+)
+
+// Source loads the market data the universe is built from; *moex.Client
+// implements it.
+type Source interface {
+	FetchDayResults(ctx context.Context, date time.Time) ([]moex.DayResult, error)
+	FetchQuotes(ctx context.Context) ([]moex.Quote, error)
+	FetchIndexMembers(ctx context.Context, index string) ([]string, error)
+}
+
+// Universe rebuilds the ticker universe once per Moscow day and keeps it in
+// the universe_entries table, so a restart reuses the day's selection. The
+// day results of past days are cached between rebuilds. Spreads are sampled
+// separately through the session by SampleSpreads.
+type Universe struct {
+	source Source
+	repo   *storage.Repository
+	cfg    config.UniverseConfig
+	loc    *time.Location
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	date    string   // day of tickers
+	tickers []string // included, by rank
+	days    map[string][]moex.DayResult
+
+	failures int       // failed builds in a row
+	retryAt  time.Time // no build before
+	lastErr  error
+}
+
+func NewUniverse(source Source, repo *storage.Repository, cfg *config.Config, log *logger.Logger) *Universe {
+	return &Universe{
+		source: source,
+		repo:   repo,
+		cfg:    cfg.Universe,
+		loc:    cfg.MOEXLocation(),
+		logger: log,
+		days:   make(map[string][]moex.DayResult),
+	}
+}
+
+// Tickers returns today's universe by rank, building it on the first call
+// of the day. After a failed build the call fails fast until the backoff
+// runs out.
+func (u *Universe) Tickers(ctx context.Context, now time.Time) ([]string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	today := now.In(u.loc).Format(dateLayout)
+	if u.date == today {
+		return append([]string(nil), u.tickers...), nil
+	}
+
+	entries, err := u.repo.GetUniverse(today)
+	if err != nil {
+		return nil, fmt.Errorf("load universe: %w", err)
+	}
+	if len(entries) == 0 {
+		if now.Before(u.retryAt) {
+			return nil, fmt.Errorf("universe build retried at %s: %w", u.retryAt.In(u.loc).Format("15:04"), u.lastErr)
+		}
+		if entries, err = u.build(ctx, now, today); err != nil {
+			u.failures++
+			u.lastErr = err
+			delay := retryMax
+			if u.failures < 5 { // 5m, 10m, 20m, 40m
+				delay = retryMin << (u.failures - 1)
+			}
+			u.retryAt = now.Add(delay)
+			u.logger.Error("build universe", "error", err, "failures", u.failures, "retry_in", delay)
+			return nil, err
+		}
+	}
+	u.failures, u.retryAt, u.lastErr = 0, time.Time{}, nil
+
+	u.date, u.tickers = today, nil
+	for _, e := range entries {
+		if e.Included {
+			u.tickers = append(u.tickers, e.Ticker)
+		}
+	}
+	return append([]string(nil), u.tickers...), nil
+}
+
+func (u *Universe) build(ctx context.Context, now time.Time, today string) ([]storage.UniverseEntry, error) {
+	days, err := u.window(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no trading days in the last %d days", maxCalendarDays(u.cfg.LookbackDays))
+	}
+	quotes, err := u.source.FetchQuotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string][]string)
+	for _, index := range u.cfg.Indexes {
+		tickers, err := u.source.FetchIndexMembers(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tickers {
+			members[t] = append(members[t], index)
+		}
+	}
+	oldest := days[len(days)-1]
+	if err := u.repo.DeleteSpreadSamplesBefore(oldest); err != nil {
+		u.logger.Warn("delete old spread samples", "error", err)
+	}
+	samples, err := u.repo.GetSpreadSamplesSince(oldest)
+	if err != nil {
+		return nil, fmt.Errorf("load spread samples: %w", err)
+	}
+
+	cands := candidates(days, u.days, quotes, members, samples, u.cfg.Include)
+	entries := Select(cands, u.cfg, len(days))
+
+	prevDate, err := u.repo.GetLatestUniverseDate(today)
+	if err != nil {
+		return nil, fmt.Errorf("load previous universe: %w", err)
+	}
+	var entered, left []string
+	if prevDate != "" {
+		prev, err := u.repo.GetUniverse(prevDate)
+		if err != nil {
+			return nil, fmt.Errorf("load previous universe: %w", err)
+		}
+		entries, entered, left = diff(entries, prev)
+	}
+	for i := range entries {
+		entries[i].Date = today
+	}
+
+	u.logger.Info("universe rebuilt", "date", today, "tickers", countIncluded(entries), "candidates", len(entries),
+		"trading_days", len(days), "entered", strings.Join(entered, ","), "left", strings.Join(left, ","))
+	if err := u.repo.SaveUniverse(today, entries); err != nil {
+		// the selection stays in memory for today; a restart rebuilds it
+		u.logger.Error("save universe", "error", err)
+	}
+	return entries, nil
+}
+
+// SampleSpreads records the current bid/offer spread of every share for
+// today. The scheduler calls it each cycle, so the average the universe is
+// filtered by covers the whole session rather than the opening minutes of
+// the rebuild.
+func (u *Universe) SampleSpreads(ctx context.Context, now time.Time) error {
+	quotes, err := u.source.FetchQuotes(ctx)
+	if err != nil {
+		return err
+	}
+	spreads := make(map[string]float64, len(quotes))
+	for _, q := range quotes {
+		if pct := q.SpreadPct(); pct > 0 {
+			spreads[q.Ticker] = pct
+		}
+	}
+	if err := u.repo.AddSpreadSamples(now.In(u.loc).Format(dateLayout), spreads); err != nil {
+		return fmt.Errorf("save spread samples: %w", err)
+	}
+	return nil
+}
+
+// window returns the last lookback_days trading days before today, newest
+// first, loading the day results that are not cached yet.
+func (u *Universe) window(ctx context.Context, now time.Time) ([]string, error) {
+	y, m, d := now.In(u.loc).Date()
+	yesterday := time.Date(y, m, d-1, 0, 0, 0, 0, time.UTC)
+	oldest := yesterday.AddDate(0, 0, -maxCalendarDays(u.cfg.LookbackDays)+1)
+
+	var days []string
+	for day := yesterday; !day.Before(oldest) && len(days) < u.cfg.LookbackDays; day = day.AddDate(0, 0, -1) {
+		key := day.Format(dateLayout)
+		results, ok := u.days[key]
+		if !ok {
+			var err error
+			if results, err = u.source.FetchDayResults(ctx, day); err != nil {
+				return nil, err
+			}
+			// yesterday's results may not be published yet
+			if len(results) > 0 || !day.Equal(yesterday) {
+				u.days[key] = results
+			}
+		}
+		if len(results) > 0 {
+			days = append(days, key)
+		}
+	}
+	for key := range u.days {
+		if key < oldest.Format(dateLayout) {
+			delete(u.days, key)
+		}
+	}
+	return days, nil
+}
+
+// maxCalendarDays bounds the walk back for the trading days of the window,
+// with room for weekends and holidays.
+func maxCalendarDays(lookback int) int {
+	return lookback*2 + 14
+}
+
+// candidates aggregates the day results of the window with the current quotes,
+// index membership and the spreads sampled during the sessions. Every day
+// with samples weighs the same in the average spread. Tickers to include are
+// candidates even without trades in the window.
+func candidates(days []string, results map[string][]moex.DayResult, quotes []moex.Quote, members map[string][]string, samples []storage.SpreadSample, include []string) []Candidate {
+	byTicker := make(map[string]*Candidate)
+	var order []string
+	get := func(ticker string) *Candidate {
+		c, ok := byTicker[ticker]
+		if !ok {
+			c = &Candidate{Ticker: ticker}
+			byTicker[ticker] = c
+			order = append(order, ticker)
+		}
+		return c
+	}
+	for _, day := range days { // newest first: the first close is the last one
+		for _, r := range results[day] {
+			c := get(r.Ticker)
+			c.AvgTurnover += r.Value
+			if r.NumTrades > 0 {
+				c.TradingDays++
+				if c.Price == 0 {
+					c.Price = r.Close
+				}
+			}
+		}
+	}
+	for _, t := range include {
+		get(t)
+	}
+
+	spreads := make(map[string][]float64)
+	for _, s := range samples {
+		if s.Samples > 0 {
+			spreads[s.Ticker] = append(spreads[s.Ticker], s.SumPct/float64(s.Samples))
+		}
+	}
+	for _, q := range quotes {
+		c, ok := byTicker[q.Ticker]
+		if !ok {
+			continue
+		}
+		c.LotSize = q.LotSize
+		if p := q.Price(); p > 0 {
+			c.Price = p
+		}
+		c.SpreadPct = q.SpreadPct()
+	}
+
+	cands := make([]Candidate, 0, len(order))
+	for _, t := range order {
+		c := byTicker[t]
+		c.AvgTurnover /= float64(len(days))
+		c.Indexes = members[t]
+		if s := spreads[t]; len(s) > 0 {
+			sum := 0.0
+			for _, v := range s {
+				sum += v
+			}
+			c.AvgSpreadPct = sum / float64(len(s))
+		}
+		cands = append(cands, *c)
+	}
+	return cands
+}
+
+// diff marks the entries that entered or left against the previous universe
+// and adds the previous tickers that are no longer candidates at all.
+func diff(entries, prev []storage.UniverseEntry) (_ []storage.UniverseEntry, entered, left []string) {
+	was := make(map[string]bool)
+	for _, e := range prev {
+		if e.Included {
+			was[e.Ticker] = true
+		}
+	}
+	seen := make(map[string]bool, len(entries))
+	for i := range entries {
+		e := &entries[i]
+		seen[e.Ticker] = true
+		switch {
+		case e.Included && !was[e.Ticker]:
+			e.Change = Entered
+			entered = append(entered, e.Ticker)
+		case !e.Included && was[e.Ticker]:
+			e.Change = Left
+			left = append(left, e.Ticker)
+		}
+	}
+	var gone []string
+	for t := range was {
+		if !seen[t] {
+			gone = append(gone, t)
+		}
+	}
+	sort.Strings(gone)
+	for _, t := range gone {
+		entries = append(entries, storage.UniverseEntry{Ticker: t, Reason: "нет торгов за период", Change: Left})
+		left = append(left, t)
+	}
+	return entries, entered, left
+}
+
+func countIncluded(entries []storage.UniverseEntry) int {
+	n := 0
+	for _, e := range entries {
+		if e.Included {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,274 @@
+package universe
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/moex"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+func TestSelect_FiltersAndRanks(t *testing.T) {
+	cfg := config.UniverseConfig{
+		Size:              3,
+		MinAvgTurnoverRub: 10e6,
+		MinPrice:          1,
+		MinLotValueRub:    100,
+		MaxAvgSpreadPct:   0.5,
+		Include:           []string{"FORCED"},
+		Exclude:           []string{"BANNED"},
+	}
+	cands := []Candidate{
+		{Ticker: "SMALL", AvgTurnover: 5e6, TradingDays: 20, Price: 100, LotSize: 1},
+		{Ticker: "SBER", AvgTurnover: 5e9, TradingDays: 20, Price: 300, LotSize: 10, AvgSpreadPct: 0.02},
+		{Ticker: "BANNED", AvgTurnover: 9e9, TradingDays: 20, Price: 100, LotSize: 1},
+		{Ticker: "NEW", AvgTurnover: 8e9, TradingDays: 5, Price: 100, LotSize: 1},
+		{Ticker: "PENNY", AvgTurnover: 1e9, TradingDays: 20, Price: 0.5, LotSize: 1000},
+		{Ticker: "CHEAPLOT", AvgTurnover: 1e9, TradingDays: 20, Price: 5, LotSize: 10},
+		{Ticker: "WIDE", AvgTurnover: 1e9, TradingDays: 20, Price: 100, LotSize: 1, AvgSpreadPct: 1.2},
+		{Ticker: "GAZP", AvgTurnover: 3e9, TradingDays: 20, Price: 130, LotSize: 10},
+		{Ticker: "LKOH", AvgTurnover: 2e9, TradingDays: 19, Price: 7000, LotSize: 1},
+		{Ticker: "FORCED", AvgTurnover: 1e6, TradingDays: 20, Price: 10, LotSize: 1},
+	}
+	entries := Select(cands, cfg, 20)
+
+	var included []string
+	reasons := make(map[string]string)
+	for i, e := range entries {
+		if e.Included {
+			included = append(included, e.Ticker)
+			if e.Rank != i+1 {
+				t.Errorf("%s: rank %d at position %d", e.Ticker, e.Rank, i)
+			}
+		} else if e.Rank != 0 {
+			t.Errorf("%s is out but ranked %d", e.Ticker, e.Rank)
+		}
+		reasons[e.Ticker] = e.Reason
+	}
+	// FORCED takes one of the three slots
+	if got := strings.Join(included, ","); got != "SBER,GAZP,FORCED" {
+		t.Fatalf("included %s", got)
+	}
+	want := map[string]string{
+		"BANNED":   "исключён в настройках",
+		"NEW":      "мало истории",
+		"PENNY":    "цена 0.50",
+		"CHEAPLOT": "лот 50 ₽",
+		"WIDE":     "средний спред 1.20%",
+		"SMALL":    "средний оборот 5.0 млн ₽ ниже",
+		"LKOH":     "вне топ-3",
+		"FORCED":   "добавлен в настройках",
+		"SBER":     "средний оборот 5000.0 млн ₽",
+	}
+	for ticker, prefix := range want {
+		if !strings.HasPrefix(reasons[ticker], prefix) {
+			t.Errorf("%s: reason %q, want prefix %q", ticker, reasons[ticker], prefix)
+		}
+	}
+}
+
+func TestSelect_IndexFilter(t *testing.T) {
+	cfg := config.UniverseConfig{Size: 10, Indexes: []string{"IMOEX"}}
+	entries := Select([]Candidate{
+		{Ticker: "SBER", AvgTurnover: 5e9, TradingDays: 20, Indexes: []string{"IMOEX"}},
+		{Ticker: "OUTSIDER", AvgTurnover: 9e9, TradingDays: 20},
+	}, cfg, 20)
+	if !entries[0].Included || entries[0].Indexes != "IMOEX" || entries[1].Included || entries[1].Reason != "не входит в IMOEX" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
+
+// fakeSource trades every weekday: tickers T00..Tnn with turnover falling by
+// index, shifted by the offset so a later day reorders them. Quotes are
+// 100 ± spread/2, 0.2 wide by default.
+type fakeSource struct {
+	tickers    int
+	offset     map[string]float64 // extra turnover per ticker
+	spread     float64
+	dayCalls   int
+	quoteCalls int
+}
+
+func (f *fakeSource) FetchDayResults(_ context.Context, date time.Time) ([]moex.DayResult, error) {
+	f.dayCalls++
+	if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return nil, nil
+	}
+	results := make([]moex.DayResult, f.tickers)
+	for i := range results {
+		ticker := fmt.Sprintf("T%02d", i)
+		results[i] = moex.DayResult{Ticker: ticker, Date: date, Close: 100, Value: float64(f.tickers-i)*1e8 + f.offset[ticker], NumTrades: 1000}
+	}
+	return results, nil
+}
+
+func (f *fakeSource) FetchQuotes(context.Context) ([]moex.Quote, error) {
+	f.quoteCalls++
+	quotes := make([]moex.Quote, f.tickers)
+	for i := range quotes {
+		half := 0.1
+		if f.spread > 0 {
+			half = f.spread / 2
+		}
+		quotes[i] = moex.Quote{Ticker: fmt.Sprintf("T%02d", i), LotSize: 10, Bid: 100 - half, Offer: 100 + half, Last: 100}
+	}
+	return quotes, nil
+}
+
+func (f *fakeSource) FetchIndexMembers(context.Context, string) ([]string, error) {
+	return nil, fmt.Errorf("not expected")
+}
+
+func newTestUniverse(t *testing.T, src Source, repo *storage.Repository) *Universe {
+	t.Helper()
+	cfg := &config.Config{Universe: config.UniverseConfig{Size: 3, LookbackDays: 5}}
+	return NewUniverse(src, repo, cfg, logger.New("error"))
+}
+
+func TestUniverse_BuildsOncePerDayAndSurvivesRestart(t *testing.T) {
+	repo := newTestRepo(t)
+	src := &fakeSource{tickers: 6}
+	u := newTestUniverse(t, src, repo)
+
+	// Tuesday morning MSK: the window ends on Monday
+	now := time.Date(2024, 7, 9, 7, 0, 0, 0, time.UTC)
+	tickers, err := u.Tickers(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(tickers, ","); got != "T00,T01,T02" {
+		t.Fatalf("tickers %s", got)
+	}
+	// 5 trading days back from Monday span a weekend
+	if src.dayCalls != 7 || src.quoteCalls != 1 {
+		t.Errorf("expected 7 day and 1 quote requests, got %d and %d", src.dayCalls, src.quoteCalls)
+	}
+
+	if _, err := u.Tickers(context.Background(), now.Add(3*time.Hour)); err != nil || src.quoteCalls != 1 {
+		t.Errorf("expected the cached universe, got %d quote requests, %v", src.quoteCalls, err)
+	}
+
+	restarted := newTestUniverse(t, src, repo)
+	tickers, err = restarted.Tickers(context.Background(), now.Add(time.Hour))
+	if err != nil || strings.Join(tickers, ",") != "T00,T01,T02" || src.quoteCalls != 1 {
+		t.Errorf("expected today's universe from the database, got %v, %d quote requests, %v", tickers, src.quoteCalls, err)
+	}
+
+	entries, _ := repo.GetUniverse("2024-07-09")
+	if len(entries) != 6 || entries[0].SpreadPct <= 0 || entries[0].LotValue != 1000 || entries[3].Reason != "вне топ-3 по обороту" {
+		t.Errorf("unexpected stored entries %+v", entries)
+	}
+}
+
+func TestUniverse_RecordsEnteredAndLeft(t *testing.T) {
+	repo := newTestRepo(t)
+	src := &fakeSource{tickers: 6}
+	u := newTestUniverse(t, src, repo)
+
+	monday := time.Date(2024, 7, 8, 7, 0, 0, 0, time.UTC)
+	if _, err := u.Tickers(context.Background(), monday); err != nil {
+		t.Fatal(err)
+	}
+	calls := src.dayCalls
+
+	// T05 turns into the most traded share from Monday on
+	src.offset = map[string]float64{"T05": 1e10}
+	tuesday := monday.AddDate(0, 0, 1)
+	tickers, err := u.Tickers(context.Background(), tuesday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sunday was yesterday on Monday, so it is not cached as a day off
+	if src.dayCalls != calls+2 {
+		t.Errorf("expected only Monday and Sunday to be loaded, got %d new requests", src.dayCalls-calls)
+	}
+	// Monday alone lifts T05's 5-day average above the rest
+	if got := strings.Join(tickers, ","); got != "T05,T00,T01" {
+		t.Fatalf("tickers %s", got)
+	}
+	entries, _ := repo.GetUniverse("2024-07-09")
+	changes := make(map[string]string)
+	for _, e := range entries {
+		if e.Change != "" {
+			changes[e.Ticker] = e.Change
+		}
+	}
+	if len(changes) != 2 || changes["T05"] != Entered || changes["T02"] != Left {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	history, err := repo.GetTickerUniverseHistory("T05", 10)
+	if err != nil || len(history) != 2 || history[0].Date != "2024-07-09" || !history[0].Included || history[1].Included {
+		t.Errorf("unexpected T05 history %+v, %v", history, err)
+	}
+}
+
+func TestUniverse_FailedBuildBacksOff(t *testing.T) {
+	repo := newTestRepo(t)
+	src := &fakeSource{tickers: 0}
+	u := newTestUniverse(t, src, repo)
+
+	now := time.Date(2024, 7, 9, 7, 0, 0, 0, time.UTC)
+	if _, err := u.Tickers(context.Background(), now); err == nil {
+		t.Fatal("expected an error without trading days")
+	}
+	src.tickers = 4
+	calls := src.dayCalls
+	if _, err := u.Tickers(context.Background(), now.Add(time.Minute)); err == nil || src.dayCalls != calls {
+		t.Fatalf("expected a fast failure within the backoff, got %d new requests, %v", src.dayCalls-calls, err)
+	}
+	if tickers, err := u.Tickers(context.Background(), now.Add(retryMin)); err != nil || len(tickers) != 3 {
+		t.Errorf("expected a rebuild after the backoff, got %v, %v", tickers, err)
+	}
+}
+
+func TestUniverse_AveragesSpreadsSampledDuringTheSession(t *testing.T) {
+	repo := newTestRepo(t)
+	src := &fakeSource{tickers: 4}
+	u := newTestUniverse(t, src, repo)
+	ctx := context.Background()
+
+	// Friday: 0.4% at the open, 0.1% for the rest of the session
+	friday := time.Date(2024, 7, 5, 7, 0, 0, 0, time.UTC)
+	for i, spread := range []float64{0.4, 0.1, 0.1, 0.1} {
+		src.spread = spread
+		if err := u.SampleSpreads(ctx, friday.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Monday: 0.2% all day
+	src.spread = 0.2
+	if err := u.SampleSpreads(ctx, friday.AddDate(0, 0, 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rebuild quotes the wide opening spread, which the average ignores
+	src.spread = 1
+	if _, err := u.Tickers(ctx, friday.AddDate(0, 0, 4)); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := repo.GetUniverse("2024-07-09")
+	if len(entries) != 4 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	// (0.175% + 0.2%) / 2
+	if e := entries[0]; math.Abs(e.AvgSpreadPct-0.1875) > 1e-9 || math.Abs(e.SpreadPct-1) > 1e-9 {
+		t.Errorf("expected 0.1875%% on average and 1%% now, got %v and %v", e.AvgSpreadPct, e.SpreadPct)
+	}
+}
+
+func newTestRepo(t *testing.T) *storage.Repository {
+	t.Helper()
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "universe-test.db"))
+	if err != nil {
+		t.Fatalf("create test database: %v", err)
+	}
+	return storage.NewRepository(db)
+}
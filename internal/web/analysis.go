@@ -0,0 +1,215 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/camuig/rus-trader/internal/ai"
+	"github.com/camuig/rus-trader/internal/executor"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+const analysisPageSize = 50
+
+// AnalysisListData is the analysis cycle explorer page.
+type AnalysisListData struct {
+	Cycles        []CycleRow
+	Total         int64
+	Offset        int
+	Ticker        string
+	PromptVersion string
+	ErrorsOnly    bool
+	PrevURL       string // empty on the first page
+	NextURL       string // empty on the last page
+	User          string
+	Role          string
+}
+
+// CycleRow is one analysis cycle in the explorer list.
+type CycleRow struct {
+	apiAnalysisLog
+	Executed int
+	Failed   int
+}
+
+// CycleDecision follows one AI decision through the guard and the executor.
+type CycleDecision struct {
+	DecisionView
+	BlockedReason string            // empty when the guard let it through
+	Outcome       *executor.Outcome // nil when blocked or not recorded
+	Trade         *storage.Trade    // trade saved for an executed order
+}
+
+// AnalysisDetailData is a single analysis cycle, end to end.
+type AnalysisDetailData struct {
+	Log       apiAnalysisLog
+	Decisions []CycleDecision
+	Trades    []storage.Trade
+	User      string
+	Role      string
+}
+
+func (s *Server) handleAnalysisList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	data := AnalysisListData{
+		Ticker:        strings.ToUpper(strings.TrimSpace(q.Get("ticker"))),
+		PromptVersion: q.Get("prompt_version"),
+		ErrorsOnly:    q.Get("errors") == "true",
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		data.Offset = n
+	}
+
+	logs, total, err := s.repo.ListAnalysisLogs(storage.AnalysisLogFilter{
+		PromptVersion: data.PromptVersion,
+		Ticker:        data.Ticker,
+		ErrorsOnly:    data.ErrorsOnly,
+		Summary:       true,
+		Limit:         analysisPageSize,
+		Offset:        data.Offset,
+	})
+	if err != nil {
+		s.logger.Error("list analysis logs", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	data.Total = total
+	for i := range logs {
+		row := CycleRow{apiAnalysisLog: toAPIAnalysisLog(&logs[i], false)}
+		for _, o := range row.Execution {
+			switch o.Status {
+			case executor.OutcomeExecuted:
+				row.Executed++
+			case executor.OutcomeFailed:
+				row.Failed++
+			}
+		}
+		data.Cycles = append(data.Cycles, row)
+	}
+
+	if data.Offset > 0 {
+		data.PrevURL = analysisListURL(q, max(data.Offset-analysisPageSize, 0))
+	}
+	if int64(data.Offset+analysisPageSize) < total {
+		data.NextURL = analysisListURL(q, data.Offset+analysisPageSize)
+	}
+	if p := currentPrincipal(r); p != nil {
+		data.User = p.Username
+		data.Role = p.Role
+	}
+	s.renderPage(w, "analysis.html", data)
+}
+
+// analysisListURL keeps the current filters and moves to another page.
+func analysisListURL(q url.Values, offset int) string {
+	v := url.Values{}
+	for _, k := range []string{"ticker", "prompt_version", "errors"} {
+		if q.Get(k) != "" {
+			v.Set(k, q.Get(k))
+		}
+	}
+	if offset > 0 {
+		v.Set("offset", strconv.Itoa(offset))
+	}
+	if len(v) == 0 {
+		return "/analysis"
+	}
+	return "/analysis?" + v.Encode()
+}
+
+func (s *Server) handleAnalysisDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	log, err := s.repo.GetAnalysisLogByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		s.logger.Error("get analysis log", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	trades, err := s.repo.GetTradesByAnalysisLog(id)
+	if err != nil {
+		s.logger.Error("get cycle trades", "error", err)
+	}
+
+	data := AnalysisDetailData{
+		Log:    toAPIAnalysisLog(log, true),
+		Trades: trades,
+	}
+	data.Decisions = traceDecisions(data.Log, trades)
+	if p := currentPrincipal(r); p != nil {
+		data.User = p.Username
+		data.Role = p.Role
+	}
+	s.renderPage(w, "analysis_detail.html", data)
+}
+
+// traceDecisions pairs every decision with its guard verdict, executor outcome
+// and trade. Decisions are matched by ticker and action in order.
+func traceDecisions(log apiAnalysisLog, trades []storage.Trade) []CycleDecision {
+	blockedUsed := make([]bool, len(log.Blocked))
+	outcomeUsed := make([]bool, len(log.Execution))
+	out := make([]CycleDecision, 0, len(log.Decisions))
+	for _, d := range log.Decisions {
+		cd := CycleDecision{DecisionView: DecisionView{
+			AIDecision:        d,
+			ReasoningExcerpts: ai.ReasoningForTicker(log.Reasoning, d.Ticker),
+		}}
+		for i, b := range log.Blocked {
+			if !blockedUsed[i] && b.Decision.Ticker == d.Ticker && b.Decision.Action == d.Action {
+				blockedUsed[i] = true
+				cd.BlockedReason = b.Reason
+				break
+			}
+		}
+		if cd.BlockedReason == "" {
+			for i, o := range log.Execution {
+				if !outcomeUsed[i] && o.Ticker == d.Ticker && o.Action == d.Action {
+					outcomeUsed[i] = true
+					cd.Outcome = &log.Execution[i]
+					break
+				}
+			}
+		}
+		if cd.Outcome != nil && cd.Outcome.OrderID != "" {
+			for i := range trades {
+				if trades[i].OrderID == cd.Outcome.OrderID {
+					cd.Trade = &trades[i]
+					break
+				}
+			}
+		}
+		out = append(out, cd)
+	}
+	return out
+}
+
+// renderPage executes a page template and writes it.
+func (s *Server) renderPage(w http.ResponseWriter, name string, data any) {
+	tmpl, err := s.assets.page(name)
+	if err != nil {
+		s.logger.Error("parse template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		s.logger.Error("execute template", "error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/analytics"
+)
+
+const defaultAnalyticsDays = 90
+
+// analyticsRanges are the period presets offered on the analytics page; 0 = all history.
+var analyticsRanges = []int{7, 30, 90, 365, 0}
+
+type AnalyticsData struct {
+	Report analytics.Report
+	Days   int
+	Ranges []int
+	Chart  analyticsChart
+	User   string
+	Role   string
+}
+
+// analyticsChart is embedded into the page as JSON for static/charts.js.
+type analyticsChart struct {
+	Equity  []analytics.EquityPoint  `json:"equity"`
+	Daily   []analytics.PeriodReturn `json:"daily"`
+	Weekly  []analytics.PeriodReturn `json:"weekly"`
+	Monthly []analytics.PeriodReturn `json:"monthly"`
+}
+
+// buildAnalytics loads snapshots and closed round trips for [from, to) and computes
+// the report; a zero bound is open.
+func (s *Server) buildAnalytics(from, to time.Time) (analytics.Report, error) {
+	snapshots, err := s.repo.GetSnapshotsBetween(from, to)
+	if err != nil {
+		return analytics.Report{}, err
+	}
+	trades, err := s.repo.GetRoundTripsClosedBetween(from, to)
+	if err != nil {
+		return analytics.Report{}, err
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	return analytics.Build(snapshots, trades, from, to, s.config.MOEXLocation()), nil
+}
+
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	days := defaultAnalyticsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	var from time.Time
+	if days > 0 {
+		from = time.Now().AddDate(0, 0, -days)
+	}
+
+	report, err := s.buildAnalytics(from, time.Time{})
+	if err != nil {
+		s.logger.Error("build analytics", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	data := AnalyticsData{
+		Report: report,
+		Days:   days,
+		Ranges: analyticsRanges,
+		Chart: analyticsChart{
+			Equity:  report.Equity,
+			Daily:   report.Daily,
+			Weekly:  report.Weekly,
+			Monthly: report.Monthly,
+		},
+	}
+	if p := currentPrincipal(r); p != nil {
+		data.User = p.Username
+		data.Role = p.Role
+	}
+
+	s.renderPage(w, "analytics.html", data)
+}
+
+// pageFuncs are available to all page templates.
+var pageFuncs = template.FuncMap{
+	"duration": formatHours,
+	"rangeLabel": func(days int) string {
+		if days == 0 {
+			return "всё время"
+		}
+		return fmt.Sprintf("%d дн.", days)
+	},
+}
+
+// formatHours renders a duration in hours as "2 д 5 ч" or "3 ч 20 мин".
+func formatHours(hours float64) string {
+	d := time.Duration(hours * float64(time.Hour)).Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	h := int(d % (24 * time.Hour) / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%d д %d ч", days, h)
+	case h > 0:
+		return fmt.Sprintf("%d ч %d мин", h, m)
+	default:
+		return fmt.Sprintf("%d мин", m)
+	}
+}
+
+// handleAPIAnalytics returns the performance report; the default range is the last 90 days.
+func (s *Server) handleAPIAnalytics(w http.ResponseWriter, r *http.Request) {
+	from, to, err := s.parseRange(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if r.URL.Query().Get("from") == "" {
+		end := to
+		if end.IsZero() {
+			end = time.Now()
+		}
+		from = end.AddDate(0, 0, -defaultAnalyticsDays)
+	}
+
+	report, err := s.buildAnalytics(from, to)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	if r.URL.Query().Get("equity") == "false" {
+		report.Equity = nil
+	}
+	s.writeData(w, report, nil)
+}
@@ -0,0 +1,545 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"github.com/camuig/rus-trader/internal/ai"
+	"github.com/camuig/rus-trader/internal/broker"
+	"github.com/camuig/rus-trader/internal/executor"
+	"github.com/camuig/rus-trader/internal/guard"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+const (
+	apiDefaultLimit = 50
+	apiMaxLimit     = 500
+)
+
+// apiResponse is the success envelope of /api/v1.
+type apiResponse struct {
+	Data       any            `json:"data"`
+	Pagination *apiPagination `json:"pagination,omitempty"`
+}
+
+type apiPagination struct {
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// apiErrorResponse is the error envelope of /api/v1.
+type apiErrorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (s *Server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/portfolio", s.handleAPIPortfolio)
+	mux.HandleFunc("GET /api/v1/positions", s.handleAPIPositions)
+	mux.HandleFunc("GET /api/v1/trades", s.handleAPITrades)
+	mux.HandleFunc("GET /api/v1/trades/{id}", s.handleAPITrade)
+	mux.HandleFunc("GET /api/v1/analysis", s.handleAPIAnalysisLogs)
+	mux.HandleFunc("GET /api/v1/analysis/{id}", s.handleAPIAnalysisLog)
+	mux.HandleFunc("GET /api/v1/pnl", s.handleAPIPnL)
+	mux.HandleFunc("GET /api/v1/analytics", s.handleAPIAnalytics)
+	mux.HandleFunc("GET /api/v1/config", s.handleAPIConfig)
+	mux.HandleFunc("GET /api/v1/audit", s.requireOperator(s.handleAPIAudit))
+	mux.HandleFunc("GET /api/v1/events", s.handleAPIEvents)
+	mux.HandleFunc("GET /api/v1/universe", s.handleAPIUniverse)
+	mux.HandleFunc("/api/", s.handleAPINotFound)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Error("encode API response", "error", err)
+	}
+}
+
+func (s *Server) writeData(w http.ResponseWriter, data any, page *apiPagination) {
+	s.writeJSON(w, http.StatusOK, apiResponse{Data: data, Pagination: page})
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, code, message string) {
+	s.writeJSON(w, status, apiErrorResponse{Error: apiError{Code: code, Message: message}})
+}
+
+// writeStorageError maps repository errors to the API envelope.
+func (s *Server) writeStorageError(w http.ResponseWriter, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.writeError(w, http.StatusNotFound, "not_found", "resource not found")
+		return
+	}
+	s.logger.Error("API storage error", "error", err)
+	s.writeError(w, http.StatusInternalServerError, "internal", "internal server error")
+}
+
+func (s *Server) handleAPINotFound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET and POST are supported")
+		return
+	}
+	s.writeError(w, http.StatusNotFound, "not_found", "unknown endpoint "+r.URL.Path)
+}
+
+// Portfolio
+
+type apiPortfolio struct {
+	Source       string            `json:"source"` // live or snapshot
+	UpdatedAt    time.Time         `json:"updated_at"`
+	TotalRub     float64           `json:"total_rub"`
+	AvailableRub float64           `json:"available_rub"`
+	Positions    []apiLivePosition `json:"positions"`
+}
+
+type apiLivePosition struct {
+	Ticker       string  `json:"ticker"`
+	Quantity     float64 `json:"quantity"`
+	AvgPrice     float64 `json:"avg_price"`
+	CurrentPrice float64 `json:"current_price"`
+	PnL          float64 `json:"pnl"`
+}
+
+func (s *Server) handleAPIPortfolio(w http.ResponseWriter, r *http.Request) {
+	if portfolio, view := s.live.get(); portfolio != nil {
+		out := apiPortfolio{
+			Source:       "live",
+			UpdatedAt:    view.UpdatedAt,
+			TotalRub:     portfolio.TotalRub,
+			AvailableRub: portfolio.AvailableRub,
+			Positions:    toAPIPositions(portfolio.Positions),
+		}
+		s.writeData(w, out, nil)
+		return
+	}
+
+	snapshot, err := s.repo.GetLatestSnapshot()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.writeError(w, http.StatusServiceUnavailable, "unavailable", "broker unavailable and no portfolio snapshot saved")
+			return
+		}
+		s.writeStorageError(w, err)
+		return
+	}
+	out := apiPortfolio{
+		Source:       "snapshot",
+		UpdatedAt:    snapshot.CreatedAt,
+		TotalRub:     snapshot.TotalRub,
+		AvailableRub: snapshot.AvailableRub,
+	}
+	var positions []broker.PositionInfo
+	if snapshot.PositionsJSON != "" {
+		_ = json.Unmarshal([]byte(snapshot.PositionsJSON), &positions)
+	}
+	out.Positions = toAPIPositions(positions)
+	s.writeData(w, out, nil)
+}
+
+func toAPIPositions(positions []broker.PositionInfo) []apiLivePosition {
+	out := make([]apiLivePosition, 0, len(positions))
+	for _, p := range positions {
+		out = append(out, apiLivePosition{
+			Ticker:       p.Ticker,
+			Quantity:     p.Quantity,
+			AvgPrice:     p.AvgPrice,
+			CurrentPrice: p.CurrentPrice,
+			PnL:          p.PnL,
+		})
+	}
+	return out
+}
+
+func (s *Server) handleAPIPositions(w http.ResponseWriter, r *http.Request) {
+	trades, err := s.repo.GetOpenTrades()
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	portfolio, _ := s.live.get()
+	s.writeData(w, buildOpenPositions(trades, portfolio), nil)
+}
+
+// Trades
+
+func (s *Server) handleAPITrades(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := storage.TradeFilter{
+		Ticker: strings.ToUpper(q.Get("ticker")),
+		Action: strings.ToUpper(q.Get("action")),
+		Status: strings.ToLower(q.Get("status")),
+	}
+	if filter.Action != "" && filter.Action != "BUY" && filter.Action != "SELL" {
+		s.writeError(w, http.StatusBadRequest, "bad_request", "action must be BUY or SELL")
+		return
+	}
+	if filter.Status != "" && filter.Status != "open" && filter.Status != "closed" {
+		s.writeError(w, http.StatusBadRequest, "bad_request", "status must be open or closed")
+		return
+	}
+
+	var err error
+	if filter.From, filter.To, err = s.parseRange(r); err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if filter.Limit, filter.Offset, err = parsePage(r); err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	trades, total, err := s.repo.ListTrades(filter)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	s.writeData(w, trades, &apiPagination{Total: total, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+func (s *Server) handleAPITrade(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	trade, err := s.repo.GetTradeByID(id)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	s.writeData(w, trade, nil)
+}
+
+// Analysis logs
+
+type apiAnalysisLog struct {
+	ID            uint                    `json:"id"`
+	CreatedAt     time.Time               `json:"created_at"`
+	SignalsCount  int                     `json:"signals_count"`
+	Model         string                  `json:"model"`
+	PromptVersion string                  `json:"prompt_version"`
+	Error         string                  `json:"error,omitempty"`
+	Decisions     []ai.AIDecision         `json:"decisions"`
+	Blocked       []guard.BlockedDecision `json:"blocked"`
+	Execution     []executor.Outcome      `json:"execution"` // per allowed decision; empty for cycles before it was recorded
+
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`
+	TokensEstimated  bool    `json:"tokens_estimated"`
+	LatencyMs        int64   `json:"latency_ms"`
+	ReasoningMs      int64   `json:"reasoning_ms"`
+	AnswerMs         int64   `json:"answer_ms"`
+	ToolCallsCount   int     `json:"tool_calls_count"`
+	ToolMs           int64   `json:"tool_ms"`
+	CostUSD          float64 `json:"cost_usd"`
+	CostRub          float64 `json:"cost_rub"`
+
+	// Large fields, only with full=true or on the single-log endpoint.
+	SystemPrompt string              `json:"system_prompt,omitempty"`
+	UserPrompt   string              `json:"user_prompt,omitempty"`
+	AIResponse   string              `json:"ai_response,omitempty"`
+	Reasoning    string              `json:"reasoning,omitempty"`
+	ToolCalls    []ai.ToolCallRecord `json:"tool_calls,omitempty"`
+}
+
+func toAPIAnalysisLog(log *storage.AnalysisLog, full bool) apiAnalysisLog {
+	out := apiAnalysisLog{
+		ID:               log.ID,
+		CreatedAt:        log.CreatedAt,
+		SignalsCount:     log.SignalsCount,
+		Model:            log.Model,
+		PromptVersion:    log.PromptVersion,
+		Error:            log.Error,
+		Decisions:        []ai.AIDecision{},
+		Blocked:          []guard.BlockedDecision{},
+		Execution:        []executor.Outcome{},
+		PromptTokens:     log.PromptTokens,
+		CompletionTokens: log.CompletionTokens,
+		ReasoningTokens:  log.ReasoningTokens,
+		TokensEstimated:  log.TokensEstimated,
+		LatencyMs:        log.LatencyMs,
+		ReasoningMs:      log.ReasoningMs,
+		AnswerMs:         log.AnswerMs,
+		ToolCallsCount:   log.ToolCallsCount,
+		ToolMs:           log.ToolMs,
+		CostUSD:          log.CostUSD,
+		CostRub:          log.CostRub,
+	}
+	if log.DecisionsJSON != "" {
+		_ = json.Unmarshal([]byte(log.DecisionsJSON), &out.Decisions)
+	}
+	if log.BlockedJSON != "" {
+		_ = json.Unmarshal([]byte(log.BlockedJSON), &out.Blocked)
+	}
+	if log.ExecutionJSON != "" {
+		_ = json.Unmarshal([]byte(log.ExecutionJSON), &out.Execution)
+	}
+	if full {
+		out.SystemPrompt = log.SystemPrompt
+		out.UserPrompt = log.UserPrompt
+		out.AIResponse = log.AIResponse
+		out.Reasoning = log.Reasoning
+		if log.ToolCallsJSON != "" {
+			_ = json.Unmarshal([]byte(log.ToolCallsJSON), &out.ToolCalls)
+		}
+	}
+	return out
+}
+
+func (s *Server) handleAPIAnalysisLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := storage.AnalysisLogFilter{
+		PromptVersion: q.Get("prompt_version"),
+		Ticker:        strings.ToUpper(q.Get("ticker")),
+		ErrorsOnly:    q.Get("errors") == "true",
+	}
+	full := q.Get("full") == "true"
+	filter.Summary = !full
+
+	var err error
+	if filter.From, filter.To, err = s.parseRange(r); err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if filter.Limit, filter.Offset, err = parsePage(r); err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	logs, total, err := s.repo.ListAnalysisLogs(filter)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	items := make([]apiAnalysisLog, 0, len(logs))
+	for i := range logs {
+		items = append(items, toAPIAnalysisLog(&logs[i], full))
+	}
+	s.writeData(w, items, &apiPagination{Total: total, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+func (s *Server) handleAPIAnalysisLog(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	log, err := s.repo.GetAnalysisLogByID(id)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	s.writeData(w, toAPIAnalysisLog(log, true), nil)
+}
+
+// P&L
+
+type apiPnLPoint struct {
+	Time       time.Time `json:"time"` // period start, MSK
+	PnL        float64   `json:"pnl"`
+	Trades     int       `json:"trades"`
+	Cumulative float64   `json:"cumulative"`
+}
+
+// handleAPIPnL returns realised P&L of closed positions bucketed by hour or day (MSK).
+func (s *Server) handleAPIPnL(w http.ResponseWriter, r *http.Request) {
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "day"
+	}
+	if interval != "day" && interval != "hour" {
+		s.writeError(w, http.StatusBadRequest, "bad_request", "interval must be day or hour")
+		return
+	}
+	from, to, err := s.parseRange(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	trades, err := s.repo.GetClosedSellTrades(from, to)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	s.writeData(w, pnlSeries(trades, interval, s.config.MOEXLocation()), nil)
+}
+
+func pnlSeries(trades []storage.Trade, interval string, loc *time.Location) []apiPnLPoint {
+	points := []apiPnLPoint{}
+	var cumulative float64
+	for _, t := range trades {
+		at := t.CreatedAt.In(loc)
+		period := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+		if interval == "hour" {
+			period = period.Add(time.Duration(at.Hour()) * time.Hour)
+		}
+		cumulative += t.PnL
+		if n := len(points); n > 0 && points[n-1].Time.Equal(period) {
+			points[n-1].PnL += t.PnL
+			points[n-1].Trades++
+			points[n-1].Cumulative = cumulative
+			continue
+		}
+		points = append(points, apiPnLPoint{Time: period, PnL: t.PnL, Trades: 1, Cumulative: cumulative})
+	}
+	return points
+}
+
+// Config
+
+func (s *Server) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
+	// Round-trip through YAML so keys match the config file.
+	redacted := s.config.Redacted()
+	data, err := yaml.Marshal(&redacted)
+	if err != nil {
+		s.logger.Error("marshal config", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "internal", "internal server error")
+		return
+	}
+	var out map[string]any
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		s.logger.Error("unmarshal config", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "internal", "internal server error")
+		return
+	}
+	s.writeData(w, out, nil)
+}
+
+// Universe
+
+type apiUniverse struct {
+	Date    string                  `json:"date"`
+	Entries []storage.UniverseEntry `json:"entries"`
+}
+
+// handleAPIUniverse returns the universe of a day (the latest by default)
+// with every candidate and its reason, or with ticker the ticker's latest
+// days, newest first.
+func (s *Server) handleAPIUniverse(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if ticker := strings.ToUpper(q.Get("ticker")); ticker != "" {
+		limit, _, err := parsePage(r)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		entries, err := s.repo.GetTickerUniverseHistory(ticker, limit)
+		if err != nil {
+			s.writeStorageError(w, err)
+			return
+		}
+		s.writeData(w, entries, nil)
+		return
+	}
+
+	date := q.Get("date")
+	if date == "" {
+		latest, err := s.repo.GetLatestUniverseDate("")
+		if err != nil {
+			s.writeStorageError(w, err)
+			return
+		}
+		date = latest
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", "date must be YYYY-MM-DD")
+		return
+	}
+	entries := []storage.UniverseEntry{}
+	if date != "" {
+		var err error
+		if entries, err = s.repo.GetUniverse(date); err != nil {
+			s.writeStorageError(w, err)
+			return
+		}
+	}
+	s.writeData(w, apiUniverse{Date: date, Entries: entries}, nil)
+}
+
+// Audit log
+
+func (s *Server) handleAPIAudit(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	entries, total, err := s.repo.ListAuditLogs(r.URL.Query().Get("username"), limit, offset)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	s.writeData(w, entries, &apiPagination{Total: total, Limit: limit, Offset: offset})
+}
+
+// Query parsing
+
+// parseRange reads from/to as RFC 3339 or YYYY-MM-DD (midnight MSK).
+func (s *Server) parseRange(r *http.Request) (from, to time.Time, err error) {
+	q := r.URL.Query()
+	if from, err = s.parseTime(q.Get("from")); err != nil {
+		return from, to, fmt.Errorf("invalid from: %w", err)
+	}
+	if to, err = s.parseTime(q.Get("to")); err != nil {
+		return from, to, fmt.Errorf("invalid to: %w", err)
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return from, to, fmt.Errorf("to must be after from")
+	}
+	return from, to, nil
+}
+
+func (s *Server) parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, s.config.MOEXLocation())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 or YYYY-MM-DD, got %q", v)
+	}
+	return t, nil
+}
+
+func parsePage(r *http.Request) (limit, offset int, err error) {
+	q := r.URL.Query()
+	limit = apiDefaultLimit
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > apiMaxLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", apiMaxLimit)
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
+
+func parseID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid id %q", r.PathValue("id"))
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+//go:embed templates static
+var embedded embed.FS
+
+// assets provides page templates and static files. By default they are
+// embedded in the binary and templates are parsed once at startup; in dev
+// mode they are read from disk and reparsed on every request.
+type assets struct {
+	dir   string // dev mode: directory holding templates/ and static/
+	pages map[string]*template.Template
+}
+
+func newAssets(dir string) (*assets, error) {
+	a := &assets{dir: dir}
+	if dir != "" {
+		// fail early on a wrong directory, but keep reading from disk
+		_, err := a.parseAll(os.DirFS(dir))
+		return a, err
+	}
+	pages, err := a.parseAll(embedded)
+	if err != nil {
+		return nil, err
+	}
+	a.pages = pages
+	return a, nil
+}
+
+func (a *assets) parseAll(fsys fs.FS) (map[string]*template.Template, error) {
+	files, err := fs.Glob(fsys, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no templates found")
+	}
+	pages := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		tmpl, err := parseTemplate(fsys, filepath.Base(file))
+		if err != nil {
+			return nil, err
+		}
+		pages[filepath.Base(file)] = tmpl
+	}
+	return pages, nil
+}
+
+// parseTemplate parses one page with the shared page functions.
+func parseTemplate(fsys fs.FS, name string) (*template.Template, error) {
+	return template.New(name).Funcs(pageFuncs).ParseFS(fsys, "templates/"+name)
+}
+
+// page returns the template of a page by file name, e.g. "dashboard.html".
+func (a *assets) page(name string) (*template.Template, error) {
+	if a.dir != "" {
+		return parseTemplate(os.DirFS(a.dir), name)
+	}
+	tmpl, ok := a.pages[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	return tmpl, nil
+}
+
+// static serves files under /static/.
+func (a *assets) static() http.Handler {
+	if a.dir != "" {
+		return http.FileServer(http.Dir(filepath.Join(a.dir, "static")))
+	}
+	sub, err := fs.Sub(embedded, "static")
+	if err != nil {
+		panic(err) // the embed directive guarantees the directory
+	}
+	return http.FileServer(http.FS(sub))
+}
@@ -0,0 +1,447 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+const (
+	sessionCookie = "rt_session"
+
+	authSession = "session"
+	authBasic   = "basic"
+	authToken   = "token"
+
+	// Login throttling per client IP.
+	maxLoginFailures   = 5
+	loginFailureWindow = 15 * time.Minute
+)
+
+// principal is the authenticated caller of a request.
+type principal struct {
+	Username string
+	Role     string
+	Method   string // session, basic, token
+}
+
+func (p *principal) isOperator() bool {
+	return p != nil && p.Role == config.RoleOperator
+}
+
+type principalKey struct{}
+
+// currentPrincipal returns the caller, or nil when auth is disabled.
+func currentPrincipal(r *http.Request) *principal {
+	p, _ := r.Context().Value(principalKey{}).(*principal)
+	return p
+}
+
+type session struct {
+	principal
+	expires time.Time
+}
+
+// authenticator holds sessions and login throttling state. Sessions live in
+// memory, so a restart logs everyone out.
+type authenticator struct {
+	cfg  config.WebAuth
+	ttl  time.Duration
+	mu   sync.Mutex
+	sess map[string]*session
+	fail map[string][]time.Time // client IP -> recent failed logins
+}
+
+func newAuthenticator(cfg config.WebAuth) *authenticator {
+	return &authenticator{
+		cfg:  cfg,
+		ttl:  time.Duration(cfg.SessionTTLHours) * time.Hour,
+		sess: make(map[string]*session),
+		fail: make(map[string][]time.Time),
+	}
+}
+
+// checkPassword verifies user credentials against bcrypt hashes.
+func (a *authenticator) checkPassword(username, password string) *principal {
+	for _, u := range a.cfg.Users {
+		if u.Username != username {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+			return nil
+		}
+		return &principal{Username: u.Username, Role: u.Role}
+	}
+	// Same cost for unknown users, so timing does not reveal valid usernames.
+	_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+	return nil
+}
+
+var dummyHash = sync.OnceValue(func() []byte {
+	h, _ := bcrypt.GenerateFromPassword([]byte("rus-trader"), bcrypt.DefaultCost)
+	return h
+})
+
+// checkToken verifies an API bearer token against configured SHA-256 hashes.
+func (a *authenticator) checkToken(token string) *principal {
+	sum := sha256.Sum256([]byte(token))
+	got := []byte(hex.EncodeToString(sum[:]))
+	for _, t := range a.cfg.Tokens {
+		if subtle.ConstantTimeCompare(got, []byte(strings.ToLower(t.TokenSHA256))) == 1 {
+			return &principal{Username: "token:" + t.Name, Role: t.Role}
+		}
+	}
+	return nil
+}
+
+func (a *authenticator) newSession(p principal) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := base64.RawURLEncoding.EncodeToString(buf)
+	p.Method = authSession
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for k, s := range a.sess {
+		if now.After(s.expires) {
+			delete(a.sess, k)
+		}
+	}
+	a.sess[id] = &session{principal: p, expires: now.Add(a.ttl)}
+	return id, nil
+}
+
+func (a *authenticator) lookupSession(id string) *principal {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sess[id]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(s.expires) {
+		delete(a.sess, id)
+		return nil
+	}
+	p := s.principal
+	return &p
+}
+
+func (a *authenticator) deleteSession(id string) {
+	a.mu.Lock()
+	delete(a.sess, id)
+	a.mu.Unlock()
+}
+
+// throttled reports whether the client has too many recent failed logins.
+func (a *authenticator) throttled(ip string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cutoff := time.Now().Add(-loginFailureWindow)
+	recent := a.fail[ip][:0]
+	for _, t := range a.fail[ip] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(a.fail, ip)
+		return false
+	}
+	a.fail[ip] = recent
+	return len(recent) >= maxLoginFailures
+}
+
+func (a *authenticator) recordFailure(ip string) {
+	a.mu.Lock()
+	a.fail[ip] = append(a.fail[ip], time.Now())
+	a.mu.Unlock()
+}
+
+func (a *authenticator) clearFailures(ip string) {
+	a.mu.Lock()
+	delete(a.fail, ip)
+	a.mu.Unlock()
+}
+
+var (
+	errLoginThrottled = errors.New("too many failed logins")
+	errLoginFailed    = errors.New("invalid username or password")
+)
+
+// authenticate resolves the caller from session cookie, basic auth or bearer token.
+// Basic auth passwords count against the same per-IP limit as the login form:
+// errLoginFailed reports a wrong password, errLoginThrottled a client over the
+// limit, whose password is not checked.
+func (a *authenticator) authenticate(r *http.Request) (*principal, error) {
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		if p := a.lookupSession(c.Value); p != nil {
+			return p, nil
+		}
+	}
+	if user, pass, ok := r.BasicAuth(); ok {
+		ip := clientIP(r)
+		if a.throttled(ip) {
+			return nil, errLoginThrottled
+		}
+		p := a.checkPassword(user, pass)
+		if p == nil {
+			a.recordFailure(ip)
+			return nil, errLoginFailed
+		}
+		a.clearFailures(ip)
+		p.Method = authBasic
+		return p, nil
+	}
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		if p := a.checkToken(strings.TrimPrefix(h, "Bearer ")); p != nil {
+			p.Method = authToken
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// statusRecorder captures the response status for the audit log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush keeps streaming responses working through the recorder.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func isPublicPath(path string) bool {
+	return path == "/login" || path == "/healthz" || path == "/readyz" || strings.HasPrefix(path, "/static/")
+}
+
+// withAuth requires an authenticated caller for everything except the login page,
+// health endpoints and static assets, and records each authenticated request in the audit log.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	if s.auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := s.auth.authenticate(r)
+		if err != nil {
+			user, _, _ := r.BasicAuth()
+			if errors.Is(err, errLoginThrottled) {
+				s.audit(r, &principal{Username: user, Method: authBasic}, "login_throttled", http.StatusTooManyRequests)
+				if strings.HasPrefix(r.URL.Path, "/api/") {
+					s.writeError(w, http.StatusTooManyRequests, "too_many_requests", "too many failed logins, try again later")
+					return
+				}
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+			s.audit(r, &principal{Username: user, Method: authBasic}, "login_failed", http.StatusUnauthorized)
+		}
+		if p == nil {
+			if strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == metricsPath {
+				w.Header().Set("WWW-Authenticate", `Basic realm="rus-trader"`)
+				s.writeError(w, http.StatusUnauthorized, "unauthorized", "authentication required")
+				return
+			}
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+		if r.URL.Path != metricsPath { // scrapes every few seconds would flood the log
+			s.audit(r, p, "request", rec.status)
+		}
+	})
+}
+
+// requireOperator wraps handlers that change trading state.
+func (s *Server) requireOperator(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth != nil && !currentPrincipal(r).isOperator() {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				s.writeError(w, http.StatusForbidden, "forbidden", "operator role required")
+				return
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) audit(r *http.Request, p *principal, action string, status int) {
+	s.auditAction(r, p, action, status, "")
+}
+
+// auditAction records an action with its parameters and result.
+func (s *Server) auditAction(r *http.Request, p *principal, action string, status int, detail string) {
+	entry := &storage.AuditLog{
+		Action:     action,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     status,
+		RemoteAddr: clientIP(r),
+		Detail:     detail,
+	}
+	if p != nil {
+		entry.Username = p.Username
+		entry.Role = p.Role
+		entry.AuthMethod = p.Method
+	}
+	if err := s.repo.SaveAuditLog(entry); err != nil {
+		s.logger.Error("save audit log", "error", err)
+	}
+}
+
+// crossOrigin reports a browser request sent by another site. Browsers attach
+// HTTP Basic credentials to cross-site requests, so SameSite on the session
+// cookie alone does not stop a forged form post. Requests with neither
+// Sec-Fetch-Site nor Origin come from non-browser clients.
+func crossOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return false
+	case "":
+	default:
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Login page
+
+type loginData struct {
+	Error  string
+	Next   string
+	status int
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	data := loginData{Next: safeNext(r.FormValue("next"))}
+	if r.Method == http.MethodPost {
+		ip := clientIP(r)
+		username := r.PostFormValue("username")
+		switch {
+		case s.auth.throttled(ip):
+			data.Error = "Слишком много неудачных попыток, попробуйте позже"
+			data.status = http.StatusTooManyRequests
+			s.audit(r, &principal{Username: username}, "login_throttled", http.StatusTooManyRequests)
+		default:
+			p := s.auth.checkPassword(username, r.PostFormValue("password"))
+			if p == nil {
+				s.auth.recordFailure(ip)
+				data.Error = "Неверный логин или пароль"
+				data.status = http.StatusUnauthorized
+				s.audit(r, &principal{Username: username}, "login_failed", http.StatusUnauthorized)
+				break
+			}
+			id, err := s.auth.newSession(*p)
+			if err != nil {
+				s.logger.Error("create session", "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			s.auth.clearFailures(ip)
+			http.SetCookie(w, &http.Cookie{
+				Name:     sessionCookie,
+				Value:    id,
+				Path:     "/",
+				MaxAge:   int(s.auth.ttl.Seconds()),
+				HttpOnly: true,
+				Secure:   s.config.Web.TLS.Enabled(),
+				SameSite: http.SameSiteStrictMode,
+			})
+			p.Method = authSession
+			s.audit(r, p, "login", http.StatusSeeOther)
+			http.Redirect(w, r, data.Next, http.StatusSeeOther)
+			return
+		}
+	}
+
+	tmpl, err := s.assets.page("login.html")
+	if err != nil {
+		s.logger.Error("parse template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if data.status != 0 {
+		w.WriteHeader(data.status)
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		s.logger.Error("execute template", "error", err)
+	}
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if s.auth != nil {
+		if c, err := r.Cookie(sessionCookie); err == nil {
+			s.auth.deleteSession(c.Value)
+		}
+		s.audit(r, currentPrincipal(r), "logout", http.StatusSeeOther)
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// safeNext keeps post-login redirects on this site.
+func safeNext(next string) string {
+	if next == "" || !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,221 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/config"
+)
+
+func TestAuthenticator_Credentials(t *testing.T) {
+	s, _ := newTestServer(t)
+	a := s.auth
+
+	cases := []struct {
+		name  string
+		setup func(r *http.Request)
+		user  string // "" when rejected
+		role  string
+		err   error
+	}{
+		{name: "no credentials", setup: func(r *http.Request) {}},
+		{name: "operator basic", setup: func(r *http.Request) { r.SetBasicAuth("op", "secret") }, user: "op", role: config.RoleOperator},
+		{name: "viewer basic", setup: func(r *http.Request) { r.SetBasicAuth("view", "secret") }, user: "view", role: config.RoleViewer},
+		{name: "wrong password", setup: func(r *http.Request) { r.SetBasicAuth("op", "Secret") }, err: errLoginFailed},
+		{name: "unknown user", setup: func(r *http.Request) { r.SetBasicAuth("root", "secret") }, err: errLoginFailed},
+		{name: "token", setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer view-token") }, user: "token:ci", role: config.RoleViewer},
+		{name: "wrong token", setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer view-token2") }},
+		{name: "token without scheme", setup: func(r *http.Request) { r.Header.Set("Authorization", "view-token") }},
+		{name: "unknown session", setup: func(r *http.Request) { r.AddCookie(&http.Cookie{Name: sessionCookie, Value: "forged"}) }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/positions", nil)
+			c.setup(r)
+			p, err := a.authenticate(r)
+			if err != c.err {
+				t.Fatalf("error %v, want %v", err, c.err)
+			}
+			switch {
+			case c.user == "" && p != nil:
+				t.Errorf("expected no principal, got %+v", p)
+			case c.user != "" && (p == nil || p.Username != c.user || p.Role != c.role):
+				t.Errorf("principal %+v, want %s/%s", p, c.user, c.role)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_TokenHashIsCaseInsensitive(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.auth.cfg.Tokens[0].TokenSHA256 = strings.ToUpper(s.auth.cfg.Tokens[0].TokenSHA256)
+	if p := s.auth.checkToken("view-token"); p == nil {
+		t.Error("expected an upper-case hash to match")
+	}
+}
+
+func TestAuthenticator_SessionExpiry(t *testing.T) {
+	s, _ := newTestServer(t)
+	a := s.auth
+
+	id, err := a.newSession(principal{Username: "op", Role: config.RoleOperator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: id})
+	if p, err := a.authenticate(r); err != nil || p == nil || p.Username != "op" || p.Method != authSession {
+		t.Fatalf("expected the session principal, got %+v, %v", p, err)
+	}
+
+	a.sess[id].expires = time.Now().Add(-time.Second)
+	if p := a.lookupSession(id); p != nil {
+		t.Errorf("expected an expired session to be rejected, got %+v", p)
+	}
+	if _, ok := a.sess[id]; ok {
+		t.Error("expected the expired session to be dropped")
+	}
+
+	id, _ = a.newSession(principal{Username: "op"})
+	a.deleteSession(id)
+	if p := a.lookupSession(id); p != nil {
+		t.Errorf("expected a logged out session to be rejected, got %+v", p)
+	}
+}
+
+func TestAuthenticator_Throttle(t *testing.T) {
+	s, _ := newTestServer(t)
+	a := s.auth
+	const ip = "192.0.2.1"
+
+	for i := 0; i < maxLoginFailures-1; i++ {
+		a.recordFailure(ip)
+	}
+	if a.throttled(ip) {
+		t.Fatalf("throttled after %d failures", maxLoginFailures-1)
+	}
+	a.recordFailure(ip)
+	if !a.throttled(ip) || a.throttled("192.0.2.2") {
+		t.Fatal("expected only the failing IP to be throttled")
+	}
+
+	// failures older than the window no longer count
+	for i := range a.fail[ip] {
+		a.fail[ip][i] = time.Now().Add(-loginFailureWindow - time.Second)
+	}
+	if a.throttled(ip) {
+		t.Error("expected old failures to expire")
+	}
+
+	for i := 0; i < maxLoginFailures; i++ {
+		a.recordFailure(ip)
+	}
+	a.clearFailures(ip)
+	if a.throttled(ip) {
+		t.Error("expected a successful login to clear the failures")
+	}
+}
+
+func TestWithAuth_ThrottlesBasicAndLoginForm(t *testing.T) {
+	s, _ := newTestServer(t)
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, r)
+		return w
+	}
+	basic := func(password string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/control", nil)
+		r.SetBasicAuth("op", password)
+		return r
+	}
+
+	for i := 0; i < maxLoginFailures; i++ {
+		if w := serve(basic("wrong")); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status %d", i+1, w.Code)
+		}
+	}
+	// the right password is not even checked once the IP is throttled
+	if w := serve(basic("secret")); w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "too_many_requests") {
+		t.Fatalf("expected 429, got %d: %s", w.Code, w.Body)
+	}
+
+	form := url.Values{"username": {"op"}, "password": {"secret"}}
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if w := serve(r); w.Code != http.StatusTooManyRequests || w.Header().Get("Set-Cookie") != "" {
+		t.Errorf("expected the login form throttled too, got %d", w.Code)
+	}
+
+	// another client is not affected and logs in with a session cookie
+	r = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()+"&next=%2Fanalytics"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.RemoteAddr = "192.0.2.9:4000"
+	w := serve(r)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/analytics" || !strings.Contains(w.Header().Get("Set-Cookie"), sessionCookie+"=") {
+		t.Errorf("expected a login from another IP, got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestSafeNext(t *testing.T) {
+	cases := map[string]string{
+		"":                     "/",
+		"/analytics":           "/analytics",
+		"/analysis/5?x=1":      "/analysis/5?x=1",
+		"https://evil.example": "/",
+		"//evil.example":       "/",
+		"/\\evil.example":      "/",
+		"analytics":            "/",
+		"javascript:alert(1)":  "/",
+	}
+	for next, want := range cases {
+		if got := safeNext(next); got != want {
+			t.Errorf("safeNext(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
+
+func TestRequireOperator(t *testing.T) {
+	s, _ := newTestServer(t)
+	h := s.requireOperator(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	cases := []struct {
+		name   string
+		path   string
+		p      *principal
+		status int
+	}{
+		{"operator", "/api/v1/audit", &principal{Username: "op", Role: config.RoleOperator}, http.StatusNoContent},
+		{"viewer api", "/api/v1/audit", &principal{Username: "view", Role: config.RoleViewer}, http.StatusForbidden},
+		{"viewer page", "/audit", &principal{Username: "view", Role: config.RoleViewer}, http.StatusForbidden},
+		{"no principal", "/api/v1/audit", nil, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			if c.p != nil {
+				r = r.WithContext(context.WithValue(r.Context(), principalKey{}, c.p))
+			}
+			w := httptest.NewRecorder()
+			h(w, r)
+			if w.Code != c.status {
+				t.Errorf("status %d, want %d", w.Code, c.status)
+			}
+			if c.status == http.StatusForbidden && strings.HasPrefix(c.path, "/api/") && !strings.Contains(w.Body.String(), `"forbidden"`) {
+				t.Errorf("expected the API error envelope, got %s", w.Body)
+			}
+		})
+	}
+
+	// without web auth every caller passes
+	s.auth = nil
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("auth disabled: status %d", w.Code)
+	}
+}
@@ -0,0 +1,318 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/camuig/rus-trader/internal/executor"
+	"github.com/camuig/rus-trader/internal/scheduler"
+)
+
+// Operator controls. Every action has a form endpoint for the dashboard, which
+// redirects back with a flash message, and a JSON endpoint under /api/v1.
+// Actions require web auth and the operator role, are refused when a browser
+// sends them from another site, and are written to the audit log.
+
+const flashCookie = "rt_flash"
+
+type controlStatus struct {
+	Paused bool `json:"paused"`
+}
+
+type orderRequest struct {
+	Action     string  `json:"action"` // BUY or SELL
+	Ticker     string  `json:"ticker"`
+	StopLoss   float64 `json:"stop_loss"`   // optional
+	TakeProfit float64 `json:"take_profit"` // optional
+}
+
+type stopsRequest struct {
+	StopLoss   float64 `json:"stop_loss"`   // 0 keeps the current level
+	TakeProfit float64 `json:"take_profit"` // 0 keeps the current level
+}
+
+// actionError is a failed operator action with its HTTP status and API error code.
+type actionError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *actionError) Error() string { return e.message }
+
+// actionFunc performs an operator action. The message describes the action and
+// its result; it is shown to the operator and stored in the audit log.
+type actionFunc func(r *http.Request, user string) (result any, message string, err error)
+
+func (s *Server) registerControl(mux *http.ServeMux) {
+	routes := []struct {
+		pattern string
+		action  string
+		fn      actionFunc
+	}{
+		{"/control/pause", "pause", s.pauseAction},
+		{"/control/resume", "resume", s.resumeAction},
+		{"/control/cycle", "force_cycle", s.cycleAction},
+		{"/positions/{ticker}/close", "close_position", s.closeAction},
+		{"/trades/{id}/stops", "update_stops", s.stopsAction},
+		{"/orders", "manual_order", s.orderAction},
+	}
+	for _, rt := range routes {
+		h := s.operatorAction(rt.action, rt.fn)
+		mux.HandleFunc("POST "+rt.pattern, h)
+		mux.HandleFunc("POST /api/v1"+rt.pattern, h)
+	}
+	mux.HandleFunc("GET /api/v1/control", s.handleAPIControl)
+}
+
+// controlsEnabled reports whether operator actions are available at all.
+func (s *Server) controlsEnabled() bool {
+	return s.auth != nil && s.control != nil
+}
+
+func (s *Server) operatorAction(action string, fn actionFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := currentPrincipal(r)
+		var err error
+		switch {
+		case s.auth == nil:
+			err = &actionError{http.StatusForbidden, "forbidden", "operator controls require web.auth"}
+		case crossOrigin(r):
+			err = &actionError{http.StatusForbidden, "forbidden", "cross-origin request"}
+		case !p.isOperator():
+			err = &actionError{http.StatusForbidden, "forbidden", "operator role required"}
+		case s.control == nil:
+			err = &actionError{http.StatusServiceUnavailable, "unavailable", "scheduler is not running"}
+		}
+
+		var result any
+		var message string
+		if err == nil {
+			result, message, err = fn(r, p.Username)
+		}
+
+		status, code := http.StatusOK, ""
+		var ae *actionError
+		switch {
+		case errors.As(err, &ae):
+			status, code = ae.status, ae.code
+		case err != nil:
+			status, code = http.StatusBadGateway, "broker_error"
+		}
+		detail := message
+		if err != nil {
+			detail = strings.TrimPrefix(message+": "+err.Error(), ": ")
+		}
+		if p != nil {
+			s.auditAction(r, p, action, status, detail)
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			if err != nil {
+				s.writeError(w, status, code, detail)
+				return
+			}
+			s.writeData(w, result, nil)
+			return
+		}
+		if status == http.StatusForbidden {
+			http.Error(w, "Forbidden", status)
+			return
+		}
+		setFlash(w, detail, err != nil)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleAPIControl(w http.ResponseWriter, r *http.Request) {
+	if s.control == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "unavailable", "scheduler is not running")
+		return
+	}
+	s.writeData(w, controlStatus{Paused: s.control.Paused()}, nil)
+}
+
+func (s *Server) pauseAction(r *http.Request, user string) (any, string, error) {
+	s.control.Pause(user)
+	return controlStatus{Paused: true}, "торговля приостановлена", nil
+}
+
+func (s *Server) resumeAction(r *http.Request, user string) (any, string, error) {
+	s.control.Resume(user)
+	return controlStatus{Paused: false}, "торговля возобновлена", nil
+}
+
+func (s *Server) cycleAction(r *http.Request, user string) (any, string, error) {
+	message := "внеочередной цикл анализа"
+	if !s.control.TriggerCycle(user) {
+		return nil, message, &actionError{http.StatusConflict, "conflict", "цикл уже запрошен"}
+	}
+	return controlStatus{Paused: s.control.Paused()}, message + " запрошен", nil
+}
+
+func (s *Server) closeAction(r *http.Request, user string) (any, string, error) {
+	ticker := strings.ToUpper(r.PathValue("ticker"))
+	message := "закрытие " + ticker
+	outcome, err := s.control.ClosePosition(ticker, user)
+	if err != nil {
+		return nil, message, controlError(err)
+	}
+	return outcome, message + ": " + describeOutcome(outcome), outcomeError(outcome)
+}
+
+func (s *Server) stopsAction(r *http.Request, user string) (any, string, error) {
+	id, err := parseID(r)
+	if err != nil {
+		return nil, "", &actionError{http.StatusBadRequest, "bad_request", err.Error()}
+	}
+	var req stopsRequest
+	if err := decodeAction(r, &req, func(f url.Values) error {
+		var err error
+		if req.StopLoss, err = formFloat(f, "stop_loss"); err != nil {
+			return err
+		}
+		req.TakeProfit, err = formFloat(f, "take_profit")
+		return err
+	}); err != nil {
+		return nil, "", err
+	}
+
+	message := fmt.Sprintf("SL/TP сделки #%d", id)
+	trade, err := s.control.UpdateStops(id, req.StopLoss, req.TakeProfit, user)
+	if trade != nil {
+		message = fmt.Sprintf("SL/TP %s: %.2f / %.2f", trade.Ticker, trade.StopLossPrice, trade.TakeProfitPrice)
+		if portfolio, _ := s.live.get(); portfolio != nil {
+			s.updateLive(portfolio)
+		}
+	}
+	if err != nil {
+		return nil, message, controlError(err)
+	}
+	return trade, message, nil
+}
+
+func (s *Server) orderAction(r *http.Request, user string) (any, string, error) {
+	var req orderRequest
+	if err := decodeAction(r, &req, func(f url.Values) error {
+		req.Action, req.Ticker = f.Get("action"), f.Get("ticker")
+		var err error
+		if req.StopLoss, err = formFloat(f, "stop_loss"); err != nil {
+			return err
+		}
+		req.TakeProfit, err = formFloat(f, "take_profit")
+		return err
+	}); err != nil {
+		return nil, "", err
+	}
+
+	message := fmt.Sprintf("ручная заявка %s %s", strings.ToUpper(req.Action), strings.ToUpper(req.Ticker))
+	outcome, blocked, err := s.control.ManualOrder(req.Action, req.Ticker, req.StopLoss, req.TakeProfit, user)
+	if err != nil {
+		return nil, message, controlError(err)
+	}
+	if blocked != "" {
+		return nil, message, &actionError{http.StatusConflict, "blocked", "заблокировано guard: " + blocked}
+	}
+	return outcome, message + ": " + describeOutcome(outcome), outcomeError(outcome)
+}
+
+// decodeAction reads a JSON body for API requests and form fields otherwise.
+func decodeAction(r *http.Request, v any, fromForm func(url.Values) error) error {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+			return &actionError{http.StatusBadRequest, "bad_request", "invalid JSON body: " + err.Error()}
+		}
+		return nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return &actionError{http.StatusBadRequest, "bad_request", err.Error()}
+	}
+	if err := fromForm(r.PostForm); err != nil {
+		return &actionError{http.StatusBadRequest, "bad_request", err.Error()}
+	}
+	return nil
+}
+
+// formFloat parses an optional decimal form field; an empty value is 0.
+func formFloat(f url.Values, key string) (float64, error) {
+	v := strings.TrimSpace(strings.ReplaceAll(f.Get(key), ",", "."))
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number", key)
+	}
+	return n, nil
+}
+
+func controlError(err error) error {
+	switch {
+	case errors.Is(err, scheduler.ErrNoPosition):
+		return &actionError{http.StatusNotFound, "not_found", err.Error()}
+	case errors.Is(err, scheduler.ErrInvalidStop), errors.Is(err, scheduler.ErrBadOrder):
+		return &actionError{http.StatusBadRequest, "bad_request", err.Error()}
+	}
+	return err
+}
+
+// outcomeError turns an order that was not executed into an action error.
+func outcomeError(o executor.Outcome) error {
+	switch o.Status {
+	case executor.OutcomeFailed:
+		return &actionError{http.StatusBadGateway, "order_failed", o.Reason}
+	case executor.OutcomeSkipped:
+		return &actionError{http.StatusConflict, "skipped", o.Reason}
+	}
+	return nil
+}
+
+func describeOutcome(o executor.Outcome) string {
+	switch o.Status {
+	case executor.OutcomeExecuted:
+		s := fmt.Sprintf("исполнено %d лот. по %.2f", o.Lots, o.Price)
+		if o.Action == "SELL" {
+			s += fmt.Sprintf(", P&L %+.2f", o.PnL)
+		}
+		return s
+	case executor.OutcomeFailed:
+		return "ошибка"
+	case executor.OutcomeSkipped:
+		return "пропущено"
+	}
+	return o.Status
+}
+
+// setFlash stores a one-time message for the next dashboard render.
+func setFlash(w http.ResponseWriter, message string, isError bool) {
+	if isError {
+		message = "!" + message
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookie,
+		Value:    url.QueryEscape(message),
+		Path:     "/",
+		MaxAge:   60,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// takeFlash reads and clears the flash message.
+func takeFlash(w http.ResponseWriter, r *http.Request) (message string, isError bool) {
+	c, err := r.Cookie(flashCookie)
+	if err != nil {
+		return "", false
+	}
+	http.SetCookie(w, &http.Cookie{Name: flashCookie, Path: "/", MaxAge: -1})
+	message, err = url.QueryUnescape(c.Value)
+	if err != nil {
+		return "", false
+	}
+	return strings.CutPrefix(message, "!")
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+// newTestServer runs with web auth for the users "op" (operator) and "view"
+// (viewer), password "secret", and the bearer token "view-token" (viewer).
+// There is no scheduler, so an operator action that passes the checks ends
+// with 503.
+func newTestServer(t *testing.T) (*Server, *storage.Repository) {
+	t.Helper()
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "web-test.db"))
+	if err != nil {
+		t.Fatalf("create test database: %v", err)
+	}
+	repo := storage.NewRepository(db)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("view-token"))
+	cfg := &config.Config{Web: config.WebConfig{Auth: config.WebAuth{
+		Enabled:         true,
+		SessionTTLHours: 1,
+		Users: []config.WebUser{
+			{Username: "op", PasswordHash: string(hash), Role: config.RoleOperator},
+			{Username: "view", PasswordHash: string(hash), Role: config.RoleViewer},
+		},
+		Tokens: []config.APIToken{{Name: "ci", TokenSHA256: hex.EncodeToString(sum[:]), Role: config.RoleViewer}},
+	}}}
+	s, err := NewServer(nil, repo, nil, nil, nil, nil, cfg, logger.New("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, repo
+}
+
+func TestOperatorAction_RoleAndOrigin(t *testing.T) {
+	s, repo := newTestServer(t)
+
+	cases := []struct {
+		name     string
+		path     string
+		user     string // basic auth, "" for none
+		token    string
+		header   map[string]string
+		status   int
+		location string // prefix
+	}{
+		{name: "anonymous form", path: "/control/pause", status: http.StatusSeeOther, location: "/login"},
+		{name: "anonymous api", path: "/api/v1/control/pause", status: http.StatusUnauthorized},
+		{name: "viewer form", path: "/positions/SBER/close", user: "view", status: http.StatusForbidden},
+		{name: "viewer api", path: "/api/v1/orders", user: "view", status: http.StatusForbidden},
+		{name: "viewer token", path: "/api/v1/control/pause", token: "view-token", status: http.StatusForbidden},
+		{name: "operator cross-site form", path: "/orders", user: "op",
+			header: map[string]string{"Sec-Fetch-Site": "cross-site"}, status: http.StatusForbidden},
+		{name: "operator foreign origin", path: "/trades/1/stops", user: "op",
+			header: map[string]string{"Origin": "https://evil.example"}, status: http.StatusForbidden},
+		{name: "operator opaque origin", path: "/control/pause", user: "op",
+			header: map[string]string{"Origin": "null"}, status: http.StatusForbidden},
+		{name: "operator foreign origin api", path: "/api/v1/control/pause", user: "op",
+			header: map[string]string{"Origin": "https://evil.example"}, status: http.StatusForbidden},
+		{name: "operator same-origin form", path: "/control/pause", user: "op",
+			header: map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://example.com"}, status: http.StatusSeeOther, location: "/"},
+		{name: "operator api client", path: "/api/v1/control/pause", user: "op", status: http.StatusServiceUnavailable},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, c.path, nil)
+			if c.user != "" {
+				r.SetBasicAuth(c.user, "secret")
+			}
+			if c.token != "" {
+				r.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(w, r)
+			if w.Code != c.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, c.status, w.Body)
+			}
+			if loc := w.Header().Get("Location"); !strings.HasPrefix(loc, c.location) {
+				t.Errorf("redirect to %q, want %q", loc, c.location)
+			}
+		})
+	}
+
+	logs, _, err := repo.ListAuditLogs("op", 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := 0
+	for _, l := range logs {
+		if l.Status == http.StatusForbidden && strings.Contains(l.Detail, "cross-origin") {
+			refused++
+		}
+	}
+	if refused != 4 {
+		t.Errorf("expected 4 refused cross-origin actions in the audit log, got %d", refused)
+	}
+}
@@ -1,24 +1,47 @@
 package web
 
 import (
-	"html/template"
+	"encoding/json"
 	"net/http"
 	"time"
 
+	"github.com/camuig/rus-trader/internal/ai"
+	"github.com/camuig/rus-trader/internal/calibration"
+	"github.com/camuig/rus-trader/internal/moex"
 	"github.com/camuig/rus-trader/internal/storage"
 )
 
 type OpenPosition struct {
-	Ticker          string
-	Price           float64
-	Quantity        int64
-	StopLossPrice   float64
-	TakeProfitPrice float64
-	CreatedAt       time.Time
-	CurrentPrice    float64
-	PnL             float64
-	PnLPercent      float64
-	Reasoning       string
+	TradeID         uint      `json:"trade_id"`
+	Ticker          string    `json:"ticker"`
+	Price           float64   `json:"price"`
+	Quantity        int64     `json:"quantity"`
+	StopLossPrice   float64   `json:"stop_loss_price"`
+	TakeProfitPrice float64   `json:"take_profit_price"`
+	CreatedAt       time.Time `json:"created_at"`
+	CurrentPrice    float64   `json:"current_price"`
+	PnL             float64   `json:"pnl"`
+	PnLPercent      float64   `json:"pnl_percent"`
+	Reasoning       string    `json:"reasoning"`
+}
+
+// DecisionView is an AI decision with the parts of the model's reasoning that mention its ticker.
+type DecisionView struct {
+	ai.AIDecision
+	ReasoningExcerpts []string
+}
+
+type AnalysisView struct {
+	ID            uint
+	CreatedAt     time.Time
+	Model         string
+	PromptVersion string
+	ReasoningMs   int64
+	AnswerMs      int64
+	ToolCalls     int
+	ToolMs        int64
+	Reasoning     string
+	Decisions     []DecisionView
 }
 
 type DashboardData struct {
@@ -30,6 +53,28 @@ type DashboardData struct {
 	RecentTrades   []storage.Trade
 	PositionsCount int
 	Mode           string
+	AICost         storage.AICostStats // today, MSK
+	AICostWeek     storage.AICostStats // last 7 days
+	LastAnalysis   *AnalysisView
+	PromptVariants []storage.PromptVariantStats // last 30 days, shown when A/B split is used
+	Calibration    *CalibrationView
+	NewsSources    []moex.FeedStatus // nil without the scheduler
+	User           string            // empty when web auth is disabled
+	Role           string
+	CanControl     bool // operator controls are shown
+	Paused         bool
+	Flash          string // result of the last operator action
+	FlashError     bool
+}
+
+// CalibrationView is the confidence reliability table.
+type CalibrationView struct {
+	Buckets      []calibration.Bucket
+	Trades       int
+	MinTrades    int
+	LookbackDays int
+	Enabled      bool // remapping configured
+	Applied      bool // enough trades, the curve is in use
 }
 
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
@@ -39,10 +84,24 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := DashboardData{}
-
-	// Get portfolio snapshot
-	snapshot, err := s.repo.GetLatestSnapshot()
-	if err == nil && snapshot != nil {
+	if p := currentPrincipal(r); p != nil {
+		data.User = p.Username
+		data.Role = p.Role
+		data.CanControl = s.controlsEnabled() && p.isOperator()
+	}
+	if s.control != nil {
+		data.Paused = s.control.Paused()
+		data.NewsSources = s.control.NewsSources()
+	}
+	data.Flash, data.FlashError = takeFlash(w, r)
+
+	// Portfolio totals from the live cache, the last snapshot until the first refresh
+	portfolio, _ := s.live.get()
+	if portfolio != nil {
+		data.TotalRub = portfolio.TotalRub
+		data.AvailableRub = portfolio.AvailableRub
+		data.PositionsCount = len(portfolio.Positions)
+	} else if snapshot, err := s.repo.GetLatestSnapshot(); err == nil && snapshot != nil {
 		data.TotalRub = snapshot.TotalRub
 		data.AvailableRub = snapshot.AvailableRub
 		data.PositionsCount = snapshot.PositionsCount
@@ -56,9 +115,34 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		data.TotalPnL = totalPnL
 	}
 
-	// Get open positions and enrich with live prices
+	// AI usage cost
+	if cost, err := s.repo.GetAICostSince(storage.StartOfTodayMSK()); err == nil {
+		data.AICost = cost
+	}
+	if cost, err := s.repo.GetAICostSince(time.Now().Add(-7 * 24 * time.Hour)); err == nil {
+		data.AICostWeek = cost
+	}
+
+	// Prompt variant comparison
+	if stats, err := s.repo.GetPromptVariantStats(time.Now().Add(-30 * 24 * time.Hour)); err == nil {
+		if len(stats) > 1 || len(s.config.DeepSeek.Prompts.Variants) > 1 {
+			data.PromptVariants = stats
+		}
+	}
+
+	// Confidence calibration
+	if trades, err := s.repo.GetClosedBuyTradesSince(s.config.CalibrationSince()); err == nil && len(trades) > 0 {
+		data.Calibration = s.buildCalibrationView(trades)
+	}
+
+	// Open positions with cached live prices
 	if positions, err := s.repo.GetOpenTrades(); err == nil {
-		data.OpenPositions = s.enrichPositions(positions)
+		data.OpenPositions = buildOpenPositions(positions, portfolio)
+	}
+
+	// Latest AI decisions with reasoning
+	if log, err := s.repo.GetLatestAnalysisLog(); err == nil {
+		data.LastAnalysis = buildAnalysisView(log)
 	}
 
 	// Get recent trades
@@ -73,60 +157,45 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		data.Mode = "LIVE"
 	}
 
-	tmpl, err := template.ParseFiles("templates/dashboard.html")
-	if err != nil {
-		s.logger.Error("parse template", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
+	s.renderPage(w, "dashboard.html", data)
+}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := tmpl.Execute(w, data); err != nil {
-		s.logger.Error("execute template", "error", err)
+func (s *Server) buildCalibrationView(trades []storage.Trade) *CalibrationView {
+	cc := s.config.Trading.Calibration
+	outcomes := calibration.OutcomesFromTrades(trades)
+	buckets := calibration.BuildBuckets(outcomes, cc.BucketSize)
+	return &CalibrationView{
+		Buckets:      buckets,
+		Trades:       len(outcomes),
+		MinTrades:    cc.MinTrades,
+		LookbackDays: cc.LookbackDays,
+		Enabled:      cc.Enabled,
+		Applied:      cc.Enabled && calibration.Fit(buckets, cc.MinTrades) != nil,
 	}
 }
 
-func (s *Server) enrichPositions(trades []storage.Trade) []OpenPosition {
-	// Build a map of ticker -> live position data from broker
-	type liveData struct {
-		CurrentPrice float64
-		PnL          float64
+func buildAnalysisView(log *storage.AnalysisLog) *AnalysisView {
+	view := &AnalysisView{
+		ID:            log.ID,
+		CreatedAt:     log.CreatedAt,
+		Model:         log.Model,
+		PromptVersion: log.PromptVersion,
+		ReasoningMs:   log.ReasoningMs,
+		AnswerMs:      log.AnswerMs,
+		ToolCalls:     log.ToolCallsCount,
+		ToolMs:        log.ToolMs,
+		Reasoning:     log.Reasoning,
 	}
-	liveMap := make(map[string]liveData)
 
-	portfolio, err := s.broker.GetPortfolio()
-	if err != nil {
-		s.logger.Error("get portfolio for dashboard", "error", err)
-	} else {
-		for _, pos := range portfolio.Positions {
-			if pos.Ticker != "" {
-				liveMap[pos.Ticker] = liveData{
-					CurrentPrice: pos.CurrentPrice,
-					PnL:          pos.PnL,
-				}
-			}
-		}
+	var decisions []ai.AIDecision
+	if log.DecisionsJSON != "" {
+		_ = json.Unmarshal([]byte(log.DecisionsJSON), &decisions)
 	}
-
-	result := make([]OpenPosition, 0, len(trades))
-	for _, t := range trades {
-		op := OpenPosition{
-			Ticker:          t.Ticker,
-			Price:           t.Price,
-			Quantity:        t.Quantity,
-			StopLossPrice:   t.StopLossPrice,
-			TakeProfitPrice: t.TakeProfitPrice,
-			CreatedAt:       t.CreatedAt,
-			Reasoning:       t.Reasoning,
-		}
-		if live, ok := liveMap[t.Ticker]; ok {
-			op.CurrentPrice = live.CurrentPrice
-			op.PnL = live.CurrentPrice*float64(t.Quantity) - t.Price*float64(t.Quantity)
-			if t.Price > 0 {
-				op.PnLPercent = (live.CurrentPrice - t.Price) / t.Price * 100
-			}
-		}
-		result = append(result, op)
+	for _, d := range decisions {
+		view.Decisions = append(view.Decisions, DecisionView{
+			AIDecision:        d,
+			ReasoningExcerpts: ai.ReasoningForTicker(log.Reasoning, d.Ticker),
+		})
 	}
-	return result
+	return view
 }
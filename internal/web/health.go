@@ -0,0 +1,272 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Health endpoints. /healthz answers while the process serves HTTP; /readyz
+// checks dependencies and returns 503 when a critical one fails. Both are
+// public so that Docker and external monitors can probe them without credentials;
+// with auth enabled, /readyz shows the details of the checks to signed-in
+// callers only, since errors may quote channel addresses or tokens.
+
+const (
+	checkTimeout  = 5 * time.Second
+	probeCacheTTL = time.Minute // network probes of AI and notification channels run at most this often
+)
+
+var errCheckDisabled = errors.New("disabled")
+
+type checkResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // ok, fail or disabled
+	Critical   bool   `json:"critical"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type readiness struct {
+	Status string        `json:"status"` // ok, degraded (a non-critical check failed) or fail
+	Time   time.Time     `json:"time"`
+	Checks []checkResult `json:"checks"`
+}
+
+type healthCheck struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context) (detail string, err error)
+}
+
+// cachedProbe remembers the result of a remote probe for probeCacheTTL.
+type cachedProbe struct {
+	mu     sync.Mutex
+	at     time.Time
+	detail string
+	err    error
+}
+
+func (p *cachedProbe) get(ctx context.Context, probe func(ctx context.Context) (string, error)) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.at) < probeCacheTTL {
+		return p.detail, p.err
+	}
+	p.detail, p.err = probe(ctx)
+	p.at = time.Now()
+	return p.detail, p.err
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, map[string]any{
+		"status":   "ok",
+		"uptime_s": int64(time.Since(s.started).Seconds()),
+	})
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := s.healthChecks()
+	results := make([]checkResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c healthCheck) {
+			defer wg.Done()
+			results[i] = runCheck(r.Context(), c)
+		}(i, c)
+	}
+	wg.Wait()
+	if s.auth != nil {
+		if p, _ := s.auth.authenticate(r); p == nil {
+			for i := range results {
+				results[i].Detail = ""
+			}
+		}
+	}
+
+	report := readiness{Status: "ok", Time: time.Now(), Checks: results}
+	status := http.StatusOK
+	for _, res := range results {
+		if res.Status != "fail" {
+			continue
+		}
+		if res.Critical {
+			report.Status, status = "fail", http.StatusServiceUnavailable
+			break
+		}
+		report.Status = "degraded"
+	}
+	writeHealth(w, status, report)
+}
+
+// runCheck runs one check with a timeout; checks that ignore the context are abandoned.
+func runCheck(ctx context.Context, c healthCheck) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		detail, err := c.run(ctx)
+		done <- outcome{detail, err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-done:
+	case <-ctx.Done():
+		o.err = fmt.Errorf("timed out after %s", checkTimeout)
+	}
+
+	res := checkResult{Name: c.name, Status: "ok", Critical: c.critical, Detail: o.detail,
+		DurationMs: time.Since(start).Milliseconds()}
+	switch {
+	case errors.Is(o.err, errCheckDisabled):
+		res.Status = "disabled"
+	case o.err != nil:
+		res.Status = "fail"
+		res.Detail = o.err.Error()
+	}
+	return res
+}
+
+func (s *Server) healthChecks() []healthCheck {
+	return []healthCheck{
+		{"broker", true, s.checkBroker},
+		{"database", true, s.checkDatabase},
+		{"cycle", true, s.checkCycle},
+		{"ai", false, s.checkAI},
+		{"notify", false, s.checkNotify},
+	}
+}
+
+// checkBroker relies on the live portfolio poll instead of calling the broker on every probe.
+func (s *Server) checkBroker(ctx context.Context) (string, error) {
+	if s.broker == nil {
+		return "", errCheckDisabled
+	}
+	updatedAt, err := s.live.status()
+	if err != nil {
+		return "", fmt.Errorf("portfolio refresh failed: %w", err)
+	}
+	if updatedAt.IsZero() {
+		return "", errors.New("portfolio not received yet")
+	}
+	age := time.Since(updatedAt)
+	limit := 3 * time.Duration(s.config.Web.LiveRefreshSeconds) * time.Second
+	if age > limit {
+		return "", fmt.Errorf("portfolio not refreshed for %s", age.Round(time.Second))
+	}
+	return fmt.Sprintf("portfolio refreshed %s ago", age.Round(time.Second)), nil
+}
+
+func (s *Server) checkDatabase(ctx context.Context) (string, error) {
+	if err := s.repo.CheckWritable(); err != nil {
+		return "", err
+	}
+	return "writable", nil
+}
+
+func (s *Server) checkCycle(ctx context.Context) (string, error) {
+	if s.control == nil {
+		return "", errCheckDisabled
+	}
+	h := s.control.CycleHealth()
+	last := h.LastOK
+	if last.IsZero() {
+		last = h.Started
+	}
+	if last.IsZero() {
+		return "", errors.New("scheduler not started")
+	}
+
+	age := time.Since(last).Round(time.Second)
+	deadline := s.control.CycleDeadline()
+	if age > deadline {
+		err := fmt.Errorf("no completed cycle for %s (limit %s)", age, deadline)
+		if h.LastError != "" {
+			err = fmt.Errorf("%w, last error: %s", err, h.LastError)
+		}
+		return "", err
+	}
+
+	detail := fmt.Sprintf("last cycle %s ago", age)
+	if h.LastOK.IsZero() {
+		detail = fmt.Sprintf("waiting for the first cycle, started %s ago", age)
+	}
+	if h.Paused {
+		detail += ", paused"
+	}
+	if h.LastFailure.After(h.LastOK) {
+		detail += ", last attempt failed: " + h.LastError
+	}
+	return detail, nil
+}
+
+func (s *Server) checkAI(ctx context.Context) (string, error) {
+	if s.ai == nil {
+		return "", errCheckDisabled
+	}
+	return s.aiProbe.get(ctx, func(ctx context.Context) (string, error) {
+		if err := s.ai.Ping(ctx); err != nil {
+			return "", err
+		}
+		return "API reachable", nil
+	})
+}
+
+// checkNotify fails when a channel's last delivery failed or its
+// configuration check does not pass.
+func (s *Server) checkNotify(ctx context.Context) (string, error) {
+	if s.notifier == nil {
+		return "", errCheckDisabled
+	}
+	channels := s.notifier.Status()
+	if len(channels) == 0 {
+		return "", errCheckDisabled
+	}
+	var problems []string
+	for _, ch := range channels {
+		if ch.LastError != "" {
+			problems = append(problems, fmt.Sprintf("%s: last delivery failed: %s", ch.Name, ch.LastError))
+		}
+	}
+	_, err := s.notifyProbe.get(ctx, func(ctx context.Context) (string, error) {
+		var failed []string
+		for name, err := range s.notifier.Check(ctx) {
+			if err != nil {
+				failed = append(failed, name+": "+err.Error())
+			}
+		}
+		if len(failed) > 0 {
+			sort.Strings(failed)
+			return "", errors.New(strings.Join(failed, "; "))
+		}
+		return "", nil
+	})
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+	return fmt.Sprintf("%d channels", len(channels)), nil
+}
+
+func writeHealth(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
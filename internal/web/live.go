@@ -0,0 +1,263 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/camuig/rus-trader/internal/broker"
+	"github.com/camuig/rus-trader/internal/events"
+	"github.com/camuig/rus-trader/internal/metrics"
+	"github.com/camuig/rus-trader/internal/storage"
+)
+
+const (
+	sseHeartbeat  = 15 * time.Second
+	sseBacklogMax = 50 // activity events replayed to a fresh connection
+)
+
+// livePortfolio is the cached dashboard view of the account, pushed to clients
+// as the "positions" event.
+type livePortfolio struct {
+	UpdatedAt    time.Time      `json:"updated_at"`
+	TotalRub     float64        `json:"total_rub"`
+	AvailableRub float64        `json:"available_rub"`
+	DailyPnL     float64        `json:"daily_pnl"`
+	TotalPnL     float64        `json:"total_pnl"`
+	Positions    []OpenPosition `json:"positions"`
+}
+
+// liveCache holds the last broker portfolio so page views and API calls do not
+// hit the broker. It is refreshed by a single poller and by scheduler events.
+type liveCache struct {
+	mu        sync.RWMutex
+	portfolio *broker.PortfolioInfo
+	view      *livePortfolio
+	err       error // last failed refresh, cleared by a successful one
+}
+
+func (c *liveCache) get() (*broker.PortfolioInfo, *livePortfolio) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.portfolio, c.view
+}
+
+func (c *liveCache) set(p *broker.PortfolioInfo, v *livePortfolio) {
+	c.mu.Lock()
+	c.portfolio, c.view, c.err = p, v, nil
+	c.mu.Unlock()
+}
+
+func (c *liveCache) fail(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+}
+
+// status returns the time of the last successful refresh and the error of a later failed one.
+func (c *liveCache) status() (updatedAt time.Time, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.view != nil {
+		updatedAt = c.view.UpdatedAt
+	}
+	return updatedAt, c.err
+}
+
+// runLive keeps the portfolio cache fresh: polls the broker every
+// web.live_refresh_seconds, takes portfolios fetched by the scheduler, and
+// refreshes right after fills.
+func (s *Server) runLive(ctx context.Context) {
+	ch, cancel := s.events.Subscribe()
+	defer cancel()
+
+	ticker := time.NewTicker(time.Duration(s.config.Web.LiveRefreshSeconds) * time.Second)
+	defer ticker.Stop()
+
+	s.refreshPortfolio()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshPortfolio()
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			switch ev.Type {
+			case events.Portfolio:
+				if p, ok := ev.Data.(*broker.PortfolioInfo); ok {
+					s.updateLive(p)
+				}
+			case events.Fill:
+				s.refreshPortfolio()
+			}
+		}
+	}
+}
+
+func (s *Server) refreshPortfolio() {
+	portfolio, err := s.broker.GetPortfolio()
+	if err != nil {
+		s.logger.Error("refresh portfolio for dashboard", "error", err)
+		s.live.fail(err)
+		return
+	}
+	s.updateLive(portfolio)
+}
+
+// updateLive rebuilds the dashboard view from a broker portfolio and open trades
+// in the DB, caches it and publishes it to SSE clients.
+func (s *Server) updateLive(portfolio *broker.PortfolioInfo) {
+	view := &livePortfolio{
+		UpdatedAt:    time.Now(),
+		TotalRub:     portfolio.TotalRub,
+		AvailableRub: portfolio.AvailableRub,
+	}
+	if pnl, err := s.repo.GetTodayPnL(); err == nil {
+		view.DailyPnL = pnl
+	}
+	if pnl, err := s.repo.GetTotalPnL(); err == nil {
+		view.TotalPnL = pnl
+	}
+	trades, err := s.repo.GetOpenTrades()
+	if err != nil {
+		s.logger.Error("get open trades for dashboard", "error", err)
+	}
+	view.Positions = buildOpenPositions(trades, portfolio)
+
+	metrics.OpenPositions.Set(float64(len(view.Positions)))
+	metrics.Equity.Set(view.TotalRub)
+	metrics.DailyPnL.Set(view.DailyPnL)
+
+	s.live.set(portfolio, view)
+	s.events.Publish(events.Positions, view)
+}
+
+// handleAPIEvents streams bus events as Server-Sent Events. A new connection
+// gets the current positions view and recent activity; a reconnect with
+// Last-Event-ID resumes from the retained history.
+func (s *Server) handleAPIEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Error("disable write deadline for SSE", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "internal", "streaming not supported")
+		return
+	}
+
+	ch, cancel := s.events.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// IDs restart with the process, so an ID from the future means a fresh start.
+	var lastID uint64
+	id, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err == nil && id <= s.events.LastID() {
+		lastID = id
+		for _, ev := range s.events.Since(lastID) {
+			if ev.Type != events.Portfolio {
+				writeSSE(w, ev)
+			}
+			lastID = ev.ID
+		}
+	} else {
+		if _, view := s.live.get(); view != nil {
+			writeSSE(w, events.Event{Type: events.Positions, Time: view.UpdatedAt, Data: view})
+		}
+		backlog := s.events.Since(0)
+		activity := make([]events.Event, 0, len(backlog))
+		for _, ev := range backlog {
+			if ev.Type != events.Portfolio && ev.Type != events.Positions {
+				activity = append(activity, ev)
+			}
+			lastID = ev.ID
+		}
+		if len(activity) > sseBacklogMax {
+			activity = activity[len(activity)-sseBacklogMax:]
+		}
+		for _, ev := range activity {
+			writeSSE(w, ev)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.ID <= lastID || ev.Type == events.Portfolio {
+				continue
+			}
+			lastID = ev.ID
+			writeSSE(w, ev)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, ev events.Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	if ev.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", ev.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+}
+
+// buildOpenPositions merges open trades from the DB with live prices from the portfolio.
+func buildOpenPositions(trades []storage.Trade, portfolio *broker.PortfolioInfo) []OpenPosition {
+	prices := make(map[string]float64)
+	if portfolio != nil {
+		for _, pos := range portfolio.Positions {
+			if pos.Ticker != "" {
+				prices[pos.Ticker] = pos.CurrentPrice
+			}
+		}
+	}
+
+	result := make([]OpenPosition, 0, len(trades))
+	for _, t := range trades {
+		op := OpenPosition{
+			TradeID:         t.ID,
+			Ticker:          t.Ticker,
+			Price:           t.Price,
+			Quantity:        t.Quantity,
+			StopLossPrice:   t.StopLossPrice,
+			TakeProfitPrice: t.TakeProfitPrice,
+			CreatedAt:       t.CreatedAt,
+			Reasoning:       t.Reasoning,
+		}
+		if price, ok := prices[t.Ticker]; ok {
+			op.CurrentPrice = price
+			op.PnL = price*float64(t.Quantity) - t.Price*float64(t.Quantity)
+			if t.Price > 0 {
+				op.PnLPercent = (price - t.Price) / t.Price * 100
+			}
+		}
+		result = append(result, op)
+	}
+	return result
+}
@@ -0,0 +1,18 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/camuig/rus-trader/internal/metrics"
+)
+
+// metricsPath serves Prometheus metrics. With web auth enabled it needs a
+// token or basic auth like the API; scrapes are not written to the audit log.
+const metricsPath = "/metrics"
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.Default.WriteText(w); err != nil {
+		s.logger.Error("write metrics", "error", err)
+	}
+}
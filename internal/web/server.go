@@ -6,9 +6,13 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/camuig/rus-trader/internal/ai"
 	"github.com/camuig/rus-trader/internal/broker"
 	"github.com/camuig/rus-trader/internal/config"
+	"github.com/camuig/rus-trader/internal/events"
 	"github.com/camuig/rus-trader/internal/logger"
+	"github.com/camuig/rus-trader/internal/notify"
+	"github.com/camuig/rus-trader/internal/scheduler"
 	"github.com/camuig/rus-trader/internal/storage"
 )
 
@@ -16,40 +20,105 @@ type Server struct {
 	httpServer *http.Server
 	broker     *broker.BrokerClient
 	repo       *storage.Repository
+	events     *events.Bus
+	control    *scheduler.Scheduler // operator controls, nil disables them
+	ai         *ai.DeepSeekClient
+	notifier   *notify.Dispatcher
 	config     *config.Config
 	logger     *logger.Logger
+	auth       *authenticator // nil when web auth is disabled
+	assets     *assets
+	live       liveCache
+	stopLive   context.CancelFunc
+	started    time.Time
+
+	aiProbe     cachedProbe
+	notifyProbe cachedProbe
 }
 
-func NewServer(bc *broker.BrokerClient, repo *storage.Repository, cfg *config.Config, log *logger.Logger) *Server {
+func NewServer(
+	bc *broker.BrokerClient,
+	repo *storage.Repository,
+	bus *events.Bus,
+	sched *scheduler.Scheduler,
+	aiClient *ai.DeepSeekClient,
+	notifier *notify.Dispatcher,
+	cfg *config.Config,
+	log *logger.Logger,
+) (*Server, error) {
+	pages, err := newAssets(cfg.Web.DevAssetsDir)
+	if err != nil {
+		return nil, fmt.Errorf("load templates: %w", err)
+	}
+	if cfg.Web.DevAssetsDir != "" {
+		log.Warn("dev mode: templates and static files are reloaded from disk", "dir", cfg.Web.DevAssetsDir)
+	}
+
 	s := &Server{
-		broker: bc,
-		repo:   repo,
-		config: cfg,
-		logger: log,
+		broker:   bc,
+		repo:     repo,
+		events:   bus,
+		control:  sched,
+		ai:       aiClient,
+		notifier: notifier,
+		config:   cfg,
+		logger:   log,
+		started:  time.Now(),
+		assets:   pages,
+	}
+	if cfg.Web.Auth.Enabled {
+		s.auth = newAuthenticator(cfg.Web.Auth)
+	} else {
+		log.Warn("web auth is disabled, dashboard and API are open to anyone who can reach the port")
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleDashboard)
-	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+	mux.HandleFunc("GET /analytics", s.handleAnalytics)
+	mux.HandleFunc("GET /analysis", s.handleAnalysisList)
+	mux.HandleFunc("GET /analysis/{id}", s.handleAnalysisDetail)
+	mux.HandleFunc("GET /login", s.handleLogin)
+	mux.HandleFunc("POST /login", s.handleLogin)
+	mux.HandleFunc("POST /logout", s.handleLogout)
+	mux.HandleFunc("GET "+metricsPath, s.handleMetrics)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	s.registerAPI(mux)
+	s.registerControl(mux)
+	mux.Handle("/static/", http.StripPrefix("/static/", s.assets.static()))
 
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Web.Port),
-		Handler:      mux,
+		Handler:      s.withAuth(mux),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
 
-	return s
+	return s, nil
 }
 
 func (s *Server) Start() error {
-	s.logger.Info("web server starting", "port", s.config.Web.Port)
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	tls := s.config.Web.TLS
+	s.logger.Info("web server starting", "port", s.config.Web.Port, "tls", tls.Enabled(), "auth", s.auth != nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopLive = cancel
+	go s.runLive(ctx)
+
+	var err error
+	if tls.Enabled() {
+		err = s.httpServer.ListenAndServeTLS(tls.CertFile, tls.KeyFile)
+	} else {
+		err = s.httpServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("web server: %w", err)
 	}
 	return nil
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.stopLive != nil {
+		s.stopLive()
+	}
 	return s.httpServer.Shutdown(ctx)
 }
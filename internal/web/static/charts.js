@@ -0,0 +1,141 @@
+// Minimal SVG charts for the analytics page: an equity curve with drawdown
+// shading and a bar chart of period returns. No external dependencies.
+(function () {
+    'use strict';
+
+    var NS = 'http://www.w3.org/2000/svg';
+    var PAD = { top: 12, right: 12, bottom: 24, left: 64 };
+    var COLORS = { line: '#58a6ff', peak: '#30363d', drawdown: 'rgba(248, 81, 73, 0.25)', up: '#56d364', down: '#f85149', grid: '#21262d' };
+
+    function el(name, attrs, parent) {
+        var node = document.createElementNS(NS, name);
+        Object.keys(attrs).forEach(function (k) { node.setAttribute(k, attrs[k]); });
+        if (parent) parent.appendChild(node);
+        return node;
+    }
+
+    function svg(container, height) {
+        container.textContent = '';
+        var width = container.clientWidth || 800;
+        var root = el('svg', { viewBox: '0 0 ' + width + ' ' + height, height: height }, container);
+        return { root: root, width: width, height: height };
+    }
+
+    function scale(d0, d1, r0, r1) {
+        var span = d1 - d0 || 1;
+        return function (v) { return r0 + (v - d0) / span * (r1 - r0); };
+    }
+
+    function pad(n) { return n < 10 ? '0' + n : '' + n; }
+
+    function shortDate(d) { return pad(d.getDate()) + '.' + pad(d.getMonth() + 1); }
+
+    function fmt(v) { return v.toLocaleString('ru-RU', { maximumFractionDigits: 0 }); }
+
+    function yAxis(c, y, lo, hi, format) {
+        for (var i = 0; i <= 4; i++) {
+            var v = lo + (hi - lo) * i / 4;
+            var py = y(v);
+            el('line', { x1: PAD.left, x2: c.width - PAD.right, y1: py, y2: py, stroke: COLORS.grid }, c.root);
+            el('text', { x: PAD.left - 6, y: py + 4, 'text-anchor': 'end', 'class': 'axis' }, c.root).textContent = format(v);
+        }
+    }
+
+    function label(c, x, text, anchor) {
+        el('text', { x: x, y: c.height - 6, 'text-anchor': anchor, 'class': 'axis' }, c.root).textContent = text;
+    }
+
+    function readout(container) {
+        var div = document.createElement('div');
+        div.className = 'readout';
+        container.appendChild(div);
+        return div;
+    }
+
+    // equity draws the equity line, the running peak and shades the gap between them.
+    function equity(container, points) {
+        if (!points.length) return;
+        var c = svg(container, 260);
+        var times = points.map(function (p) { return new Date(p.time).getTime(); });
+        var lo = Math.min.apply(null, points.map(function (p) { return p.equity; }));
+        var hi = Math.max.apply(null, points.map(function (p) { return p.peak; }));
+        var margin = (hi - lo) * 0.05 || hi * 0.01 || 1;
+        lo -= margin;
+        hi += margin;
+
+        var x = scale(times[0], times[times.length - 1], PAD.left, c.width - PAD.right);
+        var y = scale(lo, hi, c.height - PAD.bottom, PAD.top);
+        yAxis(c, y, lo, hi, fmt);
+
+        var line = [], peak = [];
+        points.forEach(function (p, i) {
+            line.push(x(times[i]) + ',' + y(p.equity));
+            peak.push(x(times[i]) + ',' + y(p.peak));
+        });
+        var shade = peak.concat(line.slice().reverse());
+        el('polygon', { points: shade.join(' '), fill: COLORS.drawdown }, c.root);
+        el('polyline', { points: peak.join(' '), fill: 'none', stroke: COLORS.peak, 'stroke-dasharray': '4 3' }, c.root);
+        el('polyline', { points: line.join(' '), fill: 'none', stroke: COLORS.line, 'stroke-width': 2 }, c.root);
+
+        label(c, PAD.left, shortDate(new Date(times[0])), 'start');
+        label(c, c.width - PAD.right, shortDate(new Date(times[times.length - 1])), 'end');
+
+        var info = readout(container);
+        var cursor = el('line', { y1: PAD.top, y2: c.height - PAD.bottom, stroke: COLORS.peak, visibility: 'hidden' }, c.root);
+        c.root.addEventListener('mousemove', function (e) {
+            var box = c.root.getBoundingClientRect();
+            var px = (e.clientX - box.left) / box.width * c.width;
+            var i = nearest(times, x, px);
+            var p = points[i], d = new Date(times[i]);
+            cursor.setAttribute('x1', x(times[i]));
+            cursor.setAttribute('x2', x(times[i]));
+            cursor.setAttribute('visibility', 'visible');
+            info.textContent = shortDate(d) + ' ' + pad(d.getHours()) + ':' + pad(d.getMinutes()) +
+                ' · ' + fmt(p.equity) + ' ₽' + (p.drawdown_pct < 0 ? ' · ' + p.drawdown_pct.toFixed(2) + '%' : '');
+        });
+        c.root.addEventListener('mouseleave', function () {
+            cursor.setAttribute('visibility', 'hidden');
+            info.textContent = '';
+        });
+    }
+
+    function nearest(times, x, px) {
+        var best = 0, dist = Infinity;
+        for (var i = 0; i < times.length; i++) {
+            var d = Math.abs(x(times[i]) - px);
+            if (d < dist) { dist = d; best = i; }
+        }
+        return best;
+    }
+
+    // returns draws period returns in percent as green/red bars around zero.
+    function returns(container, periods) {
+        if (!periods.length) return;
+        var c = svg(container, 200);
+        var values = periods.map(function (p) { return p.return_pct; });
+        var lo = Math.min(0, Math.min.apply(null, values));
+        var hi = Math.max(0, Math.max.apply(null, values));
+        if (lo === hi) hi = 1;
+
+        var y = scale(lo, hi, c.height - PAD.bottom, PAD.top);
+        yAxis(c, y, lo, hi, function (v) { return v.toFixed(1) + '%'; });
+
+        var slot = (c.width - PAD.left - PAD.right) / periods.length;
+        var width = Math.max(1, slot * 0.7);
+        periods.forEach(function (p, i) {
+            var x0 = PAD.left + i * slot + (slot - width) / 2;
+            var top = y(Math.max(0, p.return_pct)), bottom = y(Math.min(0, p.return_pct));
+            var bar = el('rect', {
+                x: x0, y: top, width: width, height: Math.max(1, bottom - top),
+                fill: p.return_pct >= 0 ? COLORS.up : COLORS.down
+            }, c.root);
+            el('title', {}, bar).textContent = p.period + ': ' + p.return_pct.toFixed(2) + '% (' + fmt(p.pnl) + ' ₽)';
+        });
+        el('line', { x1: PAD.left, x2: c.width - PAD.right, y1: y(0), y2: y(0), stroke: '#8b949e' }, c.root);
+
+        label(c, PAD.left, periods[0].period, 'start');
+        if (periods.length > 1) label(c, c.width - PAD.right, periods[periods.length - 1].period, 'end');
+    }
+
+    window.Charts = { equity: equity, returns: returns };
+})();
@@ -0,0 +1,9 @@
+// Operator controls: ask for confirmation before submitting forms with data-confirm.
+(function () {
+    'use strict';
+
+    document.addEventListener('submit', function (e) {
+        var text = e.target.dataset.confirm;
+        if (text && !window.confirm(text)) e.preventDefault();
+    });
+})();
@@ -0,0 +1,192 @@
+// Live dashboard: positions, P&L and the activity feed from /api/v1/events.
+(function () {
+    'use strict';
+
+    var MAX_FEED = 100;
+    var feed = document.getElementById('activity');
+    var status = document.getElementById('live-status');
+
+    function money(v, signed) {
+        var s = v.toFixed(2);
+        if (signed && v > 0) s = '+' + s;
+        return s + ' ₽';
+    }
+
+    function signClass(v) {
+        return v > 0 ? 'positive' : v < 0 ? 'negative' : '';
+    }
+
+    function setStat(id, text, value) {
+        var el = document.getElementById(id);
+        if (!el) return;
+        el.textContent = text;
+        if (value !== undefined) {
+            el.classList.remove('positive', 'negative');
+            var cls = signClass(value);
+            if (cls) el.classList.add(cls);
+        }
+    }
+
+    function cell(row, text, cls) {
+        var td = document.createElement('td');
+        td.textContent = text;
+        if (cls) td.className = cls;
+        row.appendChild(td);
+        return td;
+    }
+
+    function pad(n) {
+        return n < 10 ? '0' + n : '' + n;
+    }
+
+    function shortDate(iso) {
+        var d = new Date(iso);
+        return pad(d.getDate()) + '.' + pad(d.getMonth() + 1) + ' ' + pad(d.getHours()) + ':' + pad(d.getMinutes());
+    }
+
+    // actionForm builds an operator form with optional decimal inputs, as rendered by the template.
+    function actionForm(action, confirmText, fields, label, cls) {
+        var form = document.createElement('form');
+        form.method = 'post';
+        form.action = action;
+        form.dataset.confirm = confirmText;
+        fields.forEach(function (f) {
+            var input = document.createElement('input');
+            input.type = 'text';
+            input.name = f[0];
+            input.placeholder = f[1];
+            input.inputMode = 'decimal';
+            form.appendChild(input);
+        });
+        var button = document.createElement('button');
+        button.type = 'submit';
+        button.textContent = label;
+        if (cls) button.className = cls;
+        form.appendChild(button);
+        return form;
+    }
+
+    function positionActions(row, p) {
+        var td = cell(row, '', 'position-actions');
+        td.appendChild(actionForm('/trades/' + p.trade_id + '/stops', 'Переставить стоп-заявки ' + p.ticker + '?',
+            [['stop_loss', 'SL'], ['take_profit', 'TP']], 'SL/TP'));
+        td.appendChild(actionForm('/positions/' + encodeURIComponent(p.ticker) + '/close',
+            'Закрыть позицию ' + p.ticker + ' по рынку?', [], 'Закрыть', 'danger'));
+    }
+
+    function renderPositions(view) {
+        setStat('total-rub', money(view.total_rub));
+        setStat('available-rub', money(view.available_rub));
+        setStat('daily-pnl', money(view.daily_pnl, true), view.daily_pnl);
+        setStat('total-pnl', money(view.total_pnl, true), view.total_pnl);
+
+        var positions = view.positions || [];
+        var section = document.getElementById('positions');
+        var body = document.getElementById('positions-body');
+        var controls = body.parentNode.hasAttribute('data-controls');
+        // Do not wipe an SL/TP the operator is typing; the next update will catch up
+        if (controls && body.contains(document.activeElement)) return;
+        document.getElementById('positions-count').textContent = positions.length;
+        section.hidden = positions.length === 0;
+
+        body.textContent = '';
+        positions.forEach(function (p) {
+            var row = document.createElement('tr');
+            var ticker = document.createElement('strong');
+            ticker.textContent = p.ticker;
+            cell(row, '').appendChild(ticker);
+            cell(row, p.price.toFixed(2));
+            cell(row, p.current_price > 0 ? p.current_price.toFixed(2) : '—');
+            cell(row, p.quantity);
+            cell(row, p.stop_loss_price.toFixed(2));
+            cell(row, p.take_profit_price.toFixed(2));
+            if (p.current_price > 0) {
+                var pct = (p.pnl_percent > 0 ? '+' : '') + p.pnl_percent.toFixed(1) + '%';
+                cell(row, (p.pnl > 0 ? '+' : '') + p.pnl.toFixed(2) + ' (' + pct + ')', signClass(p.pnl));
+            } else {
+                cell(row, '—');
+            }
+            cell(row, shortDate(p.created_at));
+            if (controls) positionActions(row, p);
+            body.appendChild(row);
+
+            if (p.reasoning) {
+                var reason = document.createElement('tr');
+                reason.className = 'reasoning-row';
+                var td = cell(reason, '');
+                td.colSpan = controls ? 9 : 8;
+                var em = document.createElement('em');
+                em.textContent = p.reasoning;
+                td.appendChild(em);
+                body.appendChild(reason);
+            }
+        });
+    }
+
+    var describe = {
+        cycle_started: function () {
+            return ['Цикл анализа начат', ''];
+        },
+        cycle_finished: function (d) {
+            if (!d.ok) return ['Цикл завершён с ошибкой' + (d.error ? ': ' + d.error : ''), 'negative'];
+            return ['Цикл завершён за ' + (d.duration_ms / 1000).toFixed(1) + ' с: решений ' + d.decisions +
+                ', разрешено ' + d.allowed + ', заблокировано ' + d.blocked, ''];
+        },
+        decision: function (d) {
+            return ['AI: ' + d.action + ' ' + d.ticker + ' (' + d.confidence + '%)', ''];
+        },
+        blocked: function (d) {
+            return ['Заблокировано ' + d.action + ' ' + d.ticker + ': ' + d.reason, 'muted'];
+        },
+        order: function (d) {
+            var price = d.limit_price ? ' по ' + d.limit_price.toFixed(2) : ' по рынку';
+            return ['Заявка ' + d.action + ' ' + d.ticker + ', ' + d.lots + ' лот.' + price, ''];
+        },
+        fill: function (d) {
+            var text = 'Исполнено ' + d.action + ' ' + d.ticker + ', ' + d.lots + ' лот. по ' + d.price.toFixed(2);
+            if (d.action === 'SELL') text += ', P&L ' + money(d.pnl, true);
+            return [text, d.action === 'SELL' ? signClass(d.pnl) : 'positive'];
+        },
+        order_failed: function (d) {
+            return ['Ошибка заявки ' + d.action + ' ' + d.ticker + ': ' + d.error, 'negative'];
+        }
+    };
+
+    function addActivity(ev) {
+        var fn = describe[ev.type];
+        if (!fn) return;
+        var parts = fn(ev.data || {});
+
+        var empty = feed.querySelector('.empty');
+        if (empty) empty.remove();
+
+        var li = document.createElement('li');
+        var time = document.createElement('span');
+        time.className = 'time';
+        var d = new Date(ev.time);
+        time.textContent = pad(d.getHours()) + ':' + pad(d.getMinutes()) + ':' + pad(d.getSeconds());
+        var text = document.createElement('span');
+        text.textContent = parts[0];
+        if (parts[1]) text.className = parts[1];
+        li.appendChild(time);
+        li.appendChild(text);
+        feed.insertBefore(li, feed.firstChild);
+
+        while (feed.children.length > MAX_FEED) feed.removeChild(feed.lastChild);
+    }
+
+    if (!window.EventSource || !feed) return;
+
+    var source = new EventSource('/api/v1/events');
+    source.onopen = function () { status.classList.add('connected'); };
+    source.onerror = function () { status.classList.remove('connected'); };
+
+    source.addEventListener('positions', function (e) {
+        renderPositions(JSON.parse(e.data).data);
+    });
+    Object.keys(describe).forEach(function (type) {
+        source.addEventListener(type, function (e) {
+            addActivity(JSON.parse(e.data));
+        });
+    });
+})();